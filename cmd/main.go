@@ -1,13 +1,19 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"workout/internal/adapters/postgres"
+	"workout/internal/adapters/filestorage"
+	"workout/internal/adapters/mailer"
+	"workout/internal/adapters/ratelimit"
+	"workout/internal/adapters/repository"
 	"workout/internal/config"
 	handler "workout/internal/controller"
+	"workout/internal/entity"
+	"workout/internal/lib/jwt"
 	"workout/internal/service/activity"
 	"workout/internal/service/auth"
 
@@ -15,13 +21,13 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
-// [] добавить возможность загрузки нескольких тренировок
+// [x] добавить возможность загрузки нескольких тренировок
 // [] вынести в пакет utils convertDistance
 // [] Создать структуру ответа после парсинга фит файлаы
 // [x] Заменить handler WorkoutsHandler на новые handlers
 // [x] Полключтить Postgres
 // [x] Настроить сохранение тренировок в бд
-// - Сделать эндпоинт для редактирования тренировки
+// [x] Сделать эндпоинт для редактирования тренировки
 // - Сделать дефолтное имя тренировки
 // - Сделать график с объемом за неделю, месяц, год
 // - Вывести рекорды по трассам
@@ -45,34 +51,125 @@ func main() {
 	if err != nil {
 		log.Fatal("Ошибка загрузки конфигурации: ", err)
 	}
-	fmt.Printf("%+v", cfg)
 
 	e := echo.New()
 
+	// По умолчанию Echo верит X-Forwarded-For, и лимиты по IP обходились бы подменой заголовка
+	if cfg.Server.TrustProxy {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader()
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	// Логирование и восстановление после паники
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(corsMiddleware)
 
-	repo, err := postgres.NewPostgresAdapter(cfg.Database)
+	repo, err := repository.New(cfg.Storage, cfg.Database)
 	if err != nil {
-		return
+		log.Fatal("Ошибка подключения хранилища: ", err)
 	}
+	defer repo.Close()
 
-	auth := auth.NewAuthService(repo, cfg.Auth.TokenTTL)
-	svc := activity.NewWorkoutService(repo)
-	h := handler.NewController(svc, auth)
+	files, err := filestorage.New(context.Background(), cfg.Files)
+	if err != nil {
+		log.Fatal("Ошибка подключения хранилища файлов: ", err)
+	}
+
+	keys, err := jwt.LoadKeys(cfg.Auth)
+	if errors.Is(err, jwt.ErrNoSigningKey) && cfg.Server.Environment == "development" {
+		log.Println("Ключ подписи токенов не задан, используется случайный: токены не переживут перезапуск")
+		keys, err = jwt.NewEphemeralKeys()
+	}
+	if err != nil {
+		log.Fatal("Ошибка загрузки ключей подписи токенов: ", err)
+	}
+
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatal("Ошибка настройки отправки писем: ", err)
+	}
 
-	e.POST("/login", h.Login)
-	e.POST("/register", h.Register)
+	lockout := auth.LockoutPolicy{
+		Threshold:   cfg.Auth.LockoutThreshold,
+		Duration:    cfg.Auth.LockoutDuration,
+		MaxDuration: cfg.Auth.LockoutMaxDuration,
+	}
+	auth := auth.NewAuthService(repo, keys, cfg.Auth.TokenTTL, cfg.Auth.RefreshTokenTTL)
+	auth.AdminEmails = cfg.Auth.AdminEmails
+	auth.Mailer = mail
+	auth.VerifyURL = cfg.Auth.VerifyURL
+	auth.ResetURL = cfg.Auth.PasswordResetURL
+	auth.VerifyTTL = cfg.Auth.VerifyTokenTTL
+	auth.ResetTTL = cfg.Auth.ResetTokenTTL
+	auth.RequireVerifiedEmail = cfg.Auth.RequireVerifiedEmail
+	auth.Lockout = lockout
+	svc := activity.NewWorkoutService(repo, files)
+	svc.TrashRetention = cfg.Trash.Retention
+	go svc.RunTrashPurge(context.Background(), cfg.Trash.PurgeInterval)
+
+	limiter, err := ratelimit.New(cfg.Limits)
+	if err != nil {
+		log.Fatal("Ошибка настройки ограничения частоты запросов: ", err)
+	}
+	loginLimit := handler.RateLimit(limiter, "login", ratelimit.Rule{Burst: cfg.Limits.LoginPerIP, Period: cfg.Limits.LoginWindow})
+	registerLimit := handler.RateLimit(limiter, "register", ratelimit.Rule{Burst: cfg.Limits.RegisterPerIP, Period: cfg.Limits.RegisterWindow})
+	mailLimit := handler.RateLimit(limiter, "mail", ratelimit.Rule{Burst: cfg.Limits.RegisterPerIP, Period: cfg.Limits.RegisterWindow})
 
-	api := e.Group("/api", handler.JWTMiddleware([]byte("")))
+	h := handler.NewController(svc, auth)
+	h.Limiter = limiter
+	h.LoginAccountRule = ratelimit.Rule{Burst: cfg.Limits.LoginPerAccount, Period: cfg.Limits.LoginWindow}
+
+	e.POST("/login", h.Login, loginLimit)
+	e.POST("/register", h.Register, registerLimit)
+	e.POST("/auth/refresh", h.Refresh)      // Обмен refresh-токена на новую пару токенов
+	e.GET("/.well-known/jwks.json", h.JWKS) // Открытые ключи для проверки токенов другими сервисами
+
+	e.GET("/auth/verify", h.VerifyEmail)                           // Переход по ссылке подтверждения почты (?token=)
+	e.POST("/auth/verify", h.VerifyEmail)                          // Подтверждение почты токеном в теле запроса
+	e.POST("/auth/verify/resend", h.ResendVerification, mailLimit) // Повторное письмо подтверждения
+	e.POST("/auth/password/forgot", h.ForgotPassword, mailLimit)   // Письмо со ссылкой сброса пароля
+	e.POST("/auth/password/reset", h.ResetPassword)                // Новый пароль по токену из письма
+
+	// Управлять сессиями и персональными токенами можно только из сессии, не по персональному токену
+	session := e.Group("/auth", handler.JWTMiddleware(keys, nil))
+	session.POST("/logout", h.Logout)                  // Завершение текущей сессии
+	session.GET("/sessions", h.GetSessions)            // Активные сессии пользователя по устройствам
+	session.DELETE("/sessions/:id", h.RevokeSession)   // Завершение сессии на другом устройстве
+	session.POST("/tokens", h.CreateAccessToken)       // Персональный токен для скриптов и устройств синхронизации
+	session.GET("/tokens", h.GetAccessTokens)          // Персональные токены пользователя
+	session.DELETE("/tokens/:id", h.RevokeAccessToken) // Отзыв персонального токена
+
+	// Вместо JWT можно передать персональный токен: RequireScope задаёт, какие маршруты он открывает
+	api := e.Group("/api", handler.JWTMiddleware(keys, auth))
+
+	// Загружать и менять тренировки может спортсмен; тренер без этой роли только просматривает
+	writeWorkouts := handler.RequirePermission(entity.PermWorkoutsWrite)
+	readScope := handler.RequireScope(entity.ScopeWorkoutsRead)
+	writeScope := handler.RequireScope(entity.ScopeWorkoutsWrite)
+	uploadScope := handler.RequireScope(entity.ScopeUpload, entity.ScopeWorkoutsWrite)
+
+	api.POST("/v1/workouts/upload", h.UploadHandler, writeWorkouts, uploadScope) // Загрузка одного или нескольких файлов, в том числе ZIP-архивов
+	api.POST("/v1/workout", h.CreateWorkout, writeWorkouts, writeScope)
+	api.GET("/v1/workouts", h.GetWorkouts, readScope)
+	api.GET("/v1/workouts/trash", h.GetTrash, readScope)                              // Удалённые тренировки, которые ещё можно восстановить
+	api.GET("/v1/workouts/:id", h.GetWorkout, readScope)                              // Детальная информация: сводка, круги, отрезки и трек (?points=N)
+	api.PATCH("/v1/workouts/:id", h.UpdateWorkout, writeWorkouts, writeScope)         // Изменение тренировки (например, добавление заметок), требует If-Match
+	api.DELETE("/v1/workouts/:id", h.DeleteWorkout, writeWorkouts, writeScope)        // Удаление в корзину
+	api.POST("/v1/workouts/:id/restore", h.RestoreWorkout, writeWorkouts, writeScope) // Восстановление из корзины в течение срока хранения
+	api.GET("/v1/workouts/:id/laps", h.GetLaps, readScope)                            // Круги тренировки, размеченные устройством
+	api.GET("/v1/workouts/:id/splits", h.GetSplits, readScope)                        // Отрезки по 1 км / 1 миле / своей дистанции (?unit=km|mi|custom&distance=м)
+	api.GET("/v1/workouts/:id/original", h.GetOriginal, readScope)                    // Скачивание исходного файла тренировки
+
+	// Администрирование недоступно по персональным токенам: у них нет области для админских действий,
+	// а утечка токена из скрипта не должна давать права администратора
+	admin := api.Group("/v1/admin", handler.DenyAccessTokens())
+	admin.POST("/reprocess", h.Reprocess, handler.RequirePermission(entity.PermWorkoutsReprocess))                // Повторный разбор исходных файлов (?user_id=&dry_run=true)
+	admin.GET("/users", h.GetUsers, handler.RequirePermission(entity.PermUsersManage))                            // Пользователи и их роли
+	admin.PUT("/users/:id/roles", h.SetUserRoles, handler.RequirePermission(entity.PermUsersManage))              // Назначение ролей athlete, coach, admin
+	admin.GET("/users/:id/login-attempts", h.GetLoginAttempts, handler.RequirePermission(entity.PermUsersManage)) // Журнал неудачных попыток входа
 
-	api.POST("/v1/workout/upload", h.UploadHandler)
-	api.POST("/v1/workout", h.CreateWorkout)
-	api.PUT("/v1/workout/{id}", h.UpdateWorkout) // Обновление тренировки (например, добавление заметок)
-	api.GET("/v1/workouts", h.GetWorkouts)
-	// r.Get("/api/v1/workouts/{id}", handler.WorkoutHandler)
 	// r.Get("/api/v1/workouts/{id}/pacechart", handler.PaceChartHandler) // Получаем пейс для построения графика темпа
 	// r.Get("/", handler.HomeHandler)
 
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"workout/internal/adapters/filestorage"
+	"workout/internal/adapters/repository"
+	"workout/internal/config"
+	"workout/internal/dto"
+	"workout/internal/service/activity"
+)
+
+// Повторный разбор сохранённых исходных файлов тренировок.
+// Применяет исправления парсера к уже загруженным тренировкам:
+//
+//	go run ./cmd/reprocess -user <uuid> [-dry-run]
+//	go run ./cmd/reprocess -all [-dry-run]
+func main() {
+	var (
+		envPath = flag.String("config", "config/.env", "путь к файлу конфигурации")
+		userID  = flag.String("user", "", "id пользователя, чьи тренировки нужно разобрать")
+		all     = flag.Bool("all", false, "разобрать тренировки всех пользователей")
+		dryRun  = flag.Bool("dry-run", false, "только показать изменения, ничего не сохраняя")
+	)
+	flag.Parse()
+
+	if (*userID == "") == !*all {
+		fmt.Fprintln(os.Stderr, "нужно указать ровно один из флагов -user или -all")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := config.LoadConfig(*envPath)
+	if err != nil {
+		log.Fatal("Ошибка загрузки конфигурации: ", err)
+	}
+
+	repo, err := repository.New(cfg.Storage, cfg.Database)
+	if err != nil {
+		log.Fatal("Ошибка подключения хранилища: ", err)
+	}
+	defer repo.Close()
+
+	files, err := filestorage.New(ctx, cfg.Files)
+	if err != nil {
+		log.Fatal("Ошибка подключения хранилища файлов: ", err)
+	}
+
+	svc := activity.NewWorkoutService(repo, files)
+	results, err := svc.Reprocess(ctx, activity.ReprocessOptions{UserID: *userID, DryRun: *dryRun})
+
+	var updated, unchanged, skipped, failed int
+	for _, r := range results {
+		switch r.Status {
+		case dto.ReprocessStatusUpdated:
+			updated++
+			fmt.Printf("%s (пользователь %s): изменена\n", r.WorkoutID, r.UserID)
+			for _, c := range r.Changes {
+				fmt.Printf("    %s: %q -> %q\n", c.Field, c.Old, c.New)
+			}
+			if r.PointsChanged > 0 {
+				fmt.Printf("    точек трека: %d\n", r.PointsChanged)
+			}
+			if r.LapsChanged > 0 {
+				fmt.Printf("    кругов: %d\n", r.LapsChanged)
+			}
+		case dto.ReprocessStatusUnchanged:
+			unchanged++
+		case dto.ReprocessStatusSkipped:
+			skipped++
+			fmt.Printf("%s (пользователь %s): пропущена: %v\n", r.WorkoutID, r.UserID, r.Err)
+		case dto.ReprocessStatusError:
+			failed++
+			fmt.Printf("%s (пользователь %s): ошибка: %v\n", r.WorkoutID, r.UserID, r.Err)
+		}
+	}
+
+	mode := "обновлено"
+	if *dryRun {
+		mode = "будет обновлено"
+	}
+	fmt.Printf("\nВсего: %d, %s: %d, без изменений: %d, пропущено: %d, ошибок: %d\n", len(results), mode, updated, unchanged, skipped, failed)
+
+	if err != nil {
+		log.Fatal("Повторный разбор прерван: ", err)
+	}
+}
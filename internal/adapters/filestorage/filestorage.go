@@ -0,0 +1,26 @@
+package filestorage
+
+import (
+	"context"
+	"fmt"
+	"workout/internal/config"
+)
+
+// Storage - общий интерфейс локального и S3 хранилищ
+type Storage interface {
+	Save(ctx context.Context, key string, data []byte) error
+	Load(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New создаёт хранилище исходных файлов по типу из конфигурации
+func New(ctx context.Context, cfg config.FileStorageConfig) (Storage, error) {
+	switch cfg.Type {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalPath)
+	case "s3":
+		return NewS3Storage(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("неизвестный тип хранилища файлов: %q", cfg.Type)
+	}
+}
@@ -0,0 +1,32 @@
+package filestorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, s.Save(ctx, "originals/user/abc", []byte("data")))
+
+	data, err := s.Load(ctx, "originals/user/abc")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	require.NoError(t, s.Delete(ctx, "originals/user/abc"))
+	_, err = s.Load(ctx, "originals/user/abc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Повторное удаление не ошибка
+	assert.NoError(t, s.Delete(ctx, "originals/user/abc"))
+
+	for _, key := range []string{"", "../escape", "/etc/passwd", "a/../../b"} {
+		assert.ErrorIs(t, s.Save(ctx, key, nil), ErrInvalidKey, key)
+	}
+}
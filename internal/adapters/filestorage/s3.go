@@ -0,0 +1,88 @@
+package filestorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"workout/internal/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3 хранит файлы в S3-совместимом хранилище (AWS S3, MinIO, Yandex Object Storage ...)
+type s3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(ctx context.Context, cfg config.FileStorageConfig) (*s3, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания клиента S3: %w", err)
+	}
+
+	// Проверяем, что бакет существует и доступен с этими ключами
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к S3: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("бакет %q не найден", cfg.S3Bucket)
+	}
+
+	return &s3{client: client, bucket: cfg.S3Bucket}, nil
+}
+
+func (s *s3) Save(ctx context.Context, key string, data []byte) error {
+	const op = "filestorage.s3.Save"
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+		// Подписываем запрос без хэша тела: файл уже целиком в памяти,
+		// а часть S3-совместимых хранилищ не поддерживает потоковую подпись
+		DisableContentSha256: true,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *s3) Load(ctx context.Context, key string) ([]byte, error) {
+	const op = "filestorage.s3.Load"
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.convertErr(err))
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, s.convertErr(err))
+	}
+	return data, nil
+}
+
+func (s *s3) Delete(ctx context.Context, key string) error {
+	const op = "filestorage.s3.Delete"
+
+	// S3 не возвращает ошибку при удалении несуществующего объекта
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *s3) convertErr(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// file сохраняет каждое письмо в отдельный файл .eml, который открывается почтовым клиентом
+type file struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*file, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога писем: %w", err)
+	}
+	return &file{dir: dir, from: from}, nil
+}
+
+func (f *file) Send(_ context.Context, msg Message) error {
+	const op = "mailer.file.Send"
+
+	now := time.Now()
+	data, err := build(f.from, msg, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Время в имени сохраняет порядок писем, случайный суффикс исключает совпадения
+	out, err := os.CreateTemp(f.dir, now.UTC().Format("20060102T150405.000000000")+"-*.eml")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := out.Write(data); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewFileMailer(dir, "AthleticHub <no-reply@example.com>")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), Message{
+		To:      "runner@example.com",
+		Subject: "Сброс пароля",
+		Body:    "Ссылка:\nhttp://localhost/reset-password?token=abc",
+	}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	f, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer f.Close()
+
+	msg, err := mail.ReadMessage(f)
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Сброс пароля", subject)
+	assert.Equal(t, "<runner@example.com>", msg.Header.Get("To"))
+	assert.NotEmpty(t, msg.Header.Get("Message-ID"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Ссылка:\r\nhttp://localhost/reset-password?token=abc\r\n", string(body))
+
+	// Перевод строки в теме не даёт дописать заголовки
+	assert.Error(t, m.Send(context.Background(), Message{To: "runner@example.com", Subject: "a\r\nBcc: x@example.com"}))
+}
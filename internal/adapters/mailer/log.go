@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// logMailer не отправляет письма, а пишет их в журнал. Подходит для разработки:
+// ссылку подтверждения можно взять прямо из вывода сервера
+type logMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *logMailer {
+	return &logMailer{from: from}
+}
+
+func (l *logMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("письмо от %s для %s: %s\n%s", l.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
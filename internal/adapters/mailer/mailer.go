@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"workout/internal/config"
+)
+
+// Message - письмо пользователю. Body - обычный текст
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создаёт отправителя писем по типу из конфигурации
+func New(cfg config.MailerConfig) (Mailer, error) {
+	switch cfg.Type {
+	case "", "log":
+		return NewLogMailer(cfg.From), nil
+	case "file":
+		return NewFileMailer(cfg.FilePath, cfg.From)
+	case "smtp":
+		return NewSMTPMailer(cfg)
+	default:
+		return nil, fmt.Errorf("неизвестный тип отправки писем: %q", cfg.Type)
+	}
+}
@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+	"workout/internal/config"
+)
+
+// smtpMailer отправляет письма через SMTP-сервер. Если сервер поддерживает STARTTLS,
+// соединение шифруется до передачи пароля
+type smtpMailer struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTPMailer(cfg config.MailerConfig) (*smtpMailer, error) {
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("не указан SMTP_HOST")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("некорректный MAIL_FROM %q: %w", cfg.From, err)
+	}
+	return &smtpMailer{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:     cfg.SMTPHost,
+		from:     cfg.From,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+	}, nil
+}
+
+func (s *smtpMailer) Send(ctx context.Context, msg Message) error {
+	const op = "mailer.smtp.Send"
+
+	data, err := build(s.from, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.send(ctx, msg.To, data); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *smtpMailer) send(ctx context.Context, to string, data []byte) error {
+	// Адреса уже проверены в build
+	sender, _ := mail.ParseAddress(s.from)
+	rcpt, _ := mail.ParseAddress(to)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	// smtp.Client не знает о контексте, поэтому ограничиваем весь обмен его сроком
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.username != "" {
+		// PlainAuth сам откажется передавать пароль по нешифрованному соединению не на localhost
+		if err := c.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(sender.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// accessToken - персональный токен вместе с хэшем, по которому его находят
+type accessToken struct {
+	entity.AccessToken
+	hash string
+}
+
+func (t *accessToken) copy() entity.AccessToken {
+	c := t.AccessToken
+	c.Scopes = slices.Clone(t.Scopes)
+	return c
+}
+
+// CreateAccessToken сохраняет персональный токен доступа
+func (m *memory) CreateAccessToken(_ context.Context, t *entity.AccessToken, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token := &accessToken{AccessToken: *t, hash: tokenHash}
+	token.Scopes = slices.Clone(t.Scopes)
+	m.accessTokens[t.ID] = token
+	return nil
+}
+
+// GetAccessToken возвращает токен по хэшу, в том числе отозванный или истёкший
+func (m *memory) GetAccessToken(_ context.Context, tokenHash string) (*entity.AccessToken, error) {
+	const op = "storage.memory.AccessToken"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, t := range m.accessTokens {
+		if t.hash == tokenHash {
+			c := t.copy()
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+}
+
+// ListAccessTokens возвращает неотозванные токены пользователя, новые первыми
+func (m *memory) ListAccessTokens(_ context.Context, userID uuid.UUID) ([]entity.AccessToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tokens := []entity.AccessToken{}
+	for _, t := range m.accessTokens {
+		if t.UserID == userID && t.RevokedAt.IsZero() {
+			tokens = append(tokens, t.copy())
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// RevokeAccessToken отзывает токен пользователя. Чужой или уже отозванный токен - storage.ErrTokenNotFound
+func (m *memory) RevokeAccessToken(_ context.Context, userID, tokenID uuid.UUID, now time.Time) error {
+	const op = "storage.memory.RevokeAccessToken"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.accessTokens[tokenID]
+	if !ok || t.UserID != userID || !t.RevokedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+	t.RevokedAt = now
+	return nil
+}
+
+// RevokeUserAccessTokens отзывает все действующие токены пользователя, например после сброса пароля
+func (m *memory) RevokeUserAccessTokens(_ context.Context, userID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.accessTokens {
+		if t.UserID == userID && t.RevokedAt.IsZero() {
+			t.RevokedAt = now
+		}
+	}
+	return nil
+}
+
+// TouchAccessToken запоминает время последнего использования токена
+func (m *memory) TouchAccessToken(_ context.Context, tokenID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t, ok := m.accessTokens[tokenID]; ok {
+		t.LastUsedAt = now
+	}
+	return nil
+}
@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+func (m *memory) CreateWorkout(_ context.Context, workout *entity.Workout) (*entity.Workout, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workout.ID = id
+	workout.Version = 1
+
+	stored := summary(workout)
+	if stored.CreatedAt.IsZero() {
+		stored.CreatedAt = time.Now()
+	}
+	if stored.UpdatedAt.IsZero() {
+		stored.UpdatedAt = stored.CreatedAt
+	}
+	m.workouts[id] = stored
+	m.points[id] = cloneSlice(workout.RecordData)
+	m.laps[id] = cloneSlice(workout.Laps)
+
+	return workout, nil
+}
+
+// cmpFuncs сравнивают тренировки по полю сортировки, как это делает ORDER BY в postgres
+var cmpFuncs = map[string]func(a, b *entity.Workout) int{
+	dto.SortByDate: func(a, b *entity.Workout) int { return a.Date.Compare(b.Date) },
+	dto.SortByDistance: func(a, b *entity.Workout) int {
+		return cmp.Compare(parseDistance(a.Distance), parseDistance(b.Distance))
+	},
+	dto.SortByDuration:     func(a, b *entity.Workout) int { return cmp.Compare(a.Duration, b.Duration) },
+	dto.SortByAvgPace:      func(a, b *entity.Workout) int { return cmp.Compare(a.AvgPace, b.AvgPace) },
+	dto.SortByAvgHeartRate: func(a, b *entity.Workout) int { return cmp.Compare(a.AvgHeartRate, b.AvgHeartRate) },
+	dto.SortByCalories:     func(a, b *entity.Workout) int { return cmp.Compare(a.Calories, b.Calories) },
+}
+
+// GetWorkouts возвращает страницу тренировок пользователя с учётом фильтров и сортировки.
+// Для следующей страницы используется курсор (keyset): (поле сортировки, id) последней строки
+func (m *memory) GetWorkouts(_ context.Context, userID uuid.UUID, f dto.WorkoutFilter) ([]entity.Workout, error) {
+	cmpField, ok := cmpFuncs[f.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", f.SortBy)
+	}
+
+	// Сравнение по полю сортировки, при равенстве - по id
+	compare := func(a, b *entity.Workout) int {
+		if c := cmpField(a, b); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID.Bytes(), b.ID.Bytes())
+	}
+	if !f.SortAsc {
+		asc := compare
+		compare = func(a, b *entity.Workout) int { return asc(b, a) }
+	}
+
+	var after *entity.Workout
+	if f.After != nil {
+		w, err := cursorWorkout(f.SortBy, f.After)
+		if err != nil {
+			return nil, err
+		}
+		after = w
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []*entity.Workout
+	for _, w := range m.workouts {
+		if w.UserID != userID || !w.DeletedAt.IsZero() || !matchFilter(w, f) {
+			continue
+		}
+		if after != nil && compare(w, after) <= 0 {
+			continue
+		}
+		matched = append(matched, w)
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return compare(matched[i], matched[j]) < 0 })
+
+	if f.Offset > 0 {
+		matched = matched[min(f.Offset, len(matched)):]
+	}
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+	}
+
+	return copies(matched), nil
+}
+
+// matchFilter проверяет условия фильтра, кроме курсора
+func matchFilter(w *entity.Workout, f dto.WorkoutFilter) bool {
+	if !f.StartDate.IsZero() && w.Date.Before(f.StartDate) {
+		return false
+	}
+	// Конец периода включительно: до начала следующего дня
+	if !f.EndDate.IsZero() && !w.Date.Before(f.EndDate.AddDate(0, 0, 1)) {
+		return false
+	}
+	if f.SportType != "" && w.SportType != f.SportType {
+		return false
+	}
+
+	// Как и в SQL, тренировка без дистанции не проходит фильтр по дистанции
+	distance, hasDistance := parseDistanceOK(w.Distance)
+	if f.MinDistance > 0 && (!hasDistance || distance < f.MinDistance) {
+		return false
+	}
+	if f.MaxDistance > 0 && (!hasDistance || distance > f.MaxDistance) {
+		return false
+	}
+
+	if f.MinDuration > 0 && w.Duration < f.MinDuration {
+		return false
+	}
+	if f.MaxDuration > 0 && w.Duration > f.MaxDuration {
+		return false
+	}
+	if f.Query != "" {
+		q := strings.ToLower(f.Query)
+		if !strings.Contains(strings.ToLower(w.Name), q) && !strings.Contains(strings.ToLower(w.Description), q) {
+			return false
+		}
+	}
+	return true
+}
+
+// cursorWorkout строит тренировку с полем сортировки из курсора, чтобы сравнивать её с остальными
+func cursorWorkout(sortBy string, c *dto.WorkoutCursor) (*entity.Workout, error) {
+	w := &entity.Workout{ID: c.ID}
+
+	var err error
+	switch sortBy {
+	case dto.SortByDate:
+		w.Date, err = time.Parse(time.RFC3339Nano, c.Value)
+	case dto.SortByDistance:
+		_, err = strconv.ParseFloat(c.Value, 64)
+		w.Distance = c.Value
+	case dto.SortByDuration:
+		var d int64
+		d, err = strconv.ParseInt(c.Value, 10, 64)
+		w.Duration = time.Duration(d)
+	case dto.SortByAvgPace:
+		w.AvgPace, err = strconv.Atoi(c.Value)
+	case dto.SortByAvgHeartRate:
+		w.AvgHeartRate, err = strconv.Atoi(c.Value)
+	case dto.SortByCalories:
+		var c64 uint64
+		c64, err = strconv.ParseUint(c.Value, 10, 16)
+		w.Calories = uint16(c64)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor value %q: %w", c.Value, err)
+	}
+	return w, nil
+}
+
+// FindWorkoutByHash ищет тренировку пользователя, загруженную из файла с тем же содержимым
+func (m *memory) FindWorkoutByHash(_ context.Context, userID uuid.UUID, hash string) (*entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, w := range m.workouts {
+		if w.UserID == userID && w.FileHash == hash && hash != "" && w.DeletedAt.IsZero() {
+			return clone(w), nil
+		}
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+// FindWorkoutsByStartTime возвращает тренировки пользователя, начавшиеся в интервале [from, to]
+func (m *memory) FindWorkoutsByStartTime(_ context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []*entity.Workout
+	for _, w := range m.workouts {
+		if w.UserID != userID || w.StartTime.IsZero() || !w.DeletedAt.IsZero() {
+			continue
+		}
+		if w.StartTime.Before(from) || w.StartTime.After(to) {
+			continue
+		}
+		found = append(found, w)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].StartTime.Before(found[j].StartTime) })
+	return copies(found), nil
+}
+
+// GetWorkoutOriginal возвращает тренировку пользователя со ссылкой на исходный файл
+func (m *memory) GetWorkoutOriginal(_ context.Context, userID, workoutID uuid.UUID) (*entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.workouts[workoutID]
+	if !ok || w.UserID != userID || !w.DeletedAt.IsZero() {
+		return nil, storage.ErrWorkoutNotFound
+	}
+	return clone(w), nil
+}
+
+// ListWorkoutsWithOriginal возвращает тренировки с сохранённым исходным файлом, упорядоченные по id.
+// Нулевой userID - тренировки всех пользователей; after - id последней тренировки предыдущей страницы
+func (m *memory) ListWorkoutsWithOriginal(_ context.Context, userID, after uuid.UUID, limit int) ([]entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []*entity.Workout
+	for _, w := range m.workouts {
+		if w.OriginalKey == "" || !w.DeletedAt.IsZero() {
+			continue
+		}
+		if !userID.IsNil() && w.UserID != userID {
+			continue
+		}
+		if bytes.Compare(w.ID.Bytes(), after.Bytes()) <= 0 {
+			continue
+		}
+		found = append(found, w)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return bytes.Compare(found[i].ID.Bytes(), found[j].ID.Bytes()) < 0 })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return copies(found), nil
+}
+
+// UpdateWorkoutData обновляет рассчитанные из файла поля тренировки и увеличивает её версию,
+// если сохранённая версия совпадает с w.Version, иначе возвращает storage.ErrVersionConflict.
+// Новая версия записывается в w.Version. Если переданы записи или круги, они полностью заменяют сохранённые.
+// Время слияния только устанавливается: пустое w.MergedAt не сбрасывает сохранённое
+func (m *memory) UpdateWorkoutData(_ context.Context, w *entity.Workout) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.workouts[w.ID]
+	if !ok {
+		return storage.ErrWorkoutNotFound
+	}
+	if stored.Version != w.Version {
+		return storage.ErrVersionConflict
+	}
+
+	stored.SportType = w.SportType
+	stored.Duration = w.Duration
+	stored.Distance = w.Distance
+	stored.AvgPace = w.AvgPace
+	stored.AvgHeartRate = w.AvgHeartRate
+	stored.MaxHeartRate = w.MaxHeartRate
+	stored.AvgCadence = w.AvgCadence
+	stored.Calories = w.Calories
+	stored.StartTime = w.StartTime
+	stored.FileHash = w.FileHash
+	if !w.MergedAt.IsZero() {
+		stored.MergedAt = w.MergedAt
+	}
+	stored.UpdatedAt = time.Now()
+	stored.Version++
+	w.Version = stored.Version
+
+	if w.RecordData != nil {
+		m.points[w.ID] = cloneSlice(w.RecordData)
+	}
+	if w.Laps != nil {
+		m.laps[w.ID] = cloneSlice(w.Laps)
+	}
+	return nil
+}
+
+// GetWorkoutByID возвращает тренировку по id без проверки владельца. Удалённые в корзину не возвращаются
+func (m *memory) GetWorkoutByID(_ context.Context, id uuid.UUID) (*entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	w, ok := m.workouts[id]
+	if !ok || !w.DeletedAt.IsZero() {
+		return nil, storage.ErrWorkoutNotFound
+	}
+	return clone(w), nil
+}
+
+// UpdateWorkout меняет переданные поля тренировки, если её версия всё ещё равна u.Version,
+// и возвращает тренировку с новой версией
+func (m *memory) UpdateWorkout(_ context.Context, u dto.UpdateWorkout) (*entity.Workout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workouts[u.ID]
+	if !ok || w.Version != u.Version || !w.DeletedAt.IsZero() {
+		// Тренировка была изменена (или удалена) после того, как клиент её прочитал
+		return nil, storage.ErrVersionConflict
+	}
+
+	if u.Name != nil {
+		w.Name = *u.Name
+	}
+	if u.SportType != nil {
+		w.SportType = *u.SportType
+	}
+	if u.Duration != nil {
+		w.Duration = time.Duration(*u.Duration) * time.Second
+	}
+	if u.Distance != nil {
+		// В postgres дистанция хранится в NUMERIC(10,2)
+		w.Distance = strconv.FormatFloat(float64(*u.Distance), 'f', 2, 32)
+	}
+	if u.AvgPace != nil {
+		w.AvgPace = int(math.Round(float64(*u.AvgPace)))
+	}
+	if u.AvgHeartRate != nil {
+		w.AvgHeartRate = int(*u.AvgHeartRate)
+	}
+	if u.MaxHeartRate != nil {
+		w.MaxHeartRate = int(*u.MaxHeartRate)
+	}
+	if u.AvgCadence != nil {
+		w.AvgCadence = uint16(*u.AvgCadence)
+	}
+	if u.Calories != nil {
+		w.Calories = uint16(*u.Calories)
+	}
+	if u.Description != nil {
+		w.Description = *u.Description
+	}
+	// DeviceName в entity.Workout не хранится
+
+	now := time.Now()
+	if u.EditsFileData() {
+		// Отмечаем ручную правку, чтобы пересборка из файла её не затёрла
+		w.EditedAt = now
+	}
+	w.UpdatedAt = now
+	w.Version++
+
+	return clone(w), nil
+}
+
+// GetLaps возвращает круги тренировки, если она принадлежит пользователю
+func (m *memory) GetLaps(_ context.Context, userID, workoutID uuid.UUID) ([]entity.LapData, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.owns(userID, workoutID) {
+		return []entity.LapData{}, nil
+	}
+	return append([]entity.LapData{}, m.laps[workoutID]...), nil
+}
+
+// GetTrackPoints возвращает посекундные данные тренировки, если она принадлежит пользователю
+func (m *memory) GetTrackPoints(_ context.Context, userID, workoutID uuid.UUID) ([]entity.RecordData, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.owns(userID, workoutID) {
+		return []entity.RecordData{}, nil
+	}
+	return append([]entity.RecordData{}, m.points[workoutID]...), nil
+}
+
+// owns проверяет, что тренировка есть, не удалена и принадлежит пользователю. Вызывается под m.mu
+func (m *memory) owns(userID, workoutID uuid.UUID) bool {
+	w, ok := m.workouts[workoutID]
+	return ok && w.UserID == userID && w.DeletedAt.IsZero()
+}
+
+// summary копирует тренировку без трека и кругов - они хранятся отдельно
+func summary(w *entity.Workout) *entity.Workout {
+	c := *w
+	c.RecordData, c.Laps = nil, nil
+	return &c
+}
+
+func clone(w *entity.Workout) *entity.Workout {
+	c := *w
+	return &c
+}
+
+func copies(ws []*entity.Workout) []entity.Workout {
+	if len(ws) == 0 {
+		return nil
+	}
+	result := make([]entity.Workout, len(ws))
+	for i, w := range ws {
+		result[i] = *w
+	}
+	return result
+}
+
+// cloneSlice копирует срез; nil остаётся nil
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+func parseDistanceOK(s string) (float64, bool) {
+	d, err := strconv.ParseFloat(s, 64)
+	return d, err == nil
+}
+
+// parseDistance возвращает дистанцию в километрах; пустая дистанция считается нулевой
+func parseDistance(s string) float64 {
+	d, _ := parseDistanceOK(s)
+	return d
+}
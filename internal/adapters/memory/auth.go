@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// user - пользователь вместе с ролями (User.Roles)
+type user struct {
+	entity.User
+}
+
+func (m *memory) CreateUser(_ context.Context, email string, passHash []byte) (string, error) {
+	const op = "storage.memory.SaveUser"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[email]; ok {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().Unix()
+	m.users[email] = &user{User: entity.User{
+		ID:        id.String(),
+		Email:     email,
+		Password:  string(passHash),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+
+	return id.String(), nil
+}
+
+func (m *memory) GetUser(_ context.Context, email string) (*entity.User, error) {
+	const op = "storage.memory.User"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[email]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	// Как и postgres, возвращаем только id, email и хэш пароля
+	return &entity.User{
+		ID:            u.ID,
+		Email:         u.Email,
+		Password:      u.Password,
+		EmailVerified: u.EmailVerified,
+		FailedLogins:  u.FailedLogins,
+		LockedUntil:   u.LockedUntil,
+	}, nil
+}
+
+func (m *memory) GetUserByID(_ context.Context, userID uuid.UUID) (*entity.User, error) {
+	const op = "storage.memory.UserByID"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return &entity.User{
+		ID:            u.ID,
+		Email:         u.Email,
+		Password:      u.Password,
+		EmailVerified: u.EmailVerified,
+		FailedLogins:  u.FailedLogins,
+		LockedUntil:   u.LockedUntil,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// coachStatusAccepted - связь подтверждена спортсменом
+const coachStatusAccepted = "accepted"
+
+type coachLink struct {
+	coachID, athleteID uuid.UUID
+}
+
+// AddCoach связывает тренера и спортсмена с подтверждённым статусом.
+// В postgres связи создаются отдельно, здесь метод нужен для тестов и демо-данных
+func (m *memory) AddCoach(coachID, athleteID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.coaches[coachLink{coachID, athleteID}] = coachStatusAccepted
+}
+
+// IsCoachOf проверяет, что пользователь coachID - подтверждённый тренер спортсмена athleteID
+func (m *memory) IsCoachOf(_ context.Context, coachID, athleteID uuid.UUID) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.coaches[coachLink{coachID, athleteID}] == coachStatusAccepted, nil
+}
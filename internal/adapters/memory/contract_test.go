@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+	"workout/internal/adapters/storagetest"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return NewMemoryAdapter()
+	})
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// AddFailedLogin увеличивает счётчик неудачных попыток входа подряд и возвращает новое значение
+func (m *memory) AddFailedLogin(_ context.Context, userID uuid.UUID) (int, error) {
+	const op = "storage.memory.AddFailedLogin"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.FailedLogins++
+	return u.FailedLogins, nil
+}
+
+// LockUser запрещает вход до времени until
+func (m *memory) LockUser(_ context.Context, userID uuid.UUID, until time.Time) error {
+	const op = "storage.memory.LockUser"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.LockedUntil = until
+	return nil
+}
+
+// ResetFailedLogins обнуляет счётчик неудачных попыток и снимает блокировку
+func (m *memory) ResetFailedLogins(_ context.Context, userID uuid.UUID) error {
+	const op = "storage.memory.ResetFailedLogins"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.FailedLogins = 0
+	u.LockedUntil = time.Time{}
+	return nil
+}
+
+// AddLoginAttempt записывает неудачную попытку входа в журнал
+func (m *memory) AddLoginAttempt(_ context.Context, a *entity.LoginAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a.ID = int64(len(m.attempts) + 1)
+	m.attempts = append(m.attempts, *a)
+	return nil
+}
+
+// CountLoginAttempts возвращает число попыток входа с адресом email и причиной reason
+// и время последней из них
+func (m *memory) CountLoginAttempts(_ context.Context, email string, reason entity.LoginFailure) (int, time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		count int
+		last  time.Time
+	)
+	for _, a := range m.attempts {
+		if a.Email == email && a.Reason == reason {
+			count++
+			if a.CreatedAt.After(last) {
+				last = a.CreatedAt
+			}
+		}
+	}
+	return count, last, nil
+}
+
+// ListLoginAttempts возвращает последние limit неудачных попыток входа пользователя, новые первыми
+func (m *memory) ListLoginAttempts(_ context.Context, userID uuid.UUID, limit int) ([]entity.LoginAttempt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	attempts := []entity.LoginAttempt{}
+	for _, a := range m.attempts {
+		if a.UserID == userID {
+			attempts = append(attempts, a)
+		}
+	}
+	sort.SliceStable(attempts, func(i, j int) bool {
+		if !attempts[i].CreatedAt.Equal(attempts[j].CreatedAt) {
+			return attempts[i].CreatedAt.After(attempts[j].CreatedAt)
+		}
+		return attempts[i].ID > attempts[j].ID
+	})
+	if len(attempts) > limit {
+		attempts = attempts[:limit]
+	}
+	return attempts, nil
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_ConcurrentAccess(t *testing.T) {
+	m := NewMemoryAdapter()
+	ctx := context.Background()
+	userID := uuid.Must(uuid.NewV4())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w, err := m.CreateWorkout(ctx, &entity.Workout{UserID: userID, Date: time.Now(), Duration: time.Duration(i) * time.Minute})
+			if !assert.NoError(t, err) {
+				return
+			}
+			name := "Бег"
+			_, err = m.UpdateWorkout(ctx, dto.UpdateWorkout{ID: w.ID, Version: w.Version, Name: &name})
+			assert.NoError(t, err)
+			_, err = m.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDuration})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	workouts, err := m.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDuration, SortAsc: true})
+	require.NoError(t, err)
+	require.Len(t, workouts, 20)
+	for i, w := range workouts {
+		assert.Equal(t, time.Duration(i)*time.Minute, w.Duration)
+		assert.Equal(t, 2, w.Version)
+	}
+}
@@ -0,0 +1,46 @@
+// Package memory - хранилище в памяти процесса с той же семантикой, что и postgres.
+// Используется в тестах и в демо-режиме (STORAGE=memory); данные теряются при перезапуске
+package memory
+
+import (
+	"sync"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+type memory struct {
+	mu sync.RWMutex
+
+	workouts map[uuid.UUID]*entity.Workout // сводки без трека и кругов
+	points   map[uuid.UUID][]entity.RecordData
+	laps     map[uuid.UUID][]entity.LapData
+	coaches  map[coachLink]string // связь тренер - спортсмен и её статус
+	users    map[string]*user     // по email
+	sessions map[uuid.UUID]*entity.Session
+	tokens   map[string]*entity.RefreshToken // refresh-токены по хэшу
+	mail     map[string]*entity.UserToken    // одноразовые токены из писем по хэшу
+	attempts []entity.LoginAttempt           // журнал неудачных попыток входа
+
+	accessTokens map[uuid.UUID]*accessToken // персональные токены доступа
+}
+
+func NewMemoryAdapter() *memory {
+	return &memory{
+		workouts: make(map[uuid.UUID]*entity.Workout),
+		points:   make(map[uuid.UUID][]entity.RecordData),
+		laps:     make(map[uuid.UUID][]entity.LapData),
+		coaches:  make(map[coachLink]string),
+		users:    make(map[string]*user),
+		sessions: make(map[uuid.UUID]*entity.Session),
+		tokens:   make(map[string]*entity.RefreshToken),
+		mail:     make(map[string]*entity.UserToken),
+
+		accessTokens: make(map[uuid.UUID]*accessToken),
+	}
+}
+
+// Close ничего не делает и нужен для совместимости с postgres
+func (m *memory) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// userByID ищет пользователя по id; вызывается под блокировкой
+func (m *memory) userByID(userID uuid.UUID) (*user, bool) {
+	for _, u := range m.users {
+		if u.ID == userID.String() {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+// GetUserRoles возвращает роли пользователя, упорядоченные по имени
+func (m *memory) GetUserRoles(_ context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	const op = "storage.memory.UserRoles"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return sortedRoles(u.Roles), nil
+}
+
+// SetUserRoles заменяет роли пользователя
+func (m *memory) SetUserRoles(_ context.Context, userID uuid.UUID, roles []entity.Role) error {
+	const op = "storage.memory.SetUserRoles"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.Roles = slices.Compact(sortedRoles(roles))
+	return nil
+}
+
+// ListUsers возвращает всех пользователей с ролями, упорядоченных по email
+func (m *memory) ListUsers(_ context.Context) ([]entity.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]entity.User, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, entity.User{ID: u.ID, Email: u.Email, Name: u.Name, Roles: sortedRoles(u.Roles)})
+	}
+	slices.SortFunc(users, func(a, b entity.User) int {
+		return cmp.Compare(a.Email, b.Email)
+	})
+	return users, nil
+}
+
+func sortedRoles(roles []entity.Role) []entity.Role {
+	sorted := append([]entity.Role{}, roles...)
+	slices.Sort(sorted)
+	return sorted
+}
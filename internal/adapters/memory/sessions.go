@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// CreateSession сохраняет новую сессию вместе с её первым refresh-токеном
+func (m *memory) CreateSession(_ context.Context, s *entity.Session, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := *s
+	m.sessions[s.ID] = &session
+	m.tokens[tokenHash] = &entity.RefreshToken{Hash: tokenHash, SessionID: s.ID, CreatedAt: s.CreatedAt}
+	return nil
+}
+
+// GetSession возвращает сессию по id, в том числе отозванную или истёкшую
+func (m *memory) GetSession(_ context.Context, sessionID uuid.UUID) (*entity.Session, error) {
+	const op = "storage.memory.Session"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	session := *s
+	return &session, nil
+}
+
+// ListSessions возвращает активные на момент now сессии пользователя, недавно использованные первыми
+func (m *memory) ListSessions(_ context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := []entity.Session{}
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.Active(now) {
+			sessions = append(sessions, *s)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b entity.Session) int {
+		if c := b.LastUsedAt.Compare(a.LastUsedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID.String(), b.ID.String())
+	})
+	return sessions, nil
+}
+
+// RevokeSession отзывает активную сессию пользователя. Чужая или уже отозванная сессия не находится
+func (m *memory) RevokeSession(_ context.Context, userID, sessionID uuid.UUID, now time.Time) error {
+	const op = "storage.memory.RevokeSession"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok || s.UserID != userID || !s.RevokedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	s.RevokedAt = now
+	return nil
+}
+
+// GetRefreshToken ищет refresh-токен по хэшу, в том числе уже использованный
+func (m *memory) GetRefreshToken(_ context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	const op = "storage.memory.RefreshToken"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	token := *t
+	return &token, nil
+}
+
+// UseRefreshToken помечает токен использованным. Если его уже использовал параллельный
+// запрос, возвращает storage.ErrRefreshTokenUsed
+func (m *memory) UseRefreshToken(_ context.Context, tokenHash string, now time.Time) error {
+	const op = "storage.memory.UseRefreshToken"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[tokenHash]
+	if !ok || !t.UsedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+	t.UsedAt = now
+	return nil
+}
+
+// AddRefreshToken выдаёт сессии новый refresh-токен и продлевает её до expiresAt
+func (m *memory) AddRefreshToken(_ context.Context, sessionID uuid.UUID, tokenHash string, now, expiresAt time.Time) error {
+	const op = "storage.memory.AddRefreshToken"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok || !s.RevokedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	s.LastUsedAt = now
+	s.ExpiresAt = expiresAt
+	m.tokens[tokenHash] = &entity.RefreshToken{Hash: tokenHash, SessionID: sessionID, CreatedAt: now}
+	return nil
+}
+
+// RevokeUserSessions отзывает все активные сессии пользователя, например после смены пароля
+func (m *memory) RevokeUserSessions(_ context.Context, userID uuid.UUID, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt.IsZero() {
+			s.RevokedAt = now
+		}
+	}
+	return nil
+}
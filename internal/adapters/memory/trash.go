@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// SoftDeleteWorkout переносит тренировку пользователя в корзину.
+// Трек, круги и исходный файл остаются до окончательной очистки
+func (m *memory) SoftDeleteWorkout(_ context.Context, userID, workoutID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.owns(userID, workoutID) {
+		return storage.ErrWorkoutNotFound
+	}
+	m.workouts[workoutID].DeletedAt = time.Now()
+	return nil
+}
+
+// GetDeletedWorkouts возвращает тренировки пользователя в корзине, удалённые после deletedAfter
+func (m *memory) GetDeletedWorkouts(_ context.Context, userID uuid.UUID, deletedAfter time.Time) ([]entity.Workout, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found []*entity.Workout
+	for _, w := range m.workouts {
+		if w.UserID == userID && w.DeletedAt.After(deletedAfter) {
+			found = append(found, w)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].DeletedAt.After(found[j].DeletedAt) })
+	return copies(found), nil
+}
+
+// RestoreWorkout возвращает тренировку из корзины, если она удалена после deletedAfter
+func (m *memory) RestoreWorkout(_ context.Context, userID, workoutID uuid.UUID, deletedAfter time.Time) (*entity.Workout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.workouts[workoutID]
+	if !ok || w.UserID != userID || w.DeletedAt.IsZero() || !w.DeletedAt.After(deletedAfter) {
+		return nil, storage.ErrWorkoutNotFound
+	}
+	w.DeletedAt = time.Time{}
+	return clone(w), nil
+}
+
+// PurgeDeletedWorkouts окончательно удаляет тренировки, лежащие в корзине с момента до before,
+// вместе с треком и кругами. Возвращает число удалённых тренировок и ключи исходных файлов,
+// на которые больше не ссылается ни одна тренировка
+func (m *memory) PurgeDeletedWorkouts(_ context.Context, before time.Time) (int, []string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	purged := 0
+	keys := make(map[string]struct{})
+	for id, w := range m.workouts {
+		if w.DeletedAt.IsZero() || !w.DeletedAt.Before(before) {
+			continue
+		}
+		if w.OriginalKey != "" {
+			keys[w.OriginalKey] = struct{}{}
+		}
+		delete(m.workouts, id)
+		delete(m.points, id)
+		delete(m.laps, id)
+		purged++
+	}
+
+	// Тот же файл мог быть загружен снова после удаления - такой исходник оставляем
+	for _, w := range m.workouts {
+		delete(keys, w.OriginalKey)
+	}
+
+	var orphaned []string
+	for k := range keys {
+		orphaned = append(orphaned, k)
+	}
+	sort.Strings(orphaned)
+	return purged, orphaned, nil
+}
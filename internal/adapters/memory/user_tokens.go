@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// CreateUserToken сохраняет одноразовый токен из письма
+func (m *memory) CreateUserToken(_ context.Context, t *entity.UserToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token := *t
+	m.mail[t.Hash] = &token
+	return nil
+}
+
+// UseUserToken погашает действующий токен и возвращает его владельца. Остальные неиспользованные
+// токены того же назначения тоже гасятся: действует только одна, последняя использованная ссылка
+func (m *memory) UseUserToken(_ context.Context, tokenHash string, purpose entity.TokenPurpose, now time.Time) (uuid.UUID, error) {
+	const op = "storage.memory.UseUserToken"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.mail[tokenHash]
+	if !ok || t.Purpose != purpose || !t.UsedAt.IsZero() || !now.Before(t.ExpiresAt) {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+
+	for _, other := range m.mail {
+		if other.UserID == t.UserID && other.Purpose == purpose && other.UsedAt.IsZero() {
+			other.UsedAt = now
+		}
+	}
+	return t.UserID, nil
+}
+
+// SetEmailVerified отмечает адрес почты пользователя подтверждённым
+func (m *memory) SetEmailVerified(_ context.Context, userID uuid.UUID, _ time.Time) error {
+	const op = "storage.memory.SetEmailVerified"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.EmailVerified = true
+	return nil
+}
+
+// UpdatePassword заменяет хэш пароля пользователя
+func (m *memory) UpdatePassword(_ context.Context, userID uuid.UUID, passHash []byte) error {
+	const op = "storage.memory.UpdatePassword"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.userByID(userID)
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	u.Password = string(passHash)
+	u.UpdatedAt = time.Now().Unix()
+	return nil
+}
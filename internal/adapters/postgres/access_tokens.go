@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const accessTokenColumns = `id, user_id, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+func scanAccessToken(row pgx.Row) (*entity.AccessToken, error) {
+	var (
+		t                              entity.AccessToken
+		scopes                         []string
+		lastUsedAt, expiresAt, revoked *time.Time
+	)
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &t.CreatedAt, &lastUsedAt, &expiresAt, &revoked); err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
+		t.Scopes = append(t.Scopes, entity.Scope(s))
+	}
+	if lastUsedAt != nil {
+		t.LastUsedAt = *lastUsedAt
+	}
+	if expiresAt != nil {
+		t.ExpiresAt = *expiresAt
+	}
+	if revoked != nil {
+		t.RevokedAt = *revoked
+	}
+	return &t, nil
+}
+
+// CreateAccessToken сохраняет персональный токен доступа
+func (p *postgres) CreateAccessToken(ctx context.Context, t *entity.AccessToken, tokenHash string) error {
+	const op = "storage.postgres.CreateAccessToken"
+
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	var expiresAt *time.Time
+	if !t.ExpiresAt.IsZero() {
+		expiresAt = &t.ExpiresAt
+	}
+
+	_, err := p.conn(ctx).Exec(ctx, `
+		INSERT INTO access_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		t.ID, t.UserID, t.Name, tokenHash, t.Prefix, scopes, t.CreatedAt, expiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetAccessToken возвращает токен по хэшу, в том числе отозванный или истёкший
+func (p *postgres) GetAccessToken(ctx context.Context, tokenHash string) (*entity.AccessToken, error) {
+	const op = "storage.postgres.AccessToken"
+
+	t, err := scanAccessToken(p.conn(ctx).QueryRow(ctx,
+		`SELECT `+accessTokenColumns+` FROM access_tokens WHERE token_hash = $1`, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return t, nil
+}
+
+// ListAccessTokens возвращает неотозванные токены пользователя, новые первыми
+func (p *postgres) ListAccessTokens(ctx context.Context, userID uuid.UUID) ([]entity.AccessToken, error) {
+	const op = "storage.postgres.ListAccessTokens"
+
+	rows, err := p.conn(ctx).Query(ctx, `
+		SELECT `+accessTokenColumns+`
+		FROM access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tokens := []entity.AccessToken{}
+	for rows.Next() {
+		t, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tokens, nil
+}
+
+// RevokeAccessToken отзывает токен пользователя. Чужой или уже отозванный токен - storage.ErrTokenNotFound
+func (p *postgres) RevokeAccessToken(ctx context.Context, userID, tokenID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.RevokeAccessToken"
+
+	tag, err := p.conn(ctx).Exec(ctx, `
+		UPDATE access_tokens
+		SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, tokenID, userID, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+	return nil
+}
+
+// RevokeUserAccessTokens отзывает все действующие токены пользователя, например после сброса пароля
+func (p *postgres) RevokeUserAccessTokens(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.RevokeUserAccessTokens"
+
+	_, err := p.conn(ctx).Exec(ctx, `
+		UPDATE access_tokens
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// TouchAccessToken запоминает время последнего использования токена
+func (p *postgres) TouchAccessToken(ctx context.Context, tokenID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.TouchAccessToken"
+
+	if _, err := p.conn(ctx).Exec(ctx, `UPDATE access_tokens SET last_used_at = $2 WHERE id = $1`, tokenID, now); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -2,9 +2,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"math"
 	"strings"
+	"time"
+	"workout/internal/adapters/storage"
 	"workout/internal/dto"
 	"workout/internal/entity"
 )
@@ -14,36 +19,170 @@ func (p *postgres) CreateWorkout(ctx context.Context, workout *entity.Workout) (
         INSERT INTO workouts (
             user_id, name, sport_type, date, duration,
             distance, avg_pace, avg_heart_rate, max_heart_rate,
-            avg_cadence, calories, description, created_at, updated_at
+            avg_cadence, calories, description, created_at, updated_at,
+            start_time, file_hash, original_key, original_name
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
-        ) RETURNING id`
-
-	row := p.db.QueryRow(ctx, query,
-		workout.UserID, workout.Name, workout.SportType, workout.Date,
-		workout.Duration, workout.Distance,
-		workout.AvgPace, workout.AvgHeartRate, workout.MaxHeartRate,
-		workout.AvgCadence, workout.Calories, workout.Description,
-		workout.CreatedAt, workout.UpdatedAt,
-	)
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+        ) RETURNING id, version`
+
+	// Тренировка, её трек и круги сохраняются вместе или не сохраняются вовсе
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		row := p.conn(ctx).QueryRow(ctx, query,
+			workout.UserID, workout.Name, workout.SportType, workout.Date,
+			workout.Duration, workout.Distance,
+			workout.AvgPace, workout.AvgHeartRate, workout.MaxHeartRate,
+			workout.AvgCadence, workout.Calories, workout.Description,
+			workout.CreatedAt, workout.UpdatedAt,
+			nullIfZero(workout.StartTime), nullIfZero(workout.FileHash),
+			nullIfZero(workout.OriginalKey), nullIfZero(workout.OriginalName),
+		)
+
+		if err := row.Scan(&workout.ID, &workout.Version); err != nil {
+			return err
+		}
+
+		if err := p.insertTrackPoints(ctx, workout.ID, workout.RecordData); err != nil {
+			return err
+		}
 
-	if err := row.Scan(&workout.ID); err != nil {
+		return p.insertLaps(ctx, workout.ID, workout.Laps)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return workout, nil
 }
 
-func (p *postgres) GetWorkouts(ctx context.Context, userID uuid.UUID) ([]entity.Workout, error) {
-	query := `
-		SELECT id, user_id, name, sport_type, date, duration, distance,
-    		avg_pace, avg_heart_rate, max_heart_rate, avg_cadence,
-    		calories, description, created_at, updated_at
+// workoutColumns - колонки тренировки в порядке, который ожидает scanWorkout
+const workoutColumns = `
+	id, user_id, name, sport_type, date, duration, distance,
+	avg_pace, avg_heart_rate, max_heart_rate, avg_cadence,
+	calories, description, created_at, updated_at,
+	start_time, COALESCE(file_hash, ''),
+	COALESCE(original_key, ''), COALESCE(original_name, ''), deleted_at, version, merged_at, edited_at`
+
+func scanWorkout(row pgx.Row) (*entity.Workout, error) {
+	var (
+		w         entity.Workout
+		startTime *time.Time
+		deletedAt *time.Time
+		mergedAt  *time.Time
+		editedAt  *time.Time
+	)
+	if err := row.Scan(
+		&w.ID, &w.UserID, &w.Name, &w.SportType, &w.Date, &w.Duration,
+		&w.Distance, &w.AvgPace, &w.AvgHeartRate, &w.MaxHeartRate,
+		&w.AvgCadence, &w.Calories, &w.Description, &w.CreatedAt, &w.UpdatedAt,
+		&startTime, &w.FileHash, &w.OriginalKey, &w.OriginalName, &deletedAt, &w.Version, &mergedAt, &editedAt,
+	); err != nil {
+		return nil, err
+	}
+	if startTime != nil {
+		w.StartTime = *startTime
+	}
+	if deletedAt != nil {
+		w.DeletedAt = *deletedAt
+	}
+	if mergedAt != nil {
+		w.MergedAt = *mergedAt
+	}
+	if editedAt != nil {
+		w.EditedAt = *editedAt
+	}
+	return &w, nil
+}
+
+// sortColumns - выражения и типы полей, по которым можно сортировать список тренировок.
+// Пустые значения заменяются нулём, иначе сравнение по курсору пропустит строки с NULL
+var sortColumns = map[string]struct{ expr, cast string }{
+	dto.SortByDate:         {"date", "timestamptz"},
+	dto.SortByDistance:     {"COALESCE(distance, 0)", "numeric"},
+	dto.SortByDuration:     {"duration", "bigint"},
+	dto.SortByAvgPace:      {"COALESCE(avg_pace, 0)", "numeric"},
+	dto.SortByAvgHeartRate: {"COALESCE(avg_heart_rate, 0)", "integer"},
+	dto.SortByCalories:     {"COALESCE(calories, 0)", "integer"},
+}
+
+// GetWorkouts возвращает страницу тренировок пользователя с учётом фильтров и сортировки.
+// Для следующей страницы используется курсор (keyset): (поле сортировки, id) последней строки
+func (p *postgres) GetWorkouts(ctx context.Context, userID uuid.UUID, f dto.WorkoutFilter) ([]entity.Workout, error) {
+	sort, ok := sortColumns[f.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", f.SortBy)
+	}
+
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	// Хэлпер add(…) добавляет условие, подставляя номер следующего плейсхолдера вместо %d
+	add := func(cond string, vals ...any) {
+		placeholders := make([]any, len(vals))
+		for i, v := range vals {
+			args = append(args, v)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(cond, placeholders...))
+	}
+
+	if !f.StartDate.IsZero() {
+		add("date >= $%d", f.StartDate)
+	}
+	if !f.EndDate.IsZero() {
+		// Конец периода включительно: до начала следующего дня
+		add("date < $%d", f.EndDate.AddDate(0, 0, 1))
+	}
+	if f.SportType != "" {
+		add("sport_type = $%d", f.SportType)
+	}
+	if f.MinDistance > 0 {
+		add("distance >= $%d", f.MinDistance)
+	}
+	if f.MaxDistance > 0 {
+		add("distance <= $%d", f.MaxDistance)
+	}
+	if f.MinDuration > 0 {
+		add("duration >= $%d", f.MinDuration)
+	}
+	if f.MaxDuration > 0 {
+		add("duration <= $%d", f.MaxDuration)
+	}
+	if f.Query != "" {
+		add("(name ILIKE $%[1]d OR description ILIKE $%[1]d)", "%"+escapeLike(f.Query)+"%")
+	}
+
+	order, cmp := "DESC", "<"
+	if f.SortAsc {
+		order, cmp = "ASC", ">"
+	}
+	if f.After != nil {
+		add("("+sort.expr+", id) "+cmp+" ($%d::"+sort.cast+", $%d)", f.After.Value, f.After.ID)
+	}
+
+	query := `SELECT ` + workoutColumns + `
 		FROM workouts
-		WHERE user_id = $1
-		ORDER BY date DESC;`
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + sort.expr + ` ` + order + `, id ` + order
+
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	return p.queryWorkouts(ctx, query, args...)
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы искать их буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
 
-	rows, err := p.db.Query(ctx, query, userID)
+func (p *postgres) queryWorkouts(ctx context.Context, query string, args ...any) ([]entity.Workout, error) {
+	rows, err := p.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -51,74 +190,153 @@ func (p *postgres) GetWorkouts(ctx context.Context, userID uuid.UUID) ([]entity.
 
 	var workouts []entity.Workout
 	for rows.Next() {
-		var w entity.Workout
-		if err := rows.Scan(
-			&w.ID, &w.UserID, &w.Name, &w.SportType, &w.Date, &w.Duration,
-			&w.Distance, &w.AvgPace, &w.AvgHeartRate, &w.MaxHeartRate,
-			&w.AvgCadence, &w.Calories, &w.Description, &w.CreatedAt, &w.UpdatedAt,
-		); err != nil {
+		w, err := scanWorkout(rows)
+		if err != nil {
 			return nil, err
 		}
-		workouts = append(workouts, w)
+		workouts = append(workouts, *w)
 	}
 	return workouts, rows.Err()
 }
 
-func (p *postgres) GetWorkoutByID(ctx context.Context, id int64) (*entity.Workout, error) {
-	query := `
-        SELECT id, name, type, date, duration, duration,
-            distance, avg_pace,  avg_heart_rate, max_heart_rate,
-            avg_power, max_power, avg_cadence, calories,
-            description,  created_at, updated_at
-        FROM workouts WHERE id = $1`
-
-	workout := &entity.Workout{}
-	err := p.db.QueryRow(ctx, query, id).Scan(
-		&workout.Name, &workout.SportType, &workout.Date,
-		&workout.Duration, &workout.Duration, &workout.Distance,
-		&workout.AvgPace, &workout.AvgHeartRate, &workout.MaxHeartRate,
-		&workout.AvgCadence, &workout.Calories, &workout.Description,
-		&workout.CreatedAt, &workout.UpdatedAt,
-	)
+// FindWorkoutByHash ищет тренировку пользователя, загруженную из файла с тем же содержимым
+func (p *postgres) FindWorkoutByHash(ctx context.Context, userID uuid.UUID, hash string) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = $1 AND file_hash = $2 AND deleted_at IS NULL
+		LIMIT 1;`
+
+	w, err := scanWorkout(p.conn(ctx).QueryRow(ctx, query, userID, hash))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrWorkoutNotFound
+		}
 		return nil, err
 	}
-	return workout, nil
+	return w, nil
 }
 
-func (p *postgres) GetWorkoutsByUserID(ctx context.Context, userID int64) ([]*entity.Workout, error) {
-	query := `
-        SELECT id, user_id, name, type, duration, distance, avg_heart_rate, calories, created_at
-        FROM workouts 
-        WHERE user_id = $1 
-        ORDER BY start_time DESC`
+// FindWorkoutsByStartTime возвращает тренировки пользователя, начавшиеся в интервале [from, to]
+func (p *postgres) FindWorkoutsByStartTime(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = $1 AND start_time BETWEEN $2 AND $3 AND deleted_at IS NULL
+		ORDER BY start_time;`
 
-	rows, err := p.db.Query(ctx, query, userID)
+	return p.queryWorkouts(ctx, query, userID, from, to)
+}
+
+// GetWorkoutOriginal возвращает тренировку пользователя со ссылкой на исходный файл
+func (p *postgres) GetWorkoutOriginal(ctx context.Context, userID, workoutID uuid.UUID) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;`
+
+	w, err := scanWorkout(p.conn(ctx).QueryRow(ctx, query, workoutID, userID))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrWorkoutNotFound
+		}
 		return nil, err
 	}
-	defer rows.Close()
+	return w, nil
+}
 
-	var workouts []*entity.Workout
-	for rows.Next() {
-		workout := &entity.Workout{}
-		err := rows.Scan(
-			&workout.ID, &workout.UserID, &workout.Name, &workout.SportType,
-			&workout.Duration, &workout.Distance,
-			&workout.AvgHeartRate, &workout.Calories, &workout.CreatedAt,
-		)
+// ListWorkoutsWithOriginal возвращает тренировки с сохранённым исходным файлом, упорядоченные по id.
+// Нулевой userID - тренировки всех пользователей; after - id последней тренировки предыдущей страницы
+func (p *postgres) ListWorkoutsWithOriginal(ctx context.Context, userID, after uuid.UUID, limit int) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE original_key IS NOT NULL AND deleted_at IS NULL
+			AND ($1::uuid IS NULL OR user_id = $1)
+			AND id > $2
+		ORDER BY id
+		LIMIT $3;`
+
+	return p.queryWorkouts(ctx, query, nullIfZero(userID), after, limit)
+}
+
+// UpdateWorkoutData обновляет рассчитанные из файла поля тренировки и увеличивает её версию,
+// если сохранённая версия совпадает с w.Version, иначе возвращает storage.ErrVersionConflict.
+// Новая версия записывается в w.Version. Если переданы записи или круги, они полностью заменяют сохранённые.
+// Время слияния только устанавливается: пустое w.MergedAt не сбрасывает сохранённое
+func (p *postgres) UpdateWorkoutData(ctx context.Context, w *entity.Workout) error {
+	query := `
+		UPDATE workouts
+		SET sport_type = $2, duration = $3, distance = $4, avg_pace = $5,
+			avg_heart_rate = $6, max_heart_rate = $7, avg_cadence = $8, calories = $9,
+			start_time = $10, file_hash = $11, merged_at = COALESCE($12, merged_at),
+			updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $13
+		RETURNING version`
+
+	return p.WithinTx(ctx, func(ctx context.Context) error {
+		err := p.conn(ctx).QueryRow(ctx, query,
+			w.ID, w.SportType, w.Duration, w.Distance, w.AvgPace,
+			w.AvgHeartRate, w.MaxHeartRate, w.AvgCadence, w.Calories,
+			nullIfZero(w.StartTime), nullIfZero(w.FileHash), nullIfZero(w.MergedAt), w.Version,
+		).Scan(&w.Version)
 		if err != nil {
-			return nil, err
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+			// Строки нет совсем или её успели изменить после чтения
+			var exists bool
+			if err := p.conn(ctx).QueryRow(ctx,
+				`SELECT EXISTS (SELECT 1 FROM workouts WHERE id = $1)`, w.ID,
+			).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return storage.ErrVersionConflict
+			}
+			return storage.ErrWorkoutNotFound
+		}
+
+		if w.RecordData != nil {
+			if _, err := p.conn(ctx).Exec(ctx, `DELETE FROM track_points WHERE workout_id = $1`, w.ID); err != nil {
+				return err
+			}
+			if err := p.insertTrackPoints(ctx, w.ID, w.RecordData); err != nil {
+				return err
+			}
+		}
+
+		if w.Laps != nil {
+			if _, err := p.conn(ctx).Exec(ctx, `DELETE FROM workout_laps WHERE workout_id = $1`, w.ID); err != nil {
+				return err
+			}
+			if err := p.insertLaps(ctx, w.ID, w.Laps); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetWorkoutByID возвращает тренировку по id без проверки владельца. Удалённые в корзину не возвращаются
+func (p *postgres) GetWorkoutByID(ctx context.Context, id uuid.UUID) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE id = $1 AND deleted_at IS NULL;`
+
+	w, err := scanWorkout(p.conn(ctx).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrWorkoutNotFound
 		}
-		workouts = append(workouts, workout)
+		return nil, err
 	}
-	return workouts, nil
+	return w, nil
 }
 
-func (p *postgres) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) error {
-	nextIdx := 2                        // счётчик плейсхолдеров $2, $3 …
+// UpdateWorkout меняет переданные поля тренировки, если её версия всё ещё равна u.Version,
+// и возвращает тренировку с новой версией
+func (p *postgres) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) (*entity.Workout, error) {
+	nextIdx := 3                        // счётчик плейсхолдеров $3, $4 …
 	setClauses := make([]string, 0, 12) // сюда будем складывать "col = $n"
-	args := []interface{}{u.ID}
+	args := []interface{}{u.ID, u.Version}
 
 	// Хэлпер add(…) прячет всю «механику»:
 	//  • кладём "col = $n" в слайс setClauses
@@ -134,16 +352,18 @@ func (p *postgres) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) error
 		add("name", *u.Name)
 	}
 	if u.SportType != nil {
-		add("type", *u.SportType)
+		add("sport_type", *u.SportType)
 	}
 	if u.Duration != nil {
-		add("duration", *u.Duration)
+		// В базе продолжительность хранится так же, как в entity.Workout
+		add("duration", time.Duration(*u.Duration)*time.Second)
 	}
 	if u.Distance != nil {
 		add("distance", *u.Distance)
 	}
 	if u.AvgPace != nil {
-		add("avg_pace", *u.AvgPace)
+		// Колонка avg_pace целочисленная - секунды на километр
+		add("avg_pace", int(math.Round(float64(*u.AvgPace))))
 	}
 	if u.AvgHeartRate != nil {
 		add("avg_heart_rate", *u.AvgHeartRate)
@@ -160,22 +380,33 @@ func (p *postgres) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) error
 	if u.Description != nil {
 		add("description", *u.Description)
 	}
-
-	if len(setClauses) == 0 {
-		return nil
+	if u.DeviceName != nil {
+		add("device_name", *u.DeviceName)
 	}
 
 	// Склеиваем окончательный SQL
 	// Пример получится такой:
-	// UPDATE workout
-	// SET name = $2, distance = $3, updated_at = CURRENT_TIMESTAMP
-	// WHERE id = $1;
+	// UPDATE workouts
+	// SET name = $3, distance = $4, updated_at = CURRENT_TIMESTAMP, version = version + 1
+	// WHERE id = $1 AND version = $2 ...
+	if u.EditsFileData() {
+		// Отмечаем ручную правку, чтобы пересборка из файла её не затёрла
+		setClauses = append(setClauses, "edited_at = CURRENT_TIMESTAMP")
+	}
+	setClauses = append(setClauses, "updated_at = CURRENT_TIMESTAMP", "version = version + 1")
 	query := fmt.Sprintf(`
-        UPDATE workout
-        SET %s,
-            updated_at = CURRENT_TIMESTAMP
-        WHERE id = $1`, strings.Join(setClauses, ", "))
+        UPDATE workouts
+        SET %s
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
+        RETURNING %s`, strings.Join(setClauses, ", "), workoutColumns)
 
-	_, err := p.db.Exec(ctx, query, args...)
-	return err
+	w, err := scanWorkout(p.conn(ctx).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Тренировка была изменена (или удалена) после того, как клиент её прочитал
+			return nil, storage.ErrVersionConflict
+		}
+		return nil, err
+	}
+	return w, nil
 }
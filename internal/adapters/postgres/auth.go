@@ -7,26 +7,23 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+	"workout/internal/adapters/storage"
 	"workout/internal/entity"
 )
 
-var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
-)
-
 func (s *postgres) CreateUser(ctx context.Context, email string, passHash []byte) (string, error) {
-	const op = "storage.sqlite.SaveUser"
+	const op = "storage.postgres.SaveUser"
 
 	var id uuid.UUID
-	err := s.db.
+	err := s.conn(ctx).
 		QueryRow(ctx, `INSERT INTO users (email, password_hash) VALUES ($1, $2) RETURNING id`, email, passHash).
 		Scan(&id)
 	if err != nil {
 		// todo: переделать на ON CONFLICT
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
-			return "", fmt.Errorf("%s: %w", op, ErrUserExists)
+			return "", fmt.Errorf("%s: %w", op, storage.ErrUserExists)
 		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
@@ -35,37 +32,51 @@ func (s *postgres) CreateUser(ctx context.Context, email string, passHash []byte
 }
 
 func (s *postgres) GetUser(ctx context.Context, email string) (*entity.User, error) {
-	const op = "storage.sqlite.User"
+	const op = "storage.postgres.User"
 
-	var u entity.User
-	err := s.db.
-		QueryRow(ctx, `SELECT id, email, password_hash FROM users WHERE email = $1`, email).
-		Scan(&u.ID, &u.Email, &u.Password)
+	var (
+		u           entity.User
+		lockedUntil *time.Time
+	)
+	err := s.conn(ctx).
+		QueryRow(ctx, `SELECT id, email, password_hash, email_verified_at IS NOT NULL, failed_logins, locked_until FROM users WHERE email = $1`, email).
+		Scan(&u.ID, &u.Email, &u.Password, &u.EmailVerified, &u.FailedLogins, &lockedUntil)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if lockedUntil != nil {
+		u.LockedUntil = *lockedUntil
+	}
+
 	return &u, nil
 }
 
-func (s *postgres) IsAdmin(ctx context.Context, userID int64) (bool, error) {
-	const op = "storage.sqlite.IsAdmin"
+func (s *postgres) GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	const op = "storage.postgres.UserByID"
 
-	var isAdmin bool
-	err := s.db.
-		QueryRow(ctx, `SELECT is_admin FROM users WHERE id = $1`, userID).
-		Scan(&isAdmin)
+	var (
+		u           entity.User
+		lockedUntil *time.Time
+	)
+	err := s.conn(ctx).
+		QueryRow(ctx, `SELECT id, email, password_hash, email_verified_at IS NOT NULL, failed_logins, locked_until FROM users WHERE id = $1`, userID).
+		Scan(&u.ID, &u.Email, &u.Password, &u.EmailVerified, &u.FailedLogins, &lockedUntil)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
-		return false, fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockedUntil != nil {
+		u.LockedUntil = *lockedUntil
 	}
 
-	return isAdmin, nil
+	return &u, nil
 }
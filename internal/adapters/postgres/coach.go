@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// coachStatusAccepted - связь подтверждена спортсменом
+const coachStatusAccepted = "accepted"
+
+// IsCoachOf проверяет, что пользователь coachID - подтверждённый тренер спортсмена athleteID
+func (p *postgres) IsCoachOf(ctx context.Context, coachID, athleteID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM coach_athletes
+			WHERE coach_id = $1 AND athlete_id = $2 AND status = $3
+		);`
+
+	var ok bool
+	if err := p.conn(ctx).QueryRow(ctx, query, coachID, athleteID, coachStatusAccepted).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
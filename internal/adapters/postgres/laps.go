@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/muktihari/fit/profile/typedef"
+	"workout/internal/entity"
+)
+
+const insertLapSQL = `
+	INSERT INTO workout_laps (
+		workout_id, lap_number, start_time, end_time, elapsed_time, timer_time,
+		distance, avg_speed, max_speed, avg_heart_rate, max_heart_rate,
+		avg_cadence, max_cadence, calories, lap_trigger
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+	)`
+
+// insertLaps сохраняет круги тренировки в порядке их следования в файле
+func (p *postgres) insertLaps(ctx context.Context, workoutID uuid.UUID, laps []entity.LapData) error {
+	if len(laps) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for i, l := range laps {
+		batch.Queue(insertLapSQL,
+			workoutID, i+1, l.StartTime, l.Timestamp, l.TotalElapsedTime, l.TotalTimerTime,
+			float64(l.TotalDistance)/100.0, float64(l.AvgSpeed)/1000.0, float64(l.MaxSpeed)/1000.0,
+			nullIfZero(l.AvgHeartRate), nullIfZero(l.MaxHeartRate),
+			nullIfZero(l.AvgCadence), nullIfZero(l.MaxCadence), nullIfZero(l.TotalCalories),
+			l.LapTrigger.String(),
+		)
+	}
+
+	return p.conn(ctx).SendBatch(ctx, batch).Close()
+}
+
+// GetLaps возвращает круги тренировки, если она принадлежит пользователю
+func (p *postgres) GetLaps(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.LapData, error) {
+	query := `
+		SELECT l.start_time, l.end_time, l.elapsed_time, l.timer_time,
+			l.distance, l.avg_speed, l.max_speed,
+			COALESCE(l.avg_heart_rate, 0), COALESCE(l.max_heart_rate, 0),
+			COALESCE(l.avg_cadence, 0), COALESCE(l.max_cadence, 0),
+			COALESCE(l.calories, 0), l.lap_trigger
+		FROM workout_laps l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE l.workout_id = $1 AND w.user_id = $2 AND w.deleted_at IS NULL
+		ORDER BY l.lap_number;`
+
+	rows, err := p.conn(ctx).Query(ctx, query, workoutID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	laps := []entity.LapData{}
+	for rows.Next() {
+		var (
+			l                            entity.LapData
+			distance, avgSpeed, maxSpeed float64
+			trigger                      string
+		)
+		if err := rows.Scan(
+			&l.StartTime, &l.Timestamp, &l.TotalElapsedTime, &l.TotalTimerTime,
+			&distance, &avgSpeed, &maxSpeed,
+			&l.AvgHeartRate, &l.MaxHeartRate, &l.AvgCadence, &l.MaxCadence,
+			&l.TotalCalories, &trigger,
+		); err != nil {
+			return nil, err
+		}
+		l.TotalDistance = uint32(distance*100 + 0.5)
+		l.AvgSpeed = uint16(avgSpeed*1000 + 0.5)
+		l.MaxSpeed = uint16(maxSpeed*1000 + 0.5)
+		l.LapTrigger = typedef.LapTriggerFromString(trigger)
+		laps = append(laps, l)
+	}
+	return laps, rows.Err()
+}
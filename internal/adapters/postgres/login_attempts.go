@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddFailedLogin увеличивает счётчик неудачных попыток входа подряд и возвращает новое значение
+func (p *postgres) AddFailedLogin(ctx context.Context, userID uuid.UUID) (int, error) {
+	const op = "storage.postgres.AddFailedLogin"
+
+	var failures int
+	err := p.conn(ctx).QueryRow(ctx, `
+		UPDATE users
+		SET failed_logins = failed_logins + 1
+		WHERE id = $1
+		RETURNING failed_logins`, userID).Scan(&failures)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return failures, nil
+}
+
+// LockUser запрещает вход до времени until
+func (p *postgres) LockUser(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	const op = "storage.postgres.LockUser"
+
+	tag, err := p.conn(ctx).Exec(ctx, `UPDATE users SET locked_until = $2 WHERE id = $1`, userID, until)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// ResetFailedLogins обнуляет счётчик неудачных попыток и снимает блокировку
+func (p *postgres) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.postgres.ResetFailedLogins"
+
+	tag, err := p.conn(ctx).Exec(ctx, `UPDATE users SET failed_logins = 0, locked_until = NULL WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// AddLoginAttempt записывает неудачную попытку входа в журнал
+func (p *postgres) AddLoginAttempt(ctx context.Context, a *entity.LoginAttempt) error {
+	const op = "storage.postgres.AddLoginAttempt"
+
+	var userID *uuid.UUID
+	if a.UserID != uuid.Nil {
+		userID = &a.UserID
+	}
+	err := p.conn(ctx).QueryRow(ctx, `
+		INSERT INTO login_attempts (email, user_id, ip, user_agent, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		a.Email, userID, a.IP, a.UserAgent, string(a.Reason), a.CreatedAt).Scan(&a.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CountLoginAttempts возвращает число попыток входа с адресом email и причиной reason
+// и время последней из них
+func (p *postgres) CountLoginAttempts(ctx context.Context, email string, reason entity.LoginFailure) (int, time.Time, error) {
+	const op = "storage.postgres.CountLoginAttempts"
+
+	var (
+		count int
+		last  *time.Time
+	)
+	err := p.conn(ctx).QueryRow(ctx, `
+		SELECT COUNT(*), MAX(created_at)
+		FROM login_attempts
+		WHERE email = $1 AND reason = $2`, email, string(reason)).Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if last == nil {
+		return count, time.Time{}, nil
+	}
+	return count, *last, nil
+}
+
+// ListLoginAttempts возвращает последние limit неудачных попыток входа пользователя, новые первыми
+func (p *postgres) ListLoginAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]entity.LoginAttempt, error) {
+	const op = "storage.postgres.ListLoginAttempts"
+
+	rows, err := p.conn(ctx).Query(ctx, `
+		SELECT id, email, user_id, ip, user_agent, reason, created_at
+		FROM login_attempts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attempts := []entity.LoginAttempt{}
+	for rows.Next() {
+		var (
+			a      entity.LoginAttempt
+			reason string
+		)
+		if err := rows.Scan(&a.ID, &a.Email, &a.UserID, &a.IP, &a.UserAgent, &reason, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		a.Reason = entity.LoginFailure(reason)
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return attempts, nil
+}
@@ -2,75 +2,211 @@ package postgres
 
 import (
 	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"workout/internal/adapters/storage"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-const CreateTablesSQL = `
--- Таблица тренировок
-CREATE TABLE IF NOT EXISTS workouts (
-    id SERIAL PRIMARY KEY,
-    user_id BIGINT NOT NULL,
-    name VARCHAR(255) NOT NULL,
-    type VARCHAR(100) NOT NULL,
-    duration INTEGER NOT NULL,
-    distance DECIMAL(10,2),
-    avg_pace DECIMAL(10,2),
-    avg_heart_rate INTEGER,
-    max_heart_rate INTEGER,
-    avg_cadence INTEGER,
-    calories INTEGER,
-    description TEXT,
-    device_name VARCHAR(255),
-    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
-
--- Индексы для оптимизации
-CREATE INDEX IF NOT EXISTS idx_workouts_user_id ON workouts(user_id);
-CREATE INDEX IF NOT EXISTS idx_workouts_start_time ON workouts(start_time);
-CREATE INDEX IF NOT EXISTS idx_workouts_type ON workouts(type);
-CREATE INDEX IF NOT EXISTS idx_track_points_workout_id ON track_points(workout_id);
-CREATE INDEX IF NOT EXISTS idx_track_points_timestamp ON track_points(timestamp);
-`
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
 
+// migrationLockID - ключ advisory lock, чтобы миграции не применялись одновременно
+// несколькими процессами (например, при одновременном старте нескольких реплик)
+const migrationLockID int64 = 7_246_311_953
+
+const createSchemaMigrationsSQL = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+
+var ErrNoDownMigration = errors.New("migration has no down script")
+
+// MigrationStatus - состояние миграции в базе
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // применена в базе, но файла миграции нет
+}
+
+// Migrator применяет и откатывает миграции из embed.FS
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []storage.Migration
+}
+
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := storage.LoadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// RunMigrations применяет все ещё не применённые миграции
 func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, CreateTablesSQL)
+	m, err := NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
 	return err
 }
 
-// -- Таблица GPS точек
-// CREATE TABLE IF NOT EXISTS track_points (
-//     id SERIAL PRIMARY KEY,
-//     workout_id BIGINT NOT NULL,
-//     timestamp TIMESTAMP NOT NULL,
-//     latitude DECIMAL(10,8) NOT NULL,
-//     longitude DECIMAL(11,8) NOT NULL,
-//     elevation DECIMAL(10,2),
-//     heart_rate INTEGER,
-//     speed DECIMAL(10,2),
-//     power INTEGER,
-//     cadence INTEGER,
-//     temperature DECIMAL(5,2),
-//     distance DECIMAL(10,2),
-//     grade DECIMAL(5,2),
-//     FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
-// );
-
-// -- Таблица лапов
-// CREATE TABLE IF NOT EXISTS workout_laps (
-//     id SERIAL PRIMARY KEY,
-//     workout_id BIGINT NOT NULL,
-//     lap_number INTEGER NOT NULL,
-//     start_time TIMESTAMP NOT NULL,
-//     end_time TIMESTAMP NOT NULL,
-//     duration INTEGER NOT NULL,
-//     distance DECIMAL(10,2),
-//     avg_heart_rate INTEGER,
-//     max_heart_rate INTEGER,
-//     avg_speed DECIMAL(10,2),
-//     avg_power INTEGER,
-//     avg_cadence INTEGER,
-//     calories INTEGER,
-//     FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
-// );
+// Up применяет все неприменённые миграции по возрастанию версии и возвращает применённые
+func (m *Migrator) Up(ctx context.Context) ([]storage.Migration, error) {
+	var done []storage.Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, mig.Up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down откатывает n последних применённых миграций и возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, n int) ([]storage.Migration, error) {
+	known := make(map[int64]storage.Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	var done []storage.Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions[:min(n, len(versions))] {
+			mig, ok := known[v]
+			if !ok {
+				return fmt.Errorf("%w: applied version %d has no migration file", storage.ErrInvalidMigration, v)
+			}
+			if strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, ErrNoDownMigration)
+			}
+			if err := m.apply(ctx, conn, mig.Down, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status возвращает все известные миграции и отметку о применении
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var result []MigrationStatus
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if a, ok := applied[mig.Version]; ok {
+				st.Applied, st.AppliedAt = true, a.AppliedAt
+				delete(applied, mig.Version)
+			}
+			result = append(result, st)
+		}
+		for _, a := range applied {
+			result = append(result, a)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+		return nil
+	})
+	return result, err
+}
+
+// apply выполняет SQL миграции и запись в schema_migrations в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, sql string, record func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// withLock выполняет fn на отдельном соединении под advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("ошибка получения блокировки миграций: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	if _, err := conn.Exec(ctx, createSchemaMigrationsSQL); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]MigrationStatus, error) {
+	rows, err := conn.Query(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]MigrationStatus)
+	for rows.Next() {
+		st := MigrationStatus{Applied: true, Missing: true}
+		if err := rows.Scan(&st.Version, &st.Name, &st.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[st.Version] = st
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,39 @@
+-- Тренировки
+CREATE TABLE workouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    sport_type VARCHAR(100) NOT NULL,
+    date TIMESTAMPTZ NOT NULL,
+    duration BIGINT NOT NULL DEFAULT 0,  -- time.Duration (наносекунды)
+    distance NUMERIC(10,2),              -- километры
+    avg_pace INTEGER,                    -- секунды на километр
+    avg_heart_rate INTEGER,
+    max_heart_rate INTEGER,
+    avg_cadence INTEGER,
+    calories INTEGER,
+    description TEXT NOT NULL DEFAULT '',
+    device_name VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Время старта и хэш исходного файла для поиска дубликатов при импорте
+    start_time TIMESTAMPTZ,
+    file_hash VARCHAR(64),
+
+    -- Исходный файл в хранилище
+    original_key VARCHAR(255),
+    original_name VARCHAR(255),
+
+    -- Мягкое удаление: тренировка лежит в корзине до окончательной очистки
+    deleted_at TIMESTAMPTZ,
+
+    -- Версия для оптимистичной блокировки при редактировании
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_workouts_user_date ON workouts(user_id, date DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_workouts_start_time ON workouts(user_id, start_time);
+CREATE INDEX idx_workouts_file_hash ON workouts(user_id, file_hash);
+CREATE INDEX idx_workouts_sport_type ON workouts(user_id, sport_type);
+CREATE INDEX idx_workouts_deleted_at ON workouts(deleted_at) WHERE deleted_at IS NOT NULL;
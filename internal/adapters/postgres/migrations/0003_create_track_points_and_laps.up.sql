@@ -0,0 +1,38 @@
+-- Посекундные точки трека (FIT Record)
+CREATE TABLE track_points (
+    id BIGSERIAL PRIMARY KEY,
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    timestamp TIMESTAMPTZ NOT NULL,
+    latitude DECIMAL(10,8),
+    longitude DECIMAL(11,8),
+    elevation DECIMAL(10,2),
+    heart_rate INTEGER,
+    speed DECIMAL(10,3),
+    power INTEGER,
+    cadence INTEGER,
+    temperature INTEGER,
+    distance DECIMAL(10,2)
+);
+
+CREATE INDEX idx_track_points_workout_id ON track_points(workout_id);
+
+-- Круги (FIT Lap)
+CREATE TABLE workout_laps (
+    id SERIAL PRIMARY KEY,
+    workout_id UUID NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    lap_number INTEGER NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    elapsed_time BIGINT NOT NULL,
+    timer_time BIGINT NOT NULL,
+    distance DECIMAL(10,2),
+    avg_speed DECIMAL(10,3),
+    max_speed DECIMAL(10,3),
+    avg_heart_rate INTEGER,
+    max_heart_rate INTEGER,
+    avg_cadence INTEGER,
+    max_cadence INTEGER,
+    calories INTEGER,
+    lap_trigger VARCHAR(50),
+    UNIQUE (workout_id, lap_number)
+);
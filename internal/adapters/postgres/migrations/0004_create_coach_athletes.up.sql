@@ -0,0 +1,10 @@
+-- Связи тренер - спортсмен. Тренер видит тренировки спортсмена после подтверждения связи
+CREATE TABLE coach_athletes (
+    coach_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    athlete_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (coach_id, athlete_id)
+);
+
+CREATE INDEX idx_coach_athletes_athlete_id ON coach_athletes(athlete_id);
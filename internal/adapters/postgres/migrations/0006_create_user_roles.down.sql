@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE users SET is_admin = TRUE WHERE id IN (SELECT user_id FROM user_roles WHERE role = 'admin');
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,11 @@
+-- Роли пользователей вместо флага is_admin. Права ролей задаются в коде (entity.Permission)
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO user_roles (user_id, role) SELECT id, 'athlete' FROM users;
+INSERT INTO user_roles (user_id, role) SELECT id, 'admin' FROM users WHERE is_admin;
+
+ALTER TABLE users DROP COLUMN is_admin;
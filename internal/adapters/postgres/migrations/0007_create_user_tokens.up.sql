@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+-- Пользователи, зарегистрированные до подтверждения почты, считаются подтверждёнными
+UPDATE users SET email_verified_at = created_at;
+
+-- Одноразовые токены из писем: подтверждение почты и сброс пароля. Хранится только sha256
+CREATE TABLE user_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_user_tokens_user_id ON user_tokens(user_id, purpose);
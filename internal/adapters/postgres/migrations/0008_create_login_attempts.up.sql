@@ -0,0 +1,16 @@
+-- Счётчик неудачных попыток входа подряд и прогрессивная блокировка учётной записи
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- Журнал неудачных попыток входа, в том числе с незарегистрированными адресами
+CREATE TABLE login_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    reason VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_login_attempts_user_id ON login_attempts(user_id, created_at);
@@ -0,0 +1,15 @@
+-- Персональные токены доступа для скриптов и устройств синхронизации. Хранится только sha256
+CREATE TABLE access_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    prefix VARCHAR(20) NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_access_tokens_user_id ON access_tokens(user_id);
@@ -0,0 +1 @@
+ALTER TABLE workouts DROP COLUMN merged_at;
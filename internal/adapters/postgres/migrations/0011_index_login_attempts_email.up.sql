@@ -0,0 +1,3 @@
+-- Попытки входа с незарегистрированным адресом считаются по адресу, чтобы блокировка
+-- срабатывала для них так же, как для существующих учётных записей
+CREATE INDEX idx_login_attempts_email ON login_attempts(email, reason);
@@ -0,0 +1,3 @@
+-- Время последней ручной правки полей, взятых из файла (PATCH). Пересборка из
+-- исходного файла такие тренировки пропускает, чтобы не затереть правки
+ALTER TABLE workouts ADD COLUMN edited_at TIMESTAMPTZ;
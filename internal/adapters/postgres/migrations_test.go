@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"testing"
+	"workout/internal/adapters/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := storage.LoadMigrations(migrationsFS, "migrations")
+	require.NoError(t, err)
+
+	for i, m := range migrations {
+		assert.Equal(t, int64(i+1), m.Version, "версии миграций идут без пропусков")
+		assert.NotEmpty(t, m.Down, "у миграции %d_%s нет down-скрипта", m.Version, m.Name)
+	}
+}
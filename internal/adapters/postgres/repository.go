@@ -13,6 +13,21 @@ type postgres struct {
 }
 
 func NewPostgresAdapter(cfg config.DatabaseConfig) (*postgres, error) {
+	pool, err := NewPool(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgres{db: pool}, nil
+}
+
+// NewPostgresAdapterFromPool создаёт адаптер поверх готового пула соединений
+func NewPostgresAdapterFromPool(pool *pgxpool.Pool) *postgres {
+	return &postgres{db: pool}
+}
+
+// NewPool создаёт пул соединений по настройкам базы данных и проверяет подключение
+func NewPool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
 	// Формируем строку подключения
 	connString := fmt.Sprintf(
 		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
@@ -36,17 +51,18 @@ func NewPostgresAdapter(cfg config.DatabaseConfig) (*postgres, error) {
 	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
 
 	// Создаем пул соединений
-	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания пула соединений: %w", err)
 	}
 
 	// Проверяем подключение
-	if err := pool.Ping(context.Background()); err != nil {
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
 	}
 
-	return &postgres{db: pool}, nil
+	return pool, nil
 }
 
 // Close закрывает соединение с базой данных
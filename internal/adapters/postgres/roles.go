@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUserRoles возвращает роли пользователя, упорядоченные по имени
+func (p *postgres) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	const op = "storage.postgres.UserRoles"
+
+	var roles []string
+	err := p.conn(ctx).QueryRow(ctx, `
+		SELECT COALESCE(array_agg(r.role ORDER BY r.role) FILTER (WHERE r.role IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN user_roles r ON r.user_id = u.id
+		WHERE u.id = $1
+		GROUP BY u.id`, userID).Scan(&roles)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return toRoles(roles), nil
+}
+
+// SetUserRoles заменяет роли пользователя
+func (p *postgres) SetUserRoles(ctx context.Context, userID uuid.UUID, roles []entity.Role) error {
+	const op = "storage.postgres.SetUserRoles"
+
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		var exists bool
+		if err := p.conn(ctx).QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return storage.ErrUserNotFound
+		}
+
+		if _, err := p.conn(ctx).Exec(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := p.conn(ctx).Exec(ctx, `
+			INSERT INTO user_roles (user_id, role)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING`, userID, fromRoles(roles))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListUsers возвращает всех пользователей с ролями, упорядоченных по email
+func (p *postgres) ListUsers(ctx context.Context) ([]entity.User, error) {
+	const op = "storage.postgres.ListUsers"
+
+	rows, err := p.conn(ctx).Query(ctx, `
+		SELECT u.id, u.email, u.name,
+			COALESCE(array_agg(r.role ORDER BY r.role) FILTER (WHERE r.role IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN user_roles r ON r.user_id = u.id
+		GROUP BY u.id
+		ORDER BY u.email`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		var (
+			u     entity.User
+			id    uuid.UUID
+			roles []string
+		)
+		if err := rows.Scan(&id, &u.Email, &u.Name, &roles); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		u.ID = id.String()
+		u.Roles = toRoles(roles)
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, nil
+}
+
+func toRoles(names []string) []entity.Role {
+	roles := make([]entity.Role, len(names))
+	for i, n := range names {
+		roles[i] = entity.Role(n)
+	}
+	return roles
+}
+
+func fromRoles(roles []entity.Role) []string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = string(r)
+	}
+	return names
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+func scanSession(row pgx.Row) (*entity.Session, error) {
+	var (
+		s         entity.Session
+		revokedAt *time.Time
+	)
+	if err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if revokedAt != nil {
+		s.RevokedAt = *revokedAt
+	}
+	return &s, nil
+}
+
+// CreateSession сохраняет новую сессию вместе с её первым refresh-токеном
+func (p *postgres) CreateSession(ctx context.Context, s *entity.Session, tokenHash string) error {
+	const op = "storage.postgres.CreateSession"
+
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := p.conn(ctx).Exec(ctx, `
+			INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_used_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			s.ID, s.UserID, s.UserAgent, s.IP, s.CreatedAt, s.LastUsedAt, s.ExpiresAt,
+		); err != nil {
+			return err
+		}
+
+		_, err := p.conn(ctx).Exec(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES ($1, $2, $3)`,
+			tokenHash, s.ID, s.CreatedAt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetSession возвращает сессию по id, в том числе отозванную или истёкшую
+func (p *postgres) GetSession(ctx context.Context, sessionID uuid.UUID) (*entity.Session, error) {
+	const op = "storage.postgres.Session"
+
+	s, err := scanSession(p.conn(ctx).QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, sessionID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return s, nil
+}
+
+// ListSessions возвращает активные на момент now сессии пользователя, недавно использованные первыми
+func (p *postgres) ListSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error) {
+	const op = "storage.postgres.ListSessions"
+
+	rows, err := p.conn(ctx).Query(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC, id`, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession отзывает активную сессию пользователя. Чужая или уже отозванная сессия не находится
+func (p *postgres) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.RevokeSession"
+
+	tag, err := p.conn(ctx).Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	return nil
+}
+
+// GetRefreshToken ищет refresh-токен по хэшу, в том числе уже использованный
+func (p *postgres) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	const op = "storage.postgres.RefreshToken"
+
+	var (
+		t      entity.RefreshToken
+		usedAt *time.Time
+	)
+	err := p.conn(ctx).QueryRow(ctx,
+		`SELECT token_hash, session_id, created_at, used_at FROM refresh_tokens WHERE token_hash = $1`, tokenHash).
+		Scan(&t.Hash, &t.SessionID, &t.CreatedAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if usedAt != nil {
+		t.UsedAt = *usedAt
+	}
+	return &t, nil
+}
+
+// UseRefreshToken помечает токен использованным. Если его уже использовал параллельный
+// запрос, возвращает storage.ErrRefreshTokenUsed
+func (p *postgres) UseRefreshToken(ctx context.Context, tokenHash string, now time.Time) error {
+	const op = "storage.postgres.UseRefreshToken"
+
+	tag, err := p.conn(ctx).Exec(ctx,
+		`UPDATE refresh_tokens SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL`, tokenHash, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+	return nil
+}
+
+// AddRefreshToken выдаёт сессии новый refresh-токен и продлевает её до expiresAt
+func (p *postgres) AddRefreshToken(ctx context.Context, sessionID uuid.UUID, tokenHash string, now, expiresAt time.Time) error {
+	const op = "storage.postgres.AddRefreshToken"
+
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		tag, err := p.conn(ctx).Exec(ctx, `
+			UPDATE sessions
+			SET last_used_at = $2, expires_at = $3
+			WHERE id = $1 AND revoked_at IS NULL`, sessionID, now, expiresAt)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return storage.ErrSessionNotFound
+		}
+
+		_, err = p.conn(ctx).Exec(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES ($1, $2, $3)`,
+			tokenHash, sessionID, now)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RevokeUserSessions отзывает все активные сессии пользователя, например после смены пароля
+func (p *postgres) RevokeUserSessions(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.RevokeUserSessions"
+
+	_, err := p.conn(ctx).Exec(ctx, `
+		UPDATE sessions
+		SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
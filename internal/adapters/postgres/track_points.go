@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+// trackPointColumns - колонки track_points, которые заполняет insertTrackPoints
+var trackPointColumns = []string{
+	"workout_id", "timestamp", "latitude", "longitude", "elevation",
+	"heart_rate", "speed", "power", "cadence", "temperature", "distance",
+}
+
+// insertTrackPoints сохраняет посекундные данные тренировки через COPY:
+// у часовой тренировки несколько тысяч точек, и построчные INSERT заметно медленнее.
+// Значения хранятся в привычных единицах: градусы, метры, м/с
+func (p *postgres) insertTrackPoints(ctx context.Context, workoutID uuid.UUID, records []entity.RecordData) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	rows := pgx.CopyFromSlice(len(records), func(i int) ([]any, error) {
+		r := records[i]
+
+		var lat, lon *float64
+		if r.PositionLat != 0 || r.PositionLon != 0 {
+			la, lo := utils.SemicirclesToDegrees(r.PositionLat), utils.SemicirclesToDegrees(r.PositionLon)
+			lat, lon = &la, &lo
+		}
+
+		var elevation *float64
+		if r.Altitude != 0 {
+			e := utils.AltitudeToMeters(r.Altitude)
+			elevation = &e
+		}
+
+		return []any{
+			workoutID, r.Timestamp, lat, lon, elevation,
+			nullIfZero(int32(r.HeartRate)), nullIfZero(float64(r.Speed) / 1000.0), nullIfZero(int32(r.Power)),
+			nullIfZero(int32(r.Cadence)), int32(r.Temperature), nullIfZero(float64(r.Distance) / 100.0),
+		}, nil
+	})
+
+	_, err := p.conn(ctx).CopyFrom(ctx, pgx.Identifier{"track_points"}, trackPointColumns, rows)
+	return err
+}
+
+// GetTrackPoints возвращает посекундные данные тренировки, если она принадлежит пользователю
+func (p *postgres) GetTrackPoints(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.RecordData, error) {
+	query := `
+		SELECT t.timestamp, t.latitude, t.longitude, t.elevation,
+			COALESCE(t.heart_rate, 0), COALESCE(t.speed, 0), COALESCE(t.power, 0),
+			COALESCE(t.cadence, 0), COALESCE(t.temperature, 0), COALESCE(t.distance, 0)
+		FROM track_points t
+		JOIN workouts w ON w.id = t.workout_id
+		WHERE t.workout_id = $1 AND w.user_id = $2 AND w.deleted_at IS NULL
+		ORDER BY t.id;`
+
+	rows, err := p.conn(ctx).Query(ctx, query, workoutID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []entity.RecordData{}
+	for rows.Next() {
+		var (
+			r                   entity.RecordData
+			lat, lon, elevation *float64
+			speed, distance     float64
+		)
+		if err := rows.Scan(
+			&r.Timestamp, &lat, &lon, &elevation,
+			&r.HeartRate, &speed, &r.Power, &r.Cadence, &r.Temperature, &distance,
+		); err != nil {
+			return nil, err
+		}
+		if lat != nil && lon != nil {
+			r.PositionLat = utils.DegreesToSemicircles(*lat)
+			r.PositionLon = utils.DegreesToSemicircles(*lon)
+		}
+		if elevation != nil {
+			r.Altitude = utils.MetersToAltitude(*elevation)
+		}
+		r.Speed = uint16(speed*1000 + 0.5)
+		r.Distance = uint32(distance*100 + 0.5)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// nullIfZero превращает нулевое ("нет данных") значение в NULL
+func nullIfZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// SoftDeleteWorkout переносит тренировку пользователя в корзину.
+// Трек, круги и исходный файл остаются до окончательной очистки
+func (p *postgres) SoftDeleteWorkout(ctx context.Context, userID, workoutID uuid.UUID) error {
+	query := `
+		UPDATE workouts
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	tag, err := p.conn(ctx).Exec(ctx, query, workoutID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrWorkoutNotFound
+	}
+	return nil
+}
+
+// GetDeletedWorkouts возвращает тренировки пользователя в корзине, удалённые после deletedAfter
+func (p *postgres) GetDeletedWorkouts(ctx context.Context, userID uuid.UUID, deletedAfter time.Time) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = $1 AND deleted_at > $2
+		ORDER BY deleted_at DESC;`
+
+	return p.queryWorkouts(ctx, query, userID, deletedAfter)
+}
+
+// RestoreWorkout возвращает тренировку из корзины, если она удалена после deletedAfter
+func (p *postgres) RestoreWorkout(ctx context.Context, userID, workoutID uuid.UUID, deletedAfter time.Time) (*entity.Workout, error) {
+	query := `
+		UPDATE workouts
+		SET deleted_at = NULL
+		WHERE id = $1 AND user_id = $2 AND deleted_at > $3
+		RETURNING ` + workoutColumns
+
+	w, err := scanWorkout(p.conn(ctx).QueryRow(ctx, query, workoutID, userID, deletedAfter))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrWorkoutNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// PurgeDeletedWorkouts окончательно удаляет тренировки, лежащие в корзине с момента до before,
+// вместе с треком и кругами. Возвращает число удалённых тренировок и ключи исходных файлов,
+// на которые больше не ссылается ни одна тренировка
+func (p *postgres) PurgeDeletedWorkouts(ctx context.Context, before time.Time) (int, []string, error) {
+	var (
+		purged   int
+		orphaned []string
+	)
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		rows, err := p.conn(ctx).Query(ctx, `
+			DELETE FROM workouts
+			WHERE deleted_at < $1
+			RETURNING COALESCE(original_key, '')`, before)
+		if err != nil {
+			return err
+		}
+
+		var keys []string
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return err
+			}
+			purged++
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Тот же файл мог быть загружен снова после удаления - такой исходник оставляем
+		if len(keys) == 0 {
+			return nil
+		}
+		return p.conn(ctx).QueryRow(ctx, `
+			SELECT COALESCE(array_agg(DISTINCT k), '{}')
+			FROM unnest($1::text[]) AS k
+			WHERE NOT EXISTS (SELECT 1 FROM workouts WHERE original_key = k)`, keys).Scan(&orphaned)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return purged, orphaned, nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateUserToken сохраняет одноразовый токен из письма
+func (p *postgres) CreateUserToken(ctx context.Context, t *entity.UserToken) error {
+	const op = "storage.postgres.CreateUserToken"
+
+	_, err := p.conn(ctx).Exec(ctx, `
+		INSERT INTO user_tokens (token_hash, user_id, purpose, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		t.Hash, t.UserID, string(t.Purpose), t.CreatedAt, t.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UseUserToken погашает действующий токен и возвращает его владельца. Остальные неиспользованные
+// токены того же назначения тоже гасятся: действует только одна, последняя использованная ссылка
+func (p *postgres) UseUserToken(ctx context.Context, tokenHash string, purpose entity.TokenPurpose, now time.Time) (uuid.UUID, error) {
+	const op = "storage.postgres.UseUserToken"
+
+	var userID uuid.UUID
+	err := p.WithinTx(ctx, func(ctx context.Context) error {
+		err := p.conn(ctx).QueryRow(ctx, `
+			UPDATE user_tokens
+			SET used_at = $3
+			WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+			RETURNING user_id`, tokenHash, string(purpose), now).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return storage.ErrTokenNotFound
+			}
+			return err
+		}
+
+		_, err = p.conn(ctx).Exec(ctx, `
+			UPDATE user_tokens
+			SET used_at = $3
+			WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, string(purpose), now)
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return userID, nil
+}
+
+// SetEmailVerified отмечает адрес почты пользователя подтверждённым
+func (p *postgres) SetEmailVerified(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.postgres.SetEmailVerified"
+
+	tag, err := p.conn(ctx).Exec(ctx, `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, $2), updated_at = $2
+		WHERE id = $1`, userID, now)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// UpdatePassword заменяет хэш пароля пользователя
+func (p *postgres) UpdatePassword(ctx context.Context, userID uuid.UUID, passHash []byte) error {
+	const op = "storage.postgres.UpdatePassword"
+
+	tag, err := p.conn(ctx).Exec(ctx, `
+		UPDATE users
+		SET password_hash = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, userID, passHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто из памяти удаляются полные корзины: они ничем не отличаются от новых
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // когда корзина наполнится, если её не трогать
+}
+
+// memoryLimiter хранит корзины в памяти процесса. Лимиты не общие для нескольких экземпляров сервиса
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	if rule.Disabled() {
+		return true, 0, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = min(float64(rule.Burst), b.tokens+rule.refill(now.Sub(b.updated)))
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = rule.wait(1 - b.tokens)
+	}
+	b.full = now.Add(rule.wait(float64(rule.Burst) - b.tokens))
+
+	return allowed, retryAfter, nil
+}
+
+// sweep удаляет наполнившиеся корзины, чтобы память не росла с числом разных IP
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
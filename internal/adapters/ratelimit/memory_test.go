@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, time.June, 1, 7, 30, 0, 0, time.UTC)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	rule := Rule{Burst: 3, Period: 3 * time.Minute}
+
+	for range 3 {
+		allowed, _, err := l.Allow(ctx, "ip:1", rule)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter, err := l.Allow(ctx, "ip:1", rule)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// Другие ключи считаются отдельно
+	allowed, _, _ = l.Allow(ctx, "ip:2", rule)
+	assert.True(t, allowed)
+
+	// Через минуту в корзине появился один токен
+	now = now.Add(time.Minute)
+	allowed, _, _ = l.Allow(ctx, "ip:1", rule)
+	assert.True(t, allowed)
+	allowed, retryAfter, _ = l.Allow(ctx, "ip:1", rule)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// Наполнившиеся корзины удаляются из памяти
+	now = now.Add(time.Hour)
+	allowed, _, _ = l.Allow(ctx, "ip:3", rule)
+	assert.True(t, allowed)
+	assert.Len(t, l.buckets, 1)
+
+	allowed, _, _ = l.Allow(ctx, "ip:1", Rule{})
+	assert.True(t, allowed, "пустое правило ничего не ограничивает")
+}
@@ -0,0 +1,50 @@
+// Package ratelimit - ограничение частоты запросов алгоритмом token bucket.
+// Корзины хранятся в памяти процесса или в Redis, если экземпляров сервиса несколько
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+	"workout/internal/config"
+)
+
+// Rule - корзина на Burst запросов, которая полностью наполняется за Period.
+// Пустое правило (Burst == 0) ничего не ограничивает
+type Rule struct {
+	Burst  int
+	Period time.Duration
+}
+
+func (r Rule) Disabled() bool {
+	return r.Burst <= 0 || r.Period <= 0
+}
+
+// refill - сколько токенов добавляется в корзину за elapsed
+func (r Rule) refill(elapsed time.Duration) float64 {
+	return float64(elapsed) * float64(r.Burst) / float64(r.Period)
+}
+
+// wait - за сколько в корзину добавится tokens токенов, с округлением вверх
+func (r Rule) wait(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(r.Period) / float64(r.Burst)))
+}
+
+// Limiter забирает токен из корзины key. Если корзина пуста, запрос отклоняется,
+// а retryAfter сообщает, когда в ней появится следующий токен
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// New создаёт ограничитель по типу хранилища корзин из конфигурации
+func New(cfg config.RateLimitConfig) (Limiter, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryLimiter(), nil
+	case "redis":
+		return NewRedisLimiter(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище ограничений частоты: %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bucketScript - token bucket в Redis. Время берётся у Redis, чтобы расхождение часов
+// экземпляров сервиса не влияло на корзины. Возвращает {разрешено, ожидание в мс}
+var bucketScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(b[1]) or burst
+local ts = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * burst / period)
+
+local allowed, wait = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * period / burst)
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) * period / burst) + 1000)
+return {allowed, wait}
+`)
+
+// keyPrefix отделяет корзины от остальных данных в общей базе Redis
+const keyPrefix = "ratelimit:"
+
+// redisTimeout ограничивает подключение и обмен с Redis: медленный Redis не должен
+// задерживать вход и регистрацию дольше этого времени
+const redisTimeout = 2 * time.Second
+
+// redisLimiter хранит корзины в Redis (или совместимом сервере), поэтому лимиты общие
+// для всех экземпляров сервиса. Запросы идут через пул соединений клиента go-redis
+type redisLimiter struct {
+	client *redis.Client
+}
+
+func NewRedisLimiter(addr, password string, db int) (*redisLimiter, error) {
+	if addr == "" {
+		return nil, errors.New("не указан REDIS_ADDR")
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		DialTimeout:  redisTimeout,
+		ReadTimeout:  redisTimeout,
+		WriteTimeout: redisTimeout,
+		PoolTimeout:  redisTimeout,
+		// Без повторов: при недоступном Redis проверка сразу возвращает ошибку,
+		// а не задерживает запрос на время нескольких попыток
+		MaxRetries: -1,
+	})
+	return &redisLimiter{client: client}, nil
+}
+
+func (r *redisLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	const op = "ratelimit.redis.Allow"
+
+	if rule.Disabled() {
+		return true, 0, nil
+	}
+
+	period := max(rule.Period.Milliseconds(), 1)
+
+	// Run вызывает скрипт по хэшу и загружает его в Redis, если его там ещё нет
+	reply, err := bucketScript.Run(ctx, r.client, []string{keyPrefix + key}, rule.Burst, period).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(reply) != 2 {
+		return false, 0, fmt.Errorf("%s: unexpected reply %v", op, reply)
+	}
+	return reply[0] == 1, time.Duration(reply[1]) * time.Millisecond, nil
+}
+
+// Close закрывает соединения с Redis
+func (r *redisLimiter) Close() error {
+	return r.client.Close()
+}
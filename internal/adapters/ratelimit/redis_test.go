@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisLimiterScript(t *testing.T) {
+	srv := miniredis.RunT(t)
+	srv.RequireAuth("secret")
+
+	l, err := NewRedisLimiter(srv.Addr(), "secret", 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	ctx := context.Background()
+	rule := Rule{Burst: 2, Period: time.Hour}
+	for range 2 {
+		allowed, _, err := l.Allow(ctx, "login:ip:10.0.0.1", rule)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter, err := l.Allow(ctx, "login:ip:10.0.0.1", rule)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, 30*time.Minute, retryAfter, float64(time.Second))
+
+	// Корзина лежит под общим префиксом и истекает, когда снова наполнится
+	assert.True(t, srv.Exists("ratelimit:login:ip:10.0.0.1"))
+	assert.Greater(t, srv.TTL("ratelimit:login:ip:10.0.0.1"), time.Duration(0))
+
+	// Недоступный Redis - ошибка, решение о пропуске запроса принимает вызывающий
+	srv.Close()
+	_, _, err = l.Allow(ctx, "login:ip:10.0.0.1", rule)
+	assert.Error(t, err)
+}
+
+// Проверка на настоящем Redis:
+//
+//	TEST_REDIS_ADDR=localhost:6379 go test ./internal/adapters/ratelimit
+func TestRedisLimiter(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR не задан, проверки на redis пропущены")
+	}
+
+	l, err := NewRedisLimiter(addr, os.Getenv("TEST_REDIS_PASSWORD"), 0)
+	require.NoError(t, err)
+	defer l.Close()
+
+	ctx := context.Background()
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	rule := Rule{Burst: 2, Period: time.Hour}
+
+	for range 2 {
+		allowed, _, err := l.Allow(ctx, key, rule)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter, err := l.Allow(ctx, key, rule)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.InDelta(t, 30*time.Minute, retryAfter, float64(time.Second))
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"workout/internal/adapters/memory"
+	"workout/internal/adapters/postgres"
+	"workout/internal/adapters/sqlite"
+	"workout/internal/config"
+	"workout/internal/service/activity"
+	"workout/internal/service/auth"
+)
+
+// Repository - хранилище тренировок и пользователей
+type Repository interface {
+	activity.Activity
+	auth.Auth
+	Close() error
+}
+
+// New создаёт хранилище, выбранное в конфигурации (STORAGE)
+func New(storage config.StorageConfig, db config.DatabaseConfig) (Repository, error) {
+	switch storage.Type {
+	case "postgres", "":
+		return postgres.NewPostgresAdapter(db)
+	case "sqlite":
+		return sqlite.NewSQLiteAdapter(storage.SQLitePath)
+	case "memory":
+		log.Println("Данные хранятся в памяти и будут потеряны при перезапуске")
+		return memory.NewMemoryAdapter(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", storage.Type)
+	}
+}
@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const accessTokenColumns = `id, user_id, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+func scanAccessToken(row scanner) (*entity.AccessToken, error) {
+	var (
+		t                              entity.AccessToken
+		scopes                         string
+		createdAt                      int64
+		lastUsedAt, expiresAt, revoked sql.NullInt64
+	)
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &scopes, &createdAt, &lastUsedAt, &expiresAt, &revoked); err != nil {
+		return nil, err
+	}
+	for _, s := range strings.Fields(scopes) {
+		t.Scopes = append(t.Scopes, entity.Scope(s))
+	}
+	t.CreatedAt = fromNanos(createdAt)
+	if lastUsedAt.Valid {
+		t.LastUsedAt = fromNanos(lastUsedAt.Int64)
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = fromNanos(expiresAt.Int64)
+	}
+	if revoked.Valid {
+		t.RevokedAt = fromNanos(revoked.Int64)
+	}
+	return &t, nil
+}
+
+// CreateAccessToken сохраняет персональный токен доступа
+func (s *sqliteDB) CreateAccessToken(ctx context.Context, t *entity.AccessToken, tokenHash string) error {
+	const op = "storage.sqlite.CreateAccessToken"
+
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, sc := range t.Scopes {
+		scopes = append(scopes, string(sc))
+	}
+
+	_, err := s.conn(ctx).ExecContext(ctx, `
+		INSERT INTO access_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.UserID, t.Name, tokenHash, t.Prefix, strings.Join(scopes, " "), t.CreatedAt.UnixNano(), nanos(t.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetAccessToken возвращает токен по хэшу, в том числе отозванный или истёкший
+func (s *sqliteDB) GetAccessToken(ctx context.Context, tokenHash string) (*entity.AccessToken, error) {
+	const op = "storage.sqlite.AccessToken"
+
+	t, err := scanAccessToken(s.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+accessTokenColumns+` FROM access_tokens WHERE token_hash = ?`, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return t, nil
+}
+
+// ListAccessTokens возвращает неотозванные токены пользователя, новые первыми
+func (s *sqliteDB) ListAccessTokens(ctx context.Context, userID uuid.UUID) ([]entity.AccessToken, error) {
+	const op = "storage.sqlite.ListAccessTokens"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+		SELECT `+accessTokenColumns+`
+		FROM access_tokens
+		WHERE user_id = ? AND revoked_at IS NULL
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tokens := []entity.AccessToken{}
+	for rows.Next() {
+		t, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tokens = append(tokens, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tokens, nil
+}
+
+// RevokeAccessToken отзывает токен пользователя. Чужой или уже отозванный токен - storage.ErrTokenNotFound
+func (s *sqliteDB) RevokeAccessToken(ctx context.Context, userID, tokenID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.RevokeAccessToken"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE access_tokens
+		SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, now.UnixNano(), tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+	return nil
+}
+
+// RevokeUserAccessTokens отзывает все действующие токены пользователя, например после сброса пароля
+func (s *sqliteDB) RevokeUserAccessTokens(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.RevokeUserAccessTokens"
+
+	_, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE access_tokens
+		SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL`, now.UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// TouchAccessToken запоминает время последнего использования токена
+func (s *sqliteDB) TouchAccessToken(ctx context.Context, tokenID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.TouchAccessToken"
+
+	if _, err := s.conn(ctx).ExecContext(ctx, `UPDATE access_tokens SET last_used_at = ? WHERE id = ?`, now.UnixNano(), tokenID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,476 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+func (s *sqliteDB) CreateWorkout(ctx context.Context, workout *entity.Workout) (*entity.Workout, error) {
+	query := `
+		INSERT INTO workouts (
+			id, user_id, name, sport_type, date, duration,
+			distance, avg_pace, avg_heart_rate, max_heart_rate,
+			avg_cadence, calories, description, created_at, updated_at,
+			start_time, file_hash, original_key, original_name
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		) RETURNING version`
+
+	distance, err := distanceValue(workout.Distance)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	// Тренировка, её трек и круги сохраняются вместе или не сохраняются вовсе
+	err = s.WithinTx(ctx, func(ctx context.Context) error {
+		row := s.conn(ctx).QueryRowContext(ctx, query,
+			id, workout.UserID, workout.Name, workout.SportType, nanos(workout.Date),
+			int64(workout.Duration), distance,
+			workout.AvgPace, workout.AvgHeartRate, workout.MaxHeartRate,
+			workout.AvgCadence, workout.Calories, workout.Description,
+			nanos(workout.CreatedAt), nanos(workout.UpdatedAt),
+			nanos(workout.StartTime), nullIfZero(workout.FileHash),
+			nullIfZero(workout.OriginalKey), nullIfZero(workout.OriginalName),
+		)
+
+		if err := row.Scan(&workout.Version); err != nil {
+			return err
+		}
+
+		if err := s.insertTrackPoints(ctx, id, workout.RecordData); err != nil {
+			return err
+		}
+
+		return s.insertLaps(ctx, id, workout.Laps)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workout.ID = id
+	return workout, nil
+}
+
+// workoutColumns - колонки тренировки в порядке, который ожидает scanWorkout
+const workoutColumns = `
+	id, user_id, name, sport_type, date, duration, distance,
+	COALESCE(avg_pace, 0), COALESCE(avg_heart_rate, 0), COALESCE(max_heart_rate, 0),
+	COALESCE(avg_cadence, 0), COALESCE(calories, 0), description, created_at, updated_at,
+	start_time, COALESCE(file_hash, ''),
+	COALESCE(original_key, ''), COALESCE(original_name, ''), deleted_at, version, merged_at, edited_at`
+
+// scanner - общий метод sql.Row и sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWorkout(row scanner) (*entity.Workout, error) {
+	var (
+		w                                    entity.Workout
+		date, duration, createdAt, updatedAt int64
+		distance                             sql.NullFloat64
+		startTime, deletedAt, mergedAt       sql.NullInt64
+		editedAt                             sql.NullInt64
+	)
+	if err := row.Scan(
+		&w.ID, &w.UserID, &w.Name, &w.SportType, &date, &duration,
+		&distance, &w.AvgPace, &w.AvgHeartRate, &w.MaxHeartRate,
+		&w.AvgCadence, &w.Calories, &w.Description, &createdAt, &updatedAt,
+		&startTime, &w.FileHash, &w.OriginalKey, &w.OriginalName, &deletedAt, &w.Version, &mergedAt, &editedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	w.Date = fromNanos(date)
+	w.Duration = time.Duration(duration)
+	if distance.Valid {
+		// Как NUMERIC(10,2) в postgres - два знака после запятой
+		w.Distance = strconv.FormatFloat(distance.Float64, 'f', 2, 64)
+	}
+	w.CreatedAt = fromNanos(createdAt)
+	w.UpdatedAt = fromNanos(updatedAt)
+	if startTime.Valid {
+		w.StartTime = fromNanos(startTime.Int64)
+	}
+	if deletedAt.Valid {
+		w.DeletedAt = fromNanos(deletedAt.Int64)
+	}
+	if mergedAt.Valid {
+		w.MergedAt = fromNanos(mergedAt.Int64)
+	}
+	if editedAt.Valid {
+		w.EditedAt = fromNanos(editedAt.Int64)
+	}
+	return &w, nil
+}
+
+// sortColumns - выражения полей, по которым можно сортировать список тренировок,
+// и разбор значения поля из курсора. Пустые значения заменяются нулём,
+// иначе сравнение по курсору пропустит строки с NULL
+var sortColumns = map[string]struct {
+	expr  string
+	parse func(v string) (any, error)
+}{
+	dto.SortByDate:         {"date", parseCursorTime},
+	dto.SortByDistance:     {"COALESCE(distance, 0)", parseCursorFloat},
+	dto.SortByDuration:     {"duration", parseCursorInt},
+	dto.SortByAvgPace:      {"COALESCE(avg_pace, 0)", parseCursorInt},
+	dto.SortByAvgHeartRate: {"COALESCE(avg_heart_rate, 0)", parseCursorInt},
+	dto.SortByCalories:     {"COALESCE(calories, 0)", parseCursorInt},
+}
+
+func parseCursorTime(v string) (any, error) {
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return nil, err
+	}
+	return t.UnixNano(), nil
+}
+
+func parseCursorFloat(v string) (any, error) { return strconv.ParseFloat(v, 64) }
+
+func parseCursorInt(v string) (any, error) { return strconv.ParseInt(v, 10, 64) }
+
+// GetWorkouts возвращает страницу тренировок пользователя с учётом фильтров и сортировки.
+// Для следующей страницы используется курсор (keyset): (поле сортировки, id) последней строки
+func (s *sqliteDB) GetWorkouts(ctx context.Context, userID uuid.UUID, f dto.WorkoutFilter) ([]entity.Workout, error) {
+	sort, ok := sortColumns[f.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", f.SortBy)
+	}
+
+	conditions := []string{"user_id = ?", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	add := func(cond string, vals ...any) {
+		conditions = append(conditions, cond)
+		args = append(args, vals...)
+	}
+
+	if !f.StartDate.IsZero() {
+		add("date >= ?", f.StartDate.UnixNano())
+	}
+	if !f.EndDate.IsZero() {
+		// Конец периода включительно: до начала следующего дня
+		add("date < ?", f.EndDate.AddDate(0, 0, 1).UnixNano())
+	}
+	if f.SportType != "" {
+		add("sport_type = ?", f.SportType)
+	}
+	if f.MinDistance > 0 {
+		add("distance >= ?", f.MinDistance)
+	}
+	if f.MaxDistance > 0 {
+		add("distance <= ?", f.MaxDistance)
+	}
+	if f.MinDuration > 0 {
+		add("duration >= ?", int64(f.MinDuration))
+	}
+	if f.MaxDuration > 0 {
+		add("duration <= ?", int64(f.MaxDuration))
+	}
+	if f.Query != "" {
+		pattern := "%" + escapeLike(strings.ToLower(f.Query)) + "%"
+		add(lowerFunc+`(name) LIKE ? ESCAPE '\' OR `+lowerFunc+`(description) LIKE ? ESCAPE '\'`, pattern, pattern)
+		conditions[len(conditions)-1] = "(" + conditions[len(conditions)-1] + ")"
+	}
+
+	order, cmp := "DESC", "<"
+	if f.SortAsc {
+		order, cmp = "ASC", ">"
+	}
+	if f.After != nil {
+		value, err := sort.parse(f.After.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor value %q: %w", f.After.Value, err)
+		}
+		add("("+sort.expr+", id) "+cmp+" (?, ?)", value, f.After.ID)
+	}
+
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + sort.expr + ` ` + order + `, id ` + order
+
+	// В SQLite OFFSET допустим только вместе с LIMIT; -1 - без ограничения
+	if f.Limit > 0 || f.Offset > 0 {
+		limit := f.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, f.Offset)
+	}
+
+	return s.queryWorkouts(ctx, query, args...)
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE, чтобы искать их буквально
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (s *sqliteDB) queryWorkouts(ctx context.Context, query string, args ...any) ([]entity.Workout, error) {
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workouts []entity.Workout
+	for rows.Next() {
+		w, err := scanWorkout(rows)
+		if err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, *w)
+	}
+	return workouts, rows.Err()
+}
+
+// queryWorkout возвращает одну тренировку или storage.ErrWorkoutNotFound
+func (s *sqliteDB) queryWorkout(ctx context.Context, query string, args ...any) (*entity.Workout, error) {
+	w, err := scanWorkout(s.conn(ctx).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrWorkoutNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// FindWorkoutByHash ищет тренировку пользователя, загруженную из файла с тем же содержимым
+func (s *sqliteDB) FindWorkoutByHash(ctx context.Context, userID uuid.UUID, hash string) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = ? AND file_hash = ? AND deleted_at IS NULL
+		LIMIT 1;`
+
+	return s.queryWorkout(ctx, query, userID, hash)
+}
+
+// FindWorkoutsByStartTime возвращает тренировки пользователя, начавшиеся в интервале [from, to]
+func (s *sqliteDB) FindWorkoutsByStartTime(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = ? AND start_time BETWEEN ? AND ? AND deleted_at IS NULL
+		ORDER BY start_time;`
+
+	return s.queryWorkouts(ctx, query, userID, from.UnixNano(), to.UnixNano())
+}
+
+// GetWorkoutOriginal возвращает тренировку пользователя со ссылкой на исходный файл
+func (s *sqliteDB) GetWorkoutOriginal(ctx context.Context, userID, workoutID uuid.UUID) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL;`
+
+	return s.queryWorkout(ctx, query, workoutID, userID)
+}
+
+// ListWorkoutsWithOriginal возвращает тренировки с сохранённым исходным файлом, упорядоченные по id.
+// Нулевой userID - тренировки всех пользователей; after - id последней тренировки предыдущей страницы
+func (s *sqliteDB) ListWorkoutsWithOriginal(ctx context.Context, userID, after uuid.UUID, limit int) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE original_key IS NOT NULL AND deleted_at IS NULL
+			AND (?1 IS NULL OR user_id = ?1)
+			AND id > ?2
+		ORDER BY id
+		LIMIT ?3;`
+
+	return s.queryWorkouts(ctx, query, nullIfZero(userID), after, limit)
+}
+
+// UpdateWorkoutData обновляет рассчитанные из файла поля тренировки и увеличивает её версию,
+// если сохранённая версия совпадает с w.Version, иначе возвращает storage.ErrVersionConflict.
+// Новая версия записывается в w.Version. Если переданы записи или круги, они полностью заменяют сохранённые.
+// Время слияния только устанавливается: пустое w.MergedAt не сбрасывает сохранённое
+func (s *sqliteDB) UpdateWorkoutData(ctx context.Context, w *entity.Workout) error {
+	query := `
+		UPDATE workouts
+		SET sport_type = ?, duration = ?, distance = ?, avg_pace = ?,
+			avg_heart_rate = ?, max_heart_rate = ?, avg_cadence = ?, calories = ?,
+			start_time = ?, file_hash = ?, merged_at = COALESCE(?, merged_at),
+			updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?
+		RETURNING version`
+
+	distance, err := distanceValue(w.Distance)
+	if err != nil {
+		return err
+	}
+
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.conn(ctx).QueryRowContext(ctx, query,
+			w.SportType, int64(w.Duration), distance, w.AvgPace,
+			w.AvgHeartRate, w.MaxHeartRate, w.AvgCadence, w.Calories,
+			nanos(w.StartTime), nullIfZero(w.FileHash), nanos(w.MergedAt), time.Now().UnixNano(),
+			w.ID, w.Version,
+		).Scan(&w.Version)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			// Строки нет совсем или её успели изменить после чтения
+			var exists bool
+			if err := s.conn(ctx).QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM workouts WHERE id = ?)`, w.ID,
+			).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return storage.ErrVersionConflict
+			}
+			return storage.ErrWorkoutNotFound
+		}
+
+		if w.RecordData != nil {
+			if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM track_points WHERE workout_id = ?`, w.ID); err != nil {
+				return err
+			}
+			if err := s.insertTrackPoints(ctx, w.ID, w.RecordData); err != nil {
+				return err
+			}
+		}
+
+		if w.Laps != nil {
+			if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM workout_laps WHERE workout_id = ?`, w.ID); err != nil {
+				return err
+			}
+			if err := s.insertLaps(ctx, w.ID, w.Laps); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetWorkoutByID возвращает тренировку по id без проверки владельца. Удалённые в корзину не возвращаются
+func (s *sqliteDB) GetWorkoutByID(ctx context.Context, id uuid.UUID) (*entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE id = ? AND deleted_at IS NULL;`
+
+	return s.queryWorkout(ctx, query, id)
+}
+
+// UpdateWorkout меняет переданные поля тренировки, если её версия всё ещё равна u.Version,
+// и возвращает тренировку с новой версией
+func (s *sqliteDB) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) (*entity.Workout, error) {
+	var (
+		setClauses []string
+		args       []any
+	)
+	add := func(col string, val any) {
+		setClauses = append(setClauses, col+" = ?")
+		args = append(args, val)
+	}
+
+	if u.Name != nil {
+		add("name", *u.Name)
+	}
+	if u.SportType != nil {
+		add("sport_type", *u.SportType)
+	}
+	if u.Duration != nil {
+		add("duration", int64(time.Duration(*u.Duration)*time.Second))
+	}
+	if u.Distance != nil {
+		add("distance", math.Round(float64(*u.Distance)*100)/100)
+	}
+	if u.AvgPace != nil {
+		// Темп хранится целым числом секунд на километр
+		add("avg_pace", int(math.Round(float64(*u.AvgPace))))
+	}
+	if u.AvgHeartRate != nil {
+		add("avg_heart_rate", *u.AvgHeartRate)
+	}
+	if u.MaxHeartRate != nil {
+		add("max_heart_rate", *u.MaxHeartRate)
+	}
+	if u.AvgCadence != nil {
+		add("avg_cadence", *u.AvgCadence)
+	}
+	if u.Calories != nil {
+		add("calories", *u.Calories)
+	}
+	if u.Description != nil {
+		add("description", *u.Description)
+	}
+	if u.DeviceName != nil {
+		add("device_name", *u.DeviceName)
+	}
+
+	now := time.Now().UnixNano()
+	if u.EditsFileData() {
+		// Отмечаем ручную правку, чтобы пересборка из файла её не затёрла
+		add("edited_at", now)
+	}
+	add("updated_at", now)
+	setClauses = append(setClauses, "version = version + 1")
+	query := `
+		UPDATE workouts
+		SET ` + strings.Join(setClauses, ", ") + `
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+		RETURNING ` + workoutColumns
+	args = append(args, u.ID, u.Version)
+
+	w, err := s.queryWorkout(ctx, query, args...)
+	if errors.Is(err, storage.ErrWorkoutNotFound) {
+		// Тренировка была изменена (или удалена) после того, как клиент её прочитал
+		return nil, storage.ErrVersionConflict
+	}
+	return w, err
+}
+
+// nanos переводит время в наносекунды Unix; нулевое время хранится как NULL
+func nanos(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	return time.Unix(0, n).UTC()
+}
+
+// distanceValue переводит дистанцию в километрах из строки в число; пустая - NULL
+func distanceValue(d string) (any, error) {
+	if d == "" {
+		return nil, nil
+	}
+	km, err := strconv.ParseFloat(d, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distance %q: %w", d, err)
+	}
+	return math.Round(km*100) / 100, nil
+}
+
+// nullIfZero превращает нулевое ("нет данных") значение в NULL
+func nullIfZero[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func (s *sqliteDB) CreateUser(ctx context.Context, email string, passHash []byte) (string, error) {
+	const op = "storage.sqlite.SaveUser"
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UnixNano()
+	_, err = s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO users (id, email, password_hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		id.String(), email, string(passHash), now, now)
+	if err != nil {
+		var sqliteErr *sqlite.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrUserExists)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id.String(), nil
+}
+
+func (s *sqliteDB) GetUser(ctx context.Context, email string) (*entity.User, error) {
+	const op = "storage.sqlite.User"
+
+	var (
+		u           entity.User
+		lockedUntil sql.NullInt64
+	)
+	err := s.conn(ctx).
+		QueryRowContext(ctx, `SELECT id, email, password_hash, email_verified_at IS NOT NULL, failed_logins, locked_until FROM users WHERE email = ?`, email).
+		Scan(&u.ID, &u.Email, &u.Password, &u.EmailVerified, &u.FailedLogins, &lockedUntil)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockedUntil.Valid {
+		u.LockedUntil = fromNanos(lockedUntil.Int64)
+	}
+
+	return &u, nil
+}
+
+func (s *sqliteDB) GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error) {
+	const op = "storage.sqlite.UserByID"
+
+	var (
+		u           entity.User
+		lockedUntil sql.NullInt64
+	)
+	err := s.conn(ctx).
+		QueryRowContext(ctx, `SELECT id, email, password_hash, email_verified_at IS NOT NULL, failed_logins, locked_until FROM users WHERE id = ?`, userID).
+		Scan(&u.ID, &u.Email, &u.Password, &u.EmailVerified, &u.FailedLogins, &lockedUntil)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lockedUntil.Valid {
+		u.LockedUntil = fromNanos(lockedUntil.Int64)
+	}
+
+	return &u, nil
+}
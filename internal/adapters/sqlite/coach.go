@@ -0,0 +1,25 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// coachStatusAccepted - связь подтверждена спортсменом
+const coachStatusAccepted = "accepted"
+
+// IsCoachOf проверяет, что пользователь coachID - подтверждённый тренер спортсмена athleteID
+func (s *sqliteDB) IsCoachOf(ctx context.Context, coachID, athleteID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM coach_athletes
+			WHERE coach_id = ? AND athlete_id = ? AND status = ?
+		);`
+
+	var ok bool
+	if err := s.conn(ctx).QueryRowContext(ctx, query, coachID, athleteID, coachStatusAccepted).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
@@ -0,0 +1,18 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"workout/internal/adapters/storagetest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		repo, err := NewSQLiteAdapter(filepath.Join(t.TempDir(), "athletichub.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { repo.Close() })
+		return repo
+	})
+}
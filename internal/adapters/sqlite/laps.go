@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+const insertLapSQL = `
+	INSERT INTO workout_laps (
+		workout_id, lap_number, start_time, end_time, elapsed_time, timer_time,
+		distance, avg_speed, max_speed, avg_heart_rate, max_heart_rate,
+		avg_cadence, max_cadence, calories, lap_trigger
+	) VALUES (
+		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	)`
+
+// insertLaps сохраняет круги тренировки в порядке их следования в файле
+func (s *sqliteDB) insertLaps(ctx context.Context, workoutID uuid.UUID, laps []entity.LapData) error {
+	if len(laps) == 0 {
+		return nil
+	}
+
+	stmt, err := s.conn(ctx).PrepareContext(ctx, insertLapSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, l := range laps {
+		if _, err := stmt.ExecContext(ctx,
+			workoutID, i+1, l.StartTime.UnixNano(), l.Timestamp.UnixNano(), l.TotalElapsedTime, l.TotalTimerTime,
+			float64(l.TotalDistance)/100.0, float64(l.AvgSpeed)/1000.0, float64(l.MaxSpeed)/1000.0,
+			nullIfZero(l.AvgHeartRate), nullIfZero(l.MaxHeartRate),
+			nullIfZero(l.AvgCadence), nullIfZero(l.MaxCadence), nullIfZero(l.TotalCalories),
+			l.LapTrigger.String(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLaps возвращает круги тренировки, если она принадлежит пользователю
+func (s *sqliteDB) GetLaps(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.LapData, error) {
+	query := `
+		SELECT l.start_time, l.end_time, l.elapsed_time, l.timer_time,
+			COALESCE(l.distance, 0), COALESCE(l.avg_speed, 0), COALESCE(l.max_speed, 0),
+			COALESCE(l.avg_heart_rate, 0), COALESCE(l.max_heart_rate, 0),
+			COALESCE(l.avg_cadence, 0), COALESCE(l.max_cadence, 0),
+			COALESCE(l.calories, 0), COALESCE(l.lap_trigger, '')
+		FROM workout_laps l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE l.workout_id = ? AND w.user_id = ? AND w.deleted_at IS NULL
+		ORDER BY l.lap_number;`
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, workoutID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	laps := []entity.LapData{}
+	for rows.Next() {
+		var (
+			l                            entity.LapData
+			startTime, endTime           int64
+			distance, avgSpeed, maxSpeed float64
+			trigger                      string
+		)
+		if err := rows.Scan(
+			&startTime, &endTime, &l.TotalElapsedTime, &l.TotalTimerTime,
+			&distance, &avgSpeed, &maxSpeed,
+			&l.AvgHeartRate, &l.MaxHeartRate, &l.AvgCadence, &l.MaxCadence,
+			&l.TotalCalories, &trigger,
+		); err != nil {
+			return nil, err
+		}
+		l.StartTime = fromNanos(startTime)
+		l.Timestamp = fromNanos(endTime)
+		l.TotalDistance = uint32(distance*100 + 0.5)
+		l.AvgSpeed = uint16(avgSpeed*1000 + 0.5)
+		l.MaxSpeed = uint16(maxSpeed*1000 + 0.5)
+		l.LapTrigger = typedef.LapTriggerFromString(trigger)
+		laps = append(laps, l)
+	}
+	return laps, rows.Err()
+}
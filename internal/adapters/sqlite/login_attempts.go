@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// AddFailedLogin увеличивает счётчик неудачных попыток входа подряд и возвращает новое значение
+func (s *sqliteDB) AddFailedLogin(ctx context.Context, userID uuid.UUID) (int, error) {
+	const op = "storage.sqlite.AddFailedLogin"
+
+	var failures int
+	err := s.conn(ctx).QueryRowContext(ctx, `
+		UPDATE users
+		SET failed_logins = failed_logins + 1
+		WHERE id = ?
+		RETURNING failed_logins`, userID).Scan(&failures)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return failures, nil
+}
+
+// LockUser запрещает вход до времени until
+func (s *sqliteDB) LockUser(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	const op = "storage.sqlite.LockUser"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE users SET locked_until = ? WHERE id = ?`, until.UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return affectedUser(op, res)
+}
+
+// ResetFailedLogins обнуляет счётчик неудачных попыток и снимает блокировку
+func (s *sqliteDB) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	const op = "storage.sqlite.ResetFailedLogins"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE users SET failed_logins = 0, locked_until = NULL WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return affectedUser(op, res)
+}
+
+// AddLoginAttempt записывает неудачную попытку входа в журнал
+func (s *sqliteDB) AddLoginAttempt(ctx context.Context, a *entity.LoginAttempt) error {
+	const op = "storage.sqlite.AddLoginAttempt"
+
+	var userID any
+	if a.UserID != uuid.Nil {
+		userID = a.UserID
+	}
+	res, err := s.conn(ctx).ExecContext(ctx, `
+		INSERT INTO login_attempts (email, user_id, ip, user_agent, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.Email, userID, a.IP, a.UserAgent, string(a.Reason), a.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if a.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// CountLoginAttempts возвращает число попыток входа с адресом email и причиной reason
+// и время последней из них
+func (s *sqliteDB) CountLoginAttempts(ctx context.Context, email string, reason entity.LoginFailure) (int, time.Time, error) {
+	const op = "storage.sqlite.CountLoginAttempts"
+
+	var (
+		count int
+		last  sql.NullInt64
+	)
+	err := s.conn(ctx).QueryRowContext(ctx, `
+		SELECT COUNT(*), MAX(created_at)
+		FROM login_attempts
+		WHERE email = ? AND reason = ?`, email, string(reason)).Scan(&count, &last)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !last.Valid {
+		return count, time.Time{}, nil
+	}
+	return count, fromNanos(last.Int64), nil
+}
+
+// ListLoginAttempts возвращает последние limit неудачных попыток входа пользователя, новые первыми
+func (s *sqliteDB) ListLoginAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]entity.LoginAttempt, error) {
+	const op = "storage.sqlite.ListLoginAttempts"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+		SELECT id, email, user_id, ip, user_agent, reason, created_at
+		FROM login_attempts
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attempts := []entity.LoginAttempt{}
+	for rows.Next() {
+		var (
+			a         entity.LoginAttempt
+			reason    string
+			createdAt int64
+		)
+		if err := rows.Scan(&a.ID, &a.Email, &a.UserID, &a.IP, &a.UserAgent, &reason, &createdAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		a.Reason = entity.LoginFailure(reason)
+		a.CreatedAt = fromNanos(createdAt)
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return attempts, nil
+}
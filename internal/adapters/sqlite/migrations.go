@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+const createSchemaMigrationsSQL = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`
+
+// migrate применяет все ещё не применённые миграции. База принадлежит одному процессу,
+// поэтому миграции выполняются при каждом запуске, без отдельной команды
+func migrate(ctx context.Context, db *sql.DB) error {
+	migrations, err := storage.LoadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, createSchemaMigrationsSQL); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration выполняет миграцию и запись в schema_migrations в одной транзакции
+func applyMigration(ctx context.Context, db *sql.DB, m storage.Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)`, m.Version).Scan(&applied)
+	if err != nil || applied {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
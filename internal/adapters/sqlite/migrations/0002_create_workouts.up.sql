@@ -0,0 +1,39 @@
+-- Тренировки
+CREATE TABLE workouts (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    sport_type TEXT NOT NULL,
+    date INTEGER NOT NULL,
+    duration INTEGER NOT NULL DEFAULT 0,  -- time.Duration (наносекунды)
+    distance REAL,                        -- километры
+    avg_pace INTEGER,                     -- секунды на километр
+    avg_heart_rate INTEGER,
+    max_heart_rate INTEGER,
+    avg_cadence INTEGER,
+    calories INTEGER,
+    description TEXT NOT NULL DEFAULT '',
+    device_name TEXT,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+
+    -- Время старта и хэш исходного файла для поиска дубликатов при импорте
+    start_time INTEGER,
+    file_hash TEXT,
+
+    -- Исходный файл в хранилище
+    original_key TEXT,
+    original_name TEXT,
+
+    -- Мягкое удаление: тренировка лежит в корзине до окончательной очистки
+    deleted_at INTEGER,
+
+    -- Версия для оптимистичной блокировки при редактировании
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_workouts_user_date ON workouts(user_id, date DESC, id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX idx_workouts_start_time ON workouts(user_id, start_time);
+CREATE INDEX idx_workouts_file_hash ON workouts(user_id, file_hash);
+CREATE INDEX idx_workouts_sport_type ON workouts(user_id, sport_type);
+CREATE INDEX idx_workouts_deleted_at ON workouts(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,38 @@
+-- Посекундные точки трека (FIT Record)
+CREATE TABLE track_points (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workout_id TEXT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    timestamp INTEGER NOT NULL,
+    latitude REAL,
+    longitude REAL,
+    elevation REAL,
+    heart_rate INTEGER,
+    speed REAL,
+    power INTEGER,
+    cadence INTEGER,
+    temperature INTEGER,
+    distance REAL
+);
+
+CREATE INDEX idx_track_points_workout_id ON track_points(workout_id);
+
+-- Круги (FIT Lap)
+CREATE TABLE workout_laps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workout_id TEXT NOT NULL REFERENCES workouts(id) ON DELETE CASCADE,
+    lap_number INTEGER NOT NULL,
+    start_time INTEGER NOT NULL,
+    end_time INTEGER NOT NULL,
+    elapsed_time INTEGER NOT NULL,
+    timer_time INTEGER NOT NULL,
+    distance REAL,
+    avg_speed REAL,
+    max_speed REAL,
+    avg_heart_rate INTEGER,
+    max_heart_rate INTEGER,
+    avg_cadence INTEGER,
+    max_cadence INTEGER,
+    calories INTEGER,
+    lap_trigger TEXT,
+    UNIQUE (workout_id, lap_number)
+);
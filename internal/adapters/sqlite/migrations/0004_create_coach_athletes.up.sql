@@ -0,0 +1,10 @@
+-- Связи тренер - спортсмен. Тренер видит тренировки спортсмена после подтверждения связи
+CREATE TABLE coach_athletes (
+    coach_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    athlete_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (coach_id, athlete_id)
+);
+
+CREATE INDEX idx_coach_athletes_athlete_id ON coach_athletes(athlete_id);
@@ -0,0 +1,24 @@
+-- Сессии устройств. Сессия живёт, пока клиент обновляет refresh-токен до expires_at
+CREATE TABLE sessions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    last_used_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    revoked_at INTEGER
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+
+-- Refresh-токены хранятся только как sha256. Обменянный токен остаётся с used_at:
+-- его повторное предъявление означает утечку, и сессия отзывается целиком
+CREATE TABLE refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    created_at INTEGER NOT NULL,
+    used_at INTEGER
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0;
+UPDATE users SET is_admin = 1 WHERE id IN (SELECT user_id FROM user_roles WHERE role = 'admin');
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,11 @@
+-- Роли пользователей вместо флага is_admin. Права ролей задаются в коде (entity.Permission)
+CREATE TABLE user_roles (
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO user_roles (user_id, role) SELECT id, 'athlete' FROM users;
+INSERT INTO user_roles (user_id, role) SELECT id, 'admin' FROM users WHERE is_admin = 1;
+
+ALTER TABLE users DROP COLUMN is_admin;
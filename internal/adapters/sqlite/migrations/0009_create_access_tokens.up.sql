@@ -0,0 +1,16 @@
+-- Персональные токены доступа для скриптов и устройств синхронизации. Хранится только sha256.
+-- Области перечислены через пробел
+CREATE TABLE access_tokens (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    prefix TEXT NOT NULL,
+    scopes TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    last_used_at INTEGER,
+    expires_at INTEGER,
+    revoked_at INTEGER
+);
+
+CREATE INDEX idx_access_tokens_user_id ON access_tokens(user_id);
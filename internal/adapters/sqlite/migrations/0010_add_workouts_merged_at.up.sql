@@ -0,0 +1,3 @@
+-- Время слияния с той же тренировкой с другого устройства. Такую тренировку
+-- нельзя пересобрать из одного исходного файла
+ALTER TABLE workouts ADD COLUMN merged_at INTEGER;
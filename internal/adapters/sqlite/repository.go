@@ -0,0 +1,69 @@
+// Package sqlite - хранилище в одном файле SQLite для установки без отдельного сервера базы данных.
+// Драйвер написан на чистом Go, поэтому сервис остаётся одним исполняемым файлом
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+// lowerFunc - имя функции перевода в нижний регистр с поддержкой Unicode.
+// Встроенные lower() и LIKE в SQLite учитывают регистр только латиницы
+const lowerFunc = "unicode_lower"
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(lowerFunc, 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return strings.ToLower(s), nil
+	})
+}
+
+type sqliteDB struct {
+	db *sql.DB
+}
+
+// NewSQLiteAdapter открывает (или создаёт) базу в файле path и применяет миграции
+func NewSQLiteAdapter(path string) (*sqliteDB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога базы данных: %w", err)
+	}
+
+	// Внешние ключи по умолчанию выключены; WAL позволяет читать во время записи
+	dsn := "file:" + path + "?" + url.Values{"_pragma": {
+		"foreign_keys(1)",
+		"journal_mode(WAL)",
+		"busy_timeout(5000)",
+	}}.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия базы данных: %w", err)
+	}
+
+	// SQLite допускает только одного писателя. Одно соединение исключает ошибки
+	// "database is locked" при параллельных транзакциях
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка выполнения миграций: %w", err)
+	}
+
+	return &sqliteDB{db: db}, nil
+}
+
+// Close закрывает соединение с базой данных
+func (s *sqliteDB) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,118 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// GetUserRoles возвращает роли пользователя, упорядоченные по имени
+func (s *sqliteDB) GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	const op = "storage.sqlite.UserRoles"
+
+	if err := s.userExists(ctx, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT role FROM user_roles WHERE user_id = ? ORDER BY role`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	roles := []entity.Role{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		roles = append(roles, entity.Role(role))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// SetUserRoles заменяет роли пользователя
+func (s *sqliteDB) SetUserRoles(ctx context.Context, userID uuid.UUID, roles []entity.Role) error {
+	const op = "storage.sqlite.SetUserRoles"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userExists(ctx, userID); err != nil {
+			return err
+		}
+		if _, err := s.conn(ctx).ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = ?`, userID); err != nil {
+			return err
+		}
+		for _, r := range roles {
+			if _, err := s.conn(ctx).ExecContext(ctx,
+				`INSERT INTO user_roles (user_id, role) VALUES (?, ?) ON CONFLICT DO NOTHING`, userID, string(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListUsers возвращает всех пользователей с ролями, упорядоченных по email
+func (s *sqliteDB) ListUsers(ctx context.Context) ([]entity.User, error) {
+	const op = "storage.sqlite.ListUsers"
+
+	// group_concat не гарантирует порядок, поэтому роли сортируются в подзапросе
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+		SELECT u.id, u.email, u.name,
+			COALESCE((SELECT group_concat(role, ',') FROM (
+				SELECT role FROM user_roles WHERE user_id = u.id ORDER BY role
+			)), '')
+		FROM users u
+		ORDER BY u.email`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		var (
+			u     entity.User
+			roles string
+		)
+		if err := rows.Scan(&u.ID, &u.Email, &u.Name, &roles); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		u.Roles = []entity.Role{}
+		if roles != "" {
+			for _, r := range strings.Split(roles, ",") {
+				u.Roles = append(u.Roles, entity.Role(r))
+			}
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, nil
+}
+
+// userExists возвращает storage.ErrUserNotFound, если пользователя нет
+func (s *sqliteDB) userExists(ctx context.Context, userID uuid.UUID) error {
+	var exists bool
+	if err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)`, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
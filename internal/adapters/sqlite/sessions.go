@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+func scanSession(row scanner) (*entity.Session, error) {
+	var (
+		s                                entity.Session
+		createdAt, lastUsedAt, expiresAt int64
+		revokedAt                        sql.NullInt64
+	)
+	if err := row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &createdAt, &lastUsedAt, &expiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	s.CreatedAt = fromNanos(createdAt)
+	s.LastUsedAt = fromNanos(lastUsedAt)
+	s.ExpiresAt = fromNanos(expiresAt)
+	if revokedAt.Valid {
+		s.RevokedAt = fromNanos(revokedAt.Int64)
+	}
+	return &s, nil
+}
+
+// CreateSession сохраняет новую сессию вместе с её первым refresh-токеном
+func (s *sqliteDB) CreateSession(ctx context.Context, session *entity.Session, tokenHash string) error {
+	const op = "storage.sqlite.CreateSession"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.conn(ctx).ExecContext(ctx, `
+			INSERT INTO sessions (id, user_id, user_agent, ip, created_at, last_used_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			session.ID, session.UserID, session.UserAgent, session.IP,
+			session.CreatedAt.UnixNano(), session.LastUsedAt.UnixNano(), session.ExpiresAt.UnixNano(),
+		); err != nil {
+			return err
+		}
+
+		_, err := s.conn(ctx).ExecContext(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES (?, ?, ?)`,
+			tokenHash, session.ID, session.CreatedAt.UnixNano())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetSession возвращает сессию по id, в том числе отозванную или истёкшую
+func (s *sqliteDB) GetSession(ctx context.Context, sessionID uuid.UUID) (*entity.Session, error) {
+	const op = "storage.sqlite.Session"
+
+	session, err := scanSession(s.conn(ctx).QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE id = ?`, sessionID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return session, nil
+}
+
+// ListSessions возвращает активные на момент now сессии пользователя, недавно использованные первыми
+func (s *sqliteDB) ListSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error) {
+	const op = "storage.sqlite.ListSessions"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC, id`, userID, now.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession отзывает активную сессию пользователя. Чужая или уже отозванная сессия не находится
+func (s *sqliteDB) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.RevokeSession"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, now.UnixNano(), sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	return nil
+}
+
+// GetRefreshToken ищет refresh-токен по хэшу, в том числе уже использованный
+func (s *sqliteDB) GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	const op = "storage.sqlite.RefreshToken"
+
+	var (
+		t         entity.RefreshToken
+		createdAt int64
+		usedAt    sql.NullInt64
+	)
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT token_hash, session_id, created_at, used_at FROM refresh_tokens WHERE token_hash = ?`, tokenHash).
+		Scan(&t.Hash, &t.SessionID, &createdAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	t.CreatedAt = fromNanos(createdAt)
+	if usedAt.Valid {
+		t.UsedAt = fromNanos(usedAt.Int64)
+	}
+	return &t, nil
+}
+
+// UseRefreshToken помечает токен использованным. Если его уже использовал параллельный
+// запрос, возвращает storage.ErrRefreshTokenUsed
+func (s *sqliteDB) UseRefreshToken(ctx context.Context, tokenHash string, now time.Time) error {
+	const op = "storage.sqlite.UseRefreshToken"
+
+	res, err := s.conn(ctx).ExecContext(ctx,
+		`UPDATE refresh_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL`, now.UnixNano(), tokenHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+	return nil
+}
+
+// AddRefreshToken выдаёт сессии новый refresh-токен и продлевает её до expiresAt
+func (s *sqliteDB) AddRefreshToken(ctx context.Context, sessionID uuid.UUID, tokenHash string, now, expiresAt time.Time) error {
+	const op = "storage.sqlite.AddRefreshToken"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		res, err := s.conn(ctx).ExecContext(ctx, `
+			UPDATE sessions
+			SET last_used_at = ?, expires_at = ?
+			WHERE id = ? AND revoked_at IS NULL`, now.UnixNano(), expiresAt.UnixNano(), sessionID)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return storage.ErrSessionNotFound
+		}
+
+		_, err = s.conn(ctx).ExecContext(ctx,
+			`INSERT INTO refresh_tokens (token_hash, session_id, created_at) VALUES (?, ?, ?)`,
+			tokenHash, sessionID, now.UnixNano())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RevokeUserSessions отзывает все активные сессии пользователя, например после смены пароля
+func (s *sqliteDB) RevokeUserSessions(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.RevokeUserSessions"
+
+	_, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE sessions
+		SET revoked_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL`, now.UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"workout/internal/entity"
+	"workout/internal/utils"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const insertTrackPointSQL = `
+	INSERT INTO track_points (
+		workout_id, timestamp, latitude, longitude, elevation,
+		heart_rate, speed, power, cadence, temperature, distance
+	) VALUES (
+		?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	)`
+
+// insertTrackPoints сохраняет посекундные данные тренировки подготовленным запросом.
+// Вызывается внутри транзакции, поэтому тысячи вставок фиксируются одной записью на диск.
+// Значения хранятся в привычных единицах: градусы, метры, м/с
+func (s *sqliteDB) insertTrackPoints(ctx context.Context, workoutID uuid.UUID, records []entity.RecordData) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	stmt, err := s.conn(ctx).PrepareContext(ctx, insertTrackPointSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		var lat, lon any
+		if r.PositionLat != 0 || r.PositionLon != 0 {
+			lat, lon = utils.SemicirclesToDegrees(r.PositionLat), utils.SemicirclesToDegrees(r.PositionLon)
+		}
+
+		var elevation any
+		if r.Altitude != 0 {
+			elevation = utils.AltitudeToMeters(r.Altitude)
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			workoutID, r.Timestamp.UnixNano(), lat, lon, elevation,
+			nullIfZero(r.HeartRate), nullIfZero(float64(r.Speed)/1000.0), nullIfZero(r.Power),
+			nullIfZero(r.Cadence), r.Temperature, nullIfZero(float64(r.Distance)/100.0),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTrackPoints возвращает посекундные данные тренировки, если она принадлежит пользователю
+func (s *sqliteDB) GetTrackPoints(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.RecordData, error) {
+	query := `
+		SELECT t.timestamp, t.latitude, t.longitude, t.elevation,
+			COALESCE(t.heart_rate, 0), COALESCE(t.speed, 0), COALESCE(t.power, 0),
+			COALESCE(t.cadence, 0), COALESCE(t.temperature, 0), COALESCE(t.distance, 0)
+		FROM track_points t
+		JOIN workouts w ON w.id = t.workout_id
+		WHERE t.workout_id = ? AND w.user_id = ? AND w.deleted_at IS NULL
+		ORDER BY t.id;`
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, workoutID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []entity.RecordData{}
+	for rows.Next() {
+		var (
+			r                   entity.RecordData
+			timestamp           int64
+			lat, lon, elevation sql.NullFloat64
+			speed, distance     float64
+		)
+		if err := rows.Scan(
+			&timestamp, &lat, &lon, &elevation,
+			&r.HeartRate, &speed, &r.Power, &r.Cadence, &r.Temperature, &distance,
+		); err != nil {
+			return nil, err
+		}
+		r.Timestamp = fromNanos(timestamp)
+		if lat.Valid && lon.Valid {
+			r.PositionLat = utils.DegreesToSemicircles(lat.Float64)
+			r.PositionLon = utils.DegreesToSemicircles(lon.Float64)
+		}
+		if elevation.Valid {
+			r.Altitude = utils.MetersToAltitude(elevation.Float64)
+		}
+		r.Speed = uint16(speed*1000 + 0.5)
+		r.Distance = uint32(distance*100 + 0.5)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// SoftDeleteWorkout переносит тренировку пользователя в корзину.
+// Трек, круги и исходный файл остаются до окончательной очистки
+func (s *sqliteDB) SoftDeleteWorkout(ctx context.Context, userID, workoutID uuid.UUID) error {
+	query := `
+		UPDATE workouts
+		SET deleted_at = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	res, err := s.conn(ctx).ExecContext(ctx, query, time.Now().UnixNano(), workoutID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrWorkoutNotFound
+	}
+	return nil
+}
+
+// GetDeletedWorkouts возвращает тренировки пользователя в корзине, удалённые после deletedAfter
+func (s *sqliteDB) GetDeletedWorkouts(ctx context.Context, userID uuid.UUID, deletedAfter time.Time) ([]entity.Workout, error) {
+	query := `SELECT ` + workoutColumns + `
+		FROM workouts
+		WHERE user_id = ? AND deleted_at > ?
+		ORDER BY deleted_at DESC;`
+
+	return s.queryWorkouts(ctx, query, userID, unixNanoOrMin(deletedAfter))
+}
+
+// RestoreWorkout возвращает тренировку из корзины, если она удалена после deletedAfter
+func (s *sqliteDB) RestoreWorkout(ctx context.Context, userID, workoutID uuid.UUID, deletedAfter time.Time) (*entity.Workout, error) {
+	query := `
+		UPDATE workouts
+		SET deleted_at = NULL
+		WHERE id = ? AND user_id = ? AND deleted_at > ?
+		RETURNING ` + workoutColumns
+
+	return s.queryWorkout(ctx, query, workoutID, userID, unixNanoOrMin(deletedAfter))
+}
+
+// PurgeDeletedWorkouts окончательно удаляет тренировки, лежащие в корзине с момента до before,
+// вместе с треком и кругами. Возвращает число удалённых тренировок и ключи исходных файлов,
+// на которые больше не ссылается ни одна тренировка
+func (s *sqliteDB) PurgeDeletedWorkouts(ctx context.Context, before time.Time) (int, []string, error) {
+	var (
+		purged   int
+		orphaned []string
+	)
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		rows, err := s.conn(ctx).QueryContext(ctx, `
+			DELETE FROM workouts
+			WHERE deleted_at < ?
+			RETURNING COALESCE(original_key, '')`, unixNanoOrMin(before))
+		if err != nil {
+			return err
+		}
+
+		keys := make(map[string]struct{})
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return err
+			}
+			purged++
+			if key != "" {
+				keys[key] = struct{}{}
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Тот же файл мог быть загружен снова после удаления - такой исходник оставляем
+		for key := range keys {
+			var used bool
+			err := s.conn(ctx).QueryRowContext(ctx,
+				`SELECT EXISTS (SELECT 1 FROM workouts WHERE original_key = ?)`, key).Scan(&used)
+			if err != nil {
+				return err
+			}
+			if !used {
+				orphaned = append(orphaned, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return purged, orphaned, nil
+}
+
+// unixNanoOrMin переводит время в наносекунды Unix для сравнения с колонкой.
+// Нулевое время (год 1) не помещается в int64 наносекунд, поэтому это минимальное значение
+func unixNanoOrMin(t time.Time) int64 {
+	if t.IsZero() {
+		return math.MinInt64
+	}
+	return t.UnixNano()
+}
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// CreateUserToken сохраняет одноразовый токен из письма
+func (s *sqliteDB) CreateUserToken(ctx context.Context, t *entity.UserToken) error {
+	const op = "storage.sqlite.CreateUserToken"
+
+	_, err := s.conn(ctx).ExecContext(ctx, `
+		INSERT INTO user_tokens (token_hash, user_id, purpose, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		t.Hash, t.UserID, string(t.Purpose), t.CreatedAt.UnixNano(), t.ExpiresAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UseUserToken погашает действующий токен и возвращает его владельца. Остальные неиспользованные
+// токены того же назначения тоже гасятся: действует только одна, последняя использованная ссылка
+func (s *sqliteDB) UseUserToken(ctx context.Context, tokenHash string, purpose entity.TokenPurpose, now time.Time) (uuid.UUID, error) {
+	const op = "storage.sqlite.UseUserToken"
+
+	var userID uuid.UUID
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.conn(ctx).QueryRowContext(ctx, `
+			UPDATE user_tokens
+			SET used_at = ?1
+			WHERE token_hash = ?2 AND purpose = ?3 AND used_at IS NULL AND expires_at > ?1
+			RETURNING user_id`, now.UnixNano(), tokenHash, string(purpose)).Scan(&userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrTokenNotFound
+			}
+			return err
+		}
+
+		_, err = s.conn(ctx).ExecContext(ctx, `
+			UPDATE user_tokens
+			SET used_at = ?
+			WHERE user_id = ? AND purpose = ? AND used_at IS NULL`, now.UnixNano(), userID, string(purpose))
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return userID, nil
+}
+
+// SetEmailVerified отмечает адрес почты пользователя подтверждённым
+func (s *sqliteDB) SetEmailVerified(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	const op = "storage.sqlite.SetEmailVerified"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, ?1), updated_at = ?1
+		WHERE id = ?2`, now.UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return affectedUser(op, res)
+}
+
+// UpdatePassword заменяет хэш пароля пользователя
+func (s *sqliteDB) UpdatePassword(ctx context.Context, userID uuid.UUID, passHash []byte) error {
+	const op = "storage.sqlite.UpdatePassword"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = ?, updated_at = ?
+		WHERE id = ?`, string(passHash), time.Now().UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return affectedUser(op, res)
+}
+
+// affectedUser возвращает storage.ErrUserNotFound, если запрос не изменил ни одного пользователя
+func affectedUser(op string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_index.up.sql":      {Data: []byte("CREATE INDEX x ON t (a);")},
+		"m/0002_add_index.down.sql":    {Data: []byte("DROP INDEX x;")},
+		"m/0001_create_t.up.sql":       {Data: []byte("CREATE TABLE t (a INT);")},
+		"m/0001_create_t.down.sql":     {Data: []byte("DROP TABLE t;")},
+		"m/0003_no_down_script.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := LoadMigrations(fsys, "m")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	for i, name := range []string{"create_t", "add_index", "no_down_script"} {
+		assert.Equal(t, int64(i+1), migrations[i].Version)
+		assert.Equal(t, name, migrations[i].Name)
+	}
+	assert.Equal(t, "DROP TABLE t;", migrations[0].Down)
+	assert.Empty(t, migrations[2].Down)
+
+	// Неверное имя файла, миграция без up и две миграции с одной версией
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":   {"m/create_t.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up": {"m/0001_create_t.down.sql": {Data: []byte("DROP TABLE t;")}},
+		"name clash": {
+			"m/0001_create_t.up.sql": {Data: []byte("SELECT 1;")},
+			"m/0001_create_u.up.sql": {Data: []byte("SELECT 1;")},
+		},
+	} {
+		_, err := LoadMigrations(fsys, "m")
+		assert.ErrorIs(t, err, ErrInvalidMigration, name)
+	}
+}
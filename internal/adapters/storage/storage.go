@@ -0,0 +1,16 @@
+// Package storage содержит ошибки, общие для всех реализаций репозиториев.
+// Сервисы проверяют их через errors.Is и не зависят от конкретного хранилища
+package storage
+
+import "errors"
+
+var (
+	ErrWorkoutNotFound = errors.New("workout not found")
+	ErrVersionConflict = errors.New("workout was modified by another request")
+	ErrUserExists      = errors.New("user already exists")
+	ErrUserNotFound    = errors.New("user not found")
+
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrRefreshTokenUsed = errors.New("refresh token already used")
+	ErrTokenNotFound    = errors.New("token not found, used or expired")
+)
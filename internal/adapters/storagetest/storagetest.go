@@ -0,0 +1,890 @@
+// Package storagetest - общий набор проверок для реализаций activity.Activity и auth.Auth.
+// Каждое хранилище подключает его в своих тестах, чтобы все адаптеры вели себя одинаково:
+//
+//	storagetest.Run(t, func(t *testing.T) storagetest.Repository { return memory.NewMemoryAdapter() })
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+	"workout/internal/service/activity"
+	"workout/internal/service/auth"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Repository - хранилище тренировок и пользователей, которое проверяет набор
+type Repository interface {
+	activity.Activity
+	auth.Auth
+}
+
+// Run запускает все проверки. newRepo вызывается для каждой проверки; хранилище может быть
+// общим (например, одна тестовая база) - проверки работают только со своими пользователями
+func Run(t *testing.T, newRepo func(t *testing.T) Repository) {
+	tests := map[string]func(t *testing.T, repo Repository){
+		"Users":              testUsers,
+		"CreateAndGet":       testCreateAndGet,
+		"NotFound":           testNotFound,
+		"TrackPointsAndLaps": testTrackPointsAndLaps,
+		"ListOrdering":       testListOrdering,
+		"ListFilters":        testListFilters,
+		"PartialUpdate":      testPartialUpdate,
+		"UpdateWorkoutData":  testUpdateWorkoutData,
+		"Duplicates":         testDuplicates,
+		"Originals":          testOriginals,
+		"Trash":              testTrash,
+		"Sessions":           testSessions,
+		"Roles":              testRoles,
+		"UserTokens":         testUserTokens,
+		"LoginAttempts":      testLoginAttempts,
+		"AccessTokens":       testAccessTokens,
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepo(t))
+		})
+	}
+}
+
+// newUser создаёт пользователя с уникальным email и возвращает его id
+func newUser(t *testing.T, repo Repository) uuid.UUID {
+	t.Helper()
+
+	id, err := repo.CreateUser(context.Background(), uuid.Must(uuid.NewV4()).String()+"@example.com", []byte("hash"))
+	require.NoError(t, err)
+	return uuid.FromStringOrNil(id)
+}
+
+// testTime - время без долей микросекунды: точнее postgres не хранит
+func testTime(day int) time.Time {
+	return time.Date(2025, time.June, day, 7, 30, 0, 0, time.UTC)
+}
+
+func newWorkout(userID uuid.UUID, name string, day int, distance string) *entity.Workout {
+	return &entity.Workout{
+		UserID:       userID,
+		Name:         name,
+		SportType:    "Бег",
+		Date:         testTime(day),
+		Duration:     time.Duration(day) * time.Minute,
+		Distance:     distance,
+		AvgPace:      330,
+		AvgHeartRate: 150,
+		MaxHeartRate: 175,
+		AvgCadence:   170,
+		Calories:     500,
+		Description:  "",
+		CreatedAt:    testTime(day),
+		UpdatedAt:    testTime(day),
+	}
+}
+
+func create(t *testing.T, repo Repository, w *entity.Workout) *entity.Workout {
+	t.Helper()
+
+	saved, err := repo.CreateWorkout(context.Background(), w)
+	require.NoError(t, err)
+	return saved
+}
+
+func names(workouts []entity.Workout) []string {
+	result := make([]string, len(workouts))
+	for i, w := range workouts {
+		result[i] = w.Name
+	}
+	return result
+}
+
+func testUsers(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	email := uuid.Must(uuid.NewV4()).String() + "@example.com"
+
+	id, err := repo.CreateUser(ctx, email, []byte("hash"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	_, err = repo.CreateUser(ctx, email, []byte("other"))
+	assert.ErrorIs(t, err, storage.ErrUserExists)
+
+	u, err := repo.GetUser(ctx, email)
+	require.NoError(t, err)
+	assert.Equal(t, id, u.ID)
+	assert.Equal(t, email, u.Email)
+	assert.Equal(t, "hash", u.Password)
+
+	_, err = repo.GetUser(ctx, "missing-"+email)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	u, err = repo.GetUserByID(ctx, uuid.FromStringOrNil(id))
+	require.NoError(t, err)
+	assert.Equal(t, email, u.Email)
+
+	_, err = repo.GetUserByID(ctx, uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+}
+
+func testCreateAndGet(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	in := newWorkout(userID, "Утренний бег", 1, "10.50")
+	in.Description = "лёгкий темп"
+	in.StartTime = testTime(1)
+	saved := create(t, repo, in)
+	require.False(t, saved.ID.IsNil(), "id назначается при создании")
+	assert.Equal(t, 1, saved.Version)
+
+	got, err := repo.GetWorkoutByID(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, got.UserID)
+	assert.Equal(t, "Утренний бег", got.Name)
+	assert.Equal(t, "Бег", got.SportType)
+	assert.Equal(t, "лёгкий темп", got.Description)
+	assert.True(t, got.Date.Equal(testTime(1)), "date: %v", got.Date)
+	assert.True(t, got.StartTime.Equal(testTime(1)), "start_time: %v", got.StartTime)
+	assert.Equal(t, time.Minute, got.Duration)
+	assert.Equal(t, "10.50", got.Distance)
+	assert.Equal(t, 330, got.AvgPace)
+	assert.Equal(t, 150, got.AvgHeartRate)
+	assert.Equal(t, 175, got.MaxHeartRate)
+	assert.Equal(t, uint16(170), got.AvgCadence)
+	assert.Equal(t, uint16(500), got.Calories)
+	assert.Equal(t, 1, got.Version)
+	assert.True(t, got.DeletedAt.IsZero())
+}
+
+func testNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID, otherID := newUser(t, repo), newUser(t, repo)
+	saved := create(t, repo, newWorkout(userID, "Бег", 1, "5.00"))
+	missing := uuid.Must(uuid.NewV4())
+
+	_, err := repo.GetWorkoutByID(ctx, missing)
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	_, err = repo.GetWorkoutOriginal(ctx, otherID, saved.ID)
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound, "чужая тренировка")
+
+	_, err = repo.FindWorkoutByHash(ctx, userID, "no-such-hash")
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	assert.ErrorIs(t, repo.SoftDeleteWorkout(ctx, otherID, saved.ID), storage.ErrWorkoutNotFound)
+	assert.ErrorIs(t, repo.UpdateWorkoutData(ctx, &entity.Workout{ID: missing}), storage.ErrWorkoutNotFound)
+
+	_, err = repo.RestoreWorkout(ctx, userID, saved.ID, time.Time{})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound, "тренировка не в корзине")
+
+	ok, err := repo.IsCoachOf(ctx, otherID, userID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testTrackPointsAndLaps(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID, otherID := newUser(t, repo), newUser(t, repo)
+
+	w := newWorkout(userID, "Интервалы", 2, "3.00")
+	for i := 0; i < 3000; i++ {
+		w.RecordData = append(w.RecordData, entity.RecordData{
+			Timestamp: testTime(2).Add(time.Duration(i) * time.Second),
+			Distance:  uint32(i * 100),
+			Speed:     1000,
+			HeartRate: uint8(100 + i%50),
+		})
+	}
+	w.Laps = []entity.LapData{
+		{StartTime: testTime(2), Timestamp: testTime(2).Add(25 * time.Minute), TotalDistance: 150000, TotalTimerTime: 1500000, TotalElapsedTime: 1500000},
+		{StartTime: testTime(2).Add(25 * time.Minute), Timestamp: testTime(2).Add(50 * time.Minute), TotalDistance: 150000, TotalTimerTime: 1500000, TotalElapsedTime: 1500000},
+	}
+	saved := create(t, repo, w)
+
+	points, err := repo.GetTrackPoints(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	require.Len(t, points, 3000)
+	assert.True(t, points[10].Timestamp.Equal(testTime(2).Add(10*time.Second)))
+	assert.Equal(t, uint32(1000), points[10].Distance)
+	assert.Equal(t, uint16(1000), points[10].Speed)
+	assert.Equal(t, uint8(110), points[10].HeartRate)
+
+	laps, err := repo.GetLaps(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	require.Len(t, laps, 2)
+	assert.True(t, laps[1].StartTime.Equal(testTime(2).Add(25*time.Minute)))
+	assert.Equal(t, uint32(150000), laps[1].TotalDistance)
+
+	// Чужой трек не отдаётся
+	points, err = repo.GetTrackPoints(ctx, otherID, saved.ID)
+	require.NoError(t, err)
+	assert.Empty(t, points)
+	laps, err = repo.GetLaps(ctx, otherID, saved.ID)
+	require.NoError(t, err)
+	assert.Empty(t, laps)
+}
+
+func testListOrdering(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	create(t, repo, newWorkout(userID, "B", 2, "21.10"))
+	create(t, repo, newWorkout(userID, "A", 1, "5.00"))
+	create(t, repo, newWorkout(userID, "C", 3, "10.00"))
+	create(t, repo, newWorkout(newUser(t, repo), "чужая", 4, "1.00"))
+
+	byDate, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDate})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"C", "B", "A"}, names(byDate), "по умолчанию сначала новые")
+
+	byDistance, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDistance, SortAsc: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "C", "B"}, names(byDistance))
+
+	// Страница по курсору начинается сразу после последней тренировки предыдущей
+	first, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDate, Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"C", "B"}, names(first))
+
+	last := first[len(first)-1]
+	next, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{
+		SortBy: dto.SortByDate,
+		After:  &dto.WorkoutCursor{Value: last.Date.Format(time.RFC3339Nano), ID: last.ID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A"}, names(next))
+
+	offset, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDate, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"B"}, names(offset))
+
+	// Одинаковые значения поля сортировки различаются по id и не теряются между страницами
+	sameID := newUser(t, repo)
+	for _, name := range []string{"x", "y", "z"} {
+		create(t, repo, newWorkout(sameID, name, 5, "5.00"))
+	}
+	var seen []string
+	f := dto.WorkoutFilter{SortBy: dto.SortByDistance, Limit: 1}
+	for i := 0; i < 4; i++ {
+		page, err := repo.GetWorkouts(ctx, sameID, f)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		seen = append(seen, page[0].Name)
+		f.After = &dto.WorkoutCursor{Value: page[0].Distance, ID: page[0].ID}
+	}
+	assert.ElementsMatch(t, []string{"x", "y", "z"}, seen)
+}
+
+func testListFilters(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	long := newWorkout(userID, "Длинная", 10, "30.00")
+	long.Description = "по набережной"
+	create(t, repo, long)
+	ride := newWorkout(userID, "Покатушка", 12, "60.00")
+	ride.SportType = "Велосипед"
+	create(t, repo, ride)
+	create(t, repo, newWorkout(userID, "Короткая", 14, "5.00"))
+
+	list := func(f dto.WorkoutFilter) []string {
+		t.Helper()
+		f.SortBy = dto.SortByDate
+		workouts, err := repo.GetWorkouts(ctx, userID, f)
+		require.NoError(t, err)
+		return names(workouts)
+	}
+
+	assert.Equal(t, []string{"Покатушка"}, list(dto.WorkoutFilter{SportType: "Велосипед"}))
+	assert.Equal(t, []string{"Покатушка", "Длинная"}, list(dto.WorkoutFilter{MinDistance: 10}))
+	assert.Equal(t, []string{"Короткая", "Длинная"}, list(dto.WorkoutFilter{MaxDistance: 30}))
+	assert.Equal(t, []string{"Короткая"}, list(dto.WorkoutFilter{MinDuration: 13 * time.Minute}))
+	assert.Equal(t, []string{"Покатушка", "Длинная"}, list(dto.WorkoutFilter{StartDate: testTime(10), EndDate: testTime(12)}))
+	assert.Equal(t, []string{"Длинная"}, list(dto.WorkoutFilter{Query: "НАБЕРЕЖ"}), "поиск по описанию без учёта регистра")
+	assert.Empty(t, list(dto.WorkoutFilter{Query: "%"}), "спецсимволы ищутся буквально")
+}
+
+func testPartialUpdate(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	in := newWorkout(userID, "Старое название", 1, "10.00")
+	in.Description = "заметка"
+	saved := create(t, repo, in)
+
+	name := "Новое название"
+	var hr int16 = 155
+	updated, err := repo.UpdateWorkout(ctx, dto.UpdateWorkout{ID: saved.ID, Version: 1, Name: &name, AvgHeartRate: &hr})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+	assert.Equal(t, "Новое название", updated.Name)
+	assert.Equal(t, 155, updated.AvgHeartRate)
+
+	// Непереданные поля не меняются
+	assert.Equal(t, "заметка", updated.Description)
+	assert.Equal(t, "10.00", updated.Distance)
+	assert.Equal(t, 175, updated.MaxHeartRate)
+
+	got, err := repo.GetWorkoutByID(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Новое название", got.Name)
+	assert.Equal(t, 2, got.Version)
+	// Правка пульса меняет данные из файла - это отмечается для пересборки
+	assert.False(t, got.EditedAt.IsZero())
+
+	// Переименование данных из файла не касается
+	renamed := create(t, repo, newWorkout(userID, "Без правок", 2, "5.00"))
+	_, err = repo.UpdateWorkout(ctx, dto.UpdateWorkout{ID: renamed.ID, Version: 1, Name: &name})
+	require.NoError(t, err)
+	got, err = repo.GetWorkoutByID(ctx, renamed.ID)
+	require.NoError(t, err)
+	assert.True(t, got.EditedAt.IsZero())
+
+	// Изменение по устаревшей версии отклоняется
+	_, err = repo.UpdateWorkout(ctx, dto.UpdateWorkout{ID: saved.ID, Version: 1, Name: &name})
+	assert.ErrorIs(t, err, storage.ErrVersionConflict)
+}
+
+func testUpdateWorkoutData(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	w := newWorkout(userID, "Бег", 1, "1.00")
+	w.RecordData = []entity.RecordData{{Timestamp: testTime(1), Distance: 100}, {Timestamp: testTime(1).Add(time.Second), Distance: 200}}
+	w.Laps = []entity.LapData{{StartTime: testTime(1), Timestamp: testTime(1).Add(time.Second)}}
+	saved := create(t, repo, w)
+
+	fresh := *saved
+	fresh.RecordData, fresh.Laps = nil, nil
+	fresh.Calories = 600
+	require.NoError(t, repo.UpdateWorkoutData(ctx, &fresh))
+
+	got, err := repo.GetWorkoutByID(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(600), got.Calories)
+	assert.Equal(t, "Бег", got.Name, "название не пересчитывается")
+	// Данные тренировки изменились - старый ETag больше не должен подходить
+	assert.Greater(t, got.Version, saved.Version)
+	assert.Equal(t, got.Version, fresh.Version)
+
+	name := "Старая версия"
+	_, err = repo.UpdateWorkout(ctx, dto.UpdateWorkout{ID: saved.ID, Version: saved.Version, Name: &name})
+	assert.ErrorIs(t, err, storage.ErrVersionConflict)
+
+	// Пересборка по устаревшей версии не затирает изменения, сделанные после чтения
+	stale := *saved
+	stale.Calories = 700
+	assert.ErrorIs(t, repo.UpdateWorkoutData(ctx, &stale), storage.ErrVersionConflict)
+	got, err = repo.GetWorkoutByID(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(600), got.Calories)
+
+	// nil - трек и круги остаются прежними
+	points, err := repo.GetTrackPoints(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	// Пустой срез - удалить сохранённые
+	fresh.RecordData = []entity.RecordData{{Timestamp: testTime(1), Distance: 500}}
+	fresh.Laps = []entity.LapData{}
+	require.NoError(t, repo.UpdateWorkoutData(ctx, &fresh))
+
+	points, err = repo.GetTrackPoints(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, uint32(500), points[0].Distance)
+
+	laps, err := repo.GetLaps(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	assert.Empty(t, laps)
+
+	// Время слияния сохраняется и не сбрасывается следующим обновлением без него
+	fresh.RecordData, fresh.Laps = nil, nil
+	fresh.MergedAt = testTime(2)
+	require.NoError(t, repo.UpdateWorkoutData(ctx, &fresh))
+	fresh.MergedAt = time.Time{}
+	require.NoError(t, repo.UpdateWorkoutData(ctx, &fresh))
+
+	got, err = repo.GetWorkoutByID(ctx, saved.ID)
+	require.NoError(t, err)
+	assert.True(t, testTime(2).Equal(got.MergedAt))
+}
+
+func testDuplicates(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	w := newWorkout(userID, "Бег", 1, "5.00")
+	w.FileHash = "abc123"
+	w.StartTime = testTime(1)
+	saved := create(t, repo, w)
+
+	found, err := repo.FindWorkoutByHash(ctx, userID, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, found.ID)
+
+	_, err = repo.FindWorkoutByHash(ctx, newUser(t, repo), "abc123")
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound, "хэш ищется только среди тренировок пользователя")
+
+	inRange, err := repo.FindWorkoutsByStartTime(ctx, userID, testTime(1).Add(-time.Minute), testTime(1).Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, inRange, 1)
+	assert.Equal(t, saved.ID, inRange[0].ID)
+
+	outOfRange, err := repo.FindWorkoutsByStartTime(ctx, userID, testTime(2), testTime(3))
+	require.NoError(t, err)
+	assert.Empty(t, outOfRange)
+}
+
+func testOriginals(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	var ids []uuid.UUID
+	for i := 1; i <= 3; i++ {
+		w := newWorkout(userID, "Бег", i, "5.00")
+		w.OriginalKey = "originals/" + userID.String() + "/" + string(rune('a'+i))
+		w.OriginalName = "run.fit"
+		ids = append(ids, create(t, repo, w).ID)
+	}
+	create(t, repo, newWorkout(userID, "Вручную", 4, "1.00"))
+
+	original, err := repo.GetWorkoutOriginal(ctx, userID, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "run.fit", original.OriginalName)
+
+	// Постраничный обход по id без пропусков и повторов
+	var seen []uuid.UUID
+	after := uuid.Nil
+	for {
+		page, err := repo.ListWorkoutsWithOriginal(ctx, userID, after, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for _, w := range page {
+			seen = append(seen, w.ID)
+		}
+		after = page[len(page)-1].ID
+	}
+	assert.ElementsMatch(t, ids, seen)
+}
+
+func testTrash(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	w := newWorkout(userID, "Бег", 1, "5.00")
+	w.OriginalKey = "originals/" + userID.String() + "/trash"
+	saved := create(t, repo, w)
+	kept := newWorkout(userID, "Оставить", 2, "5.00")
+	kept.OriginalKey = "originals/" + userID.String() + "/shared"
+	create(t, repo, kept)
+
+	require.NoError(t, repo.SoftDeleteWorkout(ctx, userID, saved.ID))
+	assert.ErrorIs(t, repo.SoftDeleteWorkout(ctx, userID, saved.ID), storage.ErrWorkoutNotFound, "уже в корзине")
+
+	_, err := repo.GetWorkoutByID(ctx, saved.ID)
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	list, err := repo.GetWorkouts(ctx, userID, dto.WorkoutFilter{SortBy: dto.SortByDate})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Оставить"}, names(list))
+
+	trash, err := repo.GetDeletedWorkouts(ctx, userID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.False(t, trash[0].DeletedAt.IsZero())
+
+	// Срок хранения истёк
+	_, err = repo.RestoreWorkout(ctx, userID, saved.ID, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	restored, err := repo.RestoreWorkout(ctx, userID, saved.ID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+
+	// Окончательная очистка возвращает ключи файлов, на которые больше никто не ссылается
+	require.NoError(t, repo.SoftDeleteWorkout(ctx, userID, saved.ID))
+	purged, orphaned, err := repo.PurgeDeletedWorkouts(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+	assert.Contains(t, orphaned, w.OriginalKey)
+	assert.NotContains(t, orphaned, kept.OriginalKey)
+
+	trash, err = repo.GetDeletedWorkouts(ctx, userID, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	points, err := repo.GetTrackPoints(ctx, userID, saved.ID)
+	require.NoError(t, err)
+	assert.Empty(t, points)
+}
+
+func testSessions(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+	other := newUser(t, repo)
+
+	newSession := func(userID uuid.UUID, day int, hash string) *entity.Session {
+		s := &entity.Session{
+			ID:         uuid.Must(uuid.NewV4()),
+			UserID:     userID,
+			UserAgent:  "AthleticHub/1.0 (iPhone)",
+			IP:         "192.0.2.1",
+			CreatedAt:  testTime(day),
+			LastUsedAt: testTime(day),
+			ExpiresAt:  testTime(day + 7),
+		}
+		require.NoError(t, repo.CreateSession(ctx, s, hash))
+		return s
+	}
+
+	suffix := uuid.Must(uuid.NewV4()).String()
+	phone := newSession(userID, 1, "phone-1-"+suffix)
+	laptop := newSession(userID, 2, "laptop-1-"+suffix)
+	newSession(other, 2, "other-1-"+suffix)
+
+	got, err := repo.GetSession(ctx, phone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, phone.UserAgent, got.UserAgent)
+	assert.True(t, phone.ExpiresAt.Equal(got.ExpiresAt))
+	assert.True(t, got.RevokedAt.IsZero())
+
+	_, err = repo.GetSession(ctx, uuid.Must(uuid.NewV4()))
+	assert.ErrorIs(t, err, storage.ErrSessionNotFound)
+
+	// Ротация: токен используется один раз, новый токен продлевает сессию
+	token, err := repo.GetRefreshToken(ctx, "phone-1-"+suffix)
+	require.NoError(t, err)
+	assert.Equal(t, phone.ID, token.SessionID)
+	assert.True(t, token.UsedAt.IsZero())
+
+	require.NoError(t, repo.UseRefreshToken(ctx, "phone-1-"+suffix, testTime(3)))
+	assert.ErrorIs(t, repo.UseRefreshToken(ctx, "phone-1-"+suffix, testTime(3)), storage.ErrRefreshTokenUsed)
+	require.NoError(t, repo.AddRefreshToken(ctx, phone.ID, "phone-2-"+suffix, testTime(3), testTime(10)))
+
+	token, err = repo.GetRefreshToken(ctx, "phone-1-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, token.UsedAt.Equal(testTime(3)))
+
+	_, err = repo.GetRefreshToken(ctx, "missing-"+suffix)
+	assert.ErrorIs(t, err, storage.ErrSessionNotFound)
+
+	// Недавно использованные первыми, истёкшие и чужие не показываются
+	sessions, err := repo.ListSessions(ctx, userID, testTime(4))
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+	assert.True(t, sessions[0].ExpiresAt.Equal(testTime(10)))
+	assert.Equal(t, laptop.ID, sessions[1].ID)
+
+	sessions, err = repo.ListSessions(ctx, userID, testTime(9))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, phone.ID, sessions[0].ID)
+
+	// Отзыв: только своей и только один раз
+	assert.ErrorIs(t, repo.RevokeSession(ctx, other, phone.ID, testTime(5)), storage.ErrSessionNotFound)
+	require.NoError(t, repo.RevokeSession(ctx, userID, phone.ID, testTime(5)))
+	assert.ErrorIs(t, repo.RevokeSession(ctx, userID, phone.ID, testTime(5)), storage.ErrSessionNotFound)
+
+	got, err = repo.GetSession(ctx, phone.ID)
+	require.NoError(t, err)
+	assert.True(t, got.RevokedAt.Equal(testTime(5)))
+	assert.ErrorIs(t, repo.AddRefreshToken(ctx, phone.ID, "phone-3-"+suffix, testTime(5), testTime(12)), storage.ErrSessionNotFound)
+
+	sessions, err = repo.ListSessions(ctx, userID, testTime(4))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptop.ID, sessions[0].ID)
+}
+
+func testRoles(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	userID := newUser(t, repo)
+
+	roles, err := repo.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	require.NoError(t, repo.SetUserRoles(ctx, userID, []entity.Role{entity.RoleCoach, entity.RoleAthlete, entity.RoleCoach}))
+	roles, err = repo.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.Role{entity.RoleAthlete, entity.RoleCoach}, roles)
+
+	// Роли заменяются целиком
+	require.NoError(t, repo.SetUserRoles(ctx, userID, []entity.Role{entity.RoleAdmin}))
+	roles, err = repo.GetUserRoles(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.Role{entity.RoleAdmin}, roles)
+
+	missing := uuid.Must(uuid.NewV4())
+	_, err = repo.GetUserRoles(ctx, missing)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.ErrorIs(t, repo.SetUserRoles(ctx, missing, []entity.Role{entity.RoleAthlete}), storage.ErrUserNotFound)
+
+	users, err := repo.ListUsers(ctx)
+	require.NoError(t, err)
+	var found bool
+	for i, u := range users {
+		if i > 0 {
+			assert.LessOrEqual(t, users[i-1].Email, u.Email)
+		}
+		if u.ID == userID.String() {
+			found = true
+			assert.Equal(t, []entity.Role{entity.RoleAdmin}, u.Roles)
+		}
+	}
+	assert.True(t, found)
+}
+
+func testUserTokens(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	email := uuid.Must(uuid.NewV4()).String() + "@example.com"
+	id, err := repo.CreateUser(ctx, email, []byte("hash"))
+	require.NoError(t, err)
+	userID := uuid.FromStringOrNil(id)
+
+	u, err := repo.GetUser(ctx, email)
+	require.NoError(t, err)
+	assert.False(t, u.EmailVerified)
+
+	suffix := uuid.Must(uuid.NewV4()).String()
+	newToken := func(name string, purpose entity.TokenPurpose, expiresDay int) string {
+		hash := name + "-" + suffix
+		require.NoError(t, repo.CreateUserToken(ctx, &entity.UserToken{
+			Hash:      hash,
+			UserID:    userID,
+			Purpose:   purpose,
+			CreatedAt: testTime(1),
+			ExpiresAt: testTime(expiresDay),
+		}))
+		return hash
+	}
+
+	verify := newToken("verify", entity.TokenVerifyEmail, 3)
+	expired := newToken("expired", entity.TokenResetPassword, 2)
+	first := newToken("reset-1", entity.TokenResetPassword, 5)
+	second := newToken("reset-2", entity.TokenResetPassword, 5)
+
+	// Токен одного назначения не подходит для другого
+	_, err = repo.UseUserToken(ctx, verify, entity.TokenResetPassword, testTime(2))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+	_, err = repo.UseUserToken(ctx, expired, entity.TokenResetPassword, testTime(2))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+	_, err = repo.UseUserToken(ctx, "missing-"+suffix, entity.TokenVerifyEmail, testTime(2))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	got, err := repo.UseUserToken(ctx, verify, entity.TokenVerifyEmail, testTime(2))
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+	_, err = repo.UseUserToken(ctx, verify, entity.TokenVerifyEmail, testTime(2))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	// Использование одной ссылки сброса гасит остальные
+	got, err = repo.UseUserToken(ctx, second, entity.TokenResetPassword, testTime(3))
+	require.NoError(t, err)
+	assert.Equal(t, userID, got)
+	_, err = repo.UseUserToken(ctx, first, entity.TokenResetPassword, testTime(3))
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	require.NoError(t, repo.SetEmailVerified(ctx, userID, testTime(3)))
+	require.NoError(t, repo.UpdatePassword(ctx, userID, []byte("new-hash")))
+	u, err = repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, u.EmailVerified)
+	assert.Equal(t, "new-hash", u.Password)
+
+	missing := uuid.Must(uuid.NewV4())
+	assert.ErrorIs(t, repo.SetEmailVerified(ctx, missing, testTime(3)), storage.ErrUserNotFound)
+	assert.ErrorIs(t, repo.UpdatePassword(ctx, missing, []byte("x")), storage.ErrUserNotFound)
+
+	// Все сессии пользователя отзываются разом
+	for i := range 2 {
+		require.NoError(t, repo.CreateSession(ctx, &entity.Session{
+			ID:         uuid.Must(uuid.NewV4()),
+			UserID:     userID,
+			CreatedAt:  testTime(1),
+			LastUsedAt: testTime(1),
+			ExpiresAt:  testTime(10),
+		}, fmt.Sprintf("session-%d-%s", i, suffix)))
+	}
+	require.NoError(t, repo.RevokeUserSessions(ctx, userID, testTime(4)))
+	sessions, err := repo.ListSessions(ctx, userID, testTime(5))
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func testLoginAttempts(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	email := uuid.Must(uuid.NewV4()).String() + "@example.com"
+	id, err := repo.CreateUser(ctx, email, []byte("hash"))
+	require.NoError(t, err)
+	userID := uuid.FromStringOrNil(id)
+
+	for want := 1; want <= 3; want++ {
+		failures, err := repo.AddFailedLogin(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+	require.NoError(t, repo.LockUser(ctx, userID, testTime(2)))
+
+	u, err := repo.GetUser(ctx, email)
+	require.NoError(t, err)
+	assert.Equal(t, 3, u.FailedLogins)
+	assert.True(t, testTime(2).Equal(u.LockedUntil), u.LockedUntil)
+
+	require.NoError(t, repo.ResetFailedLogins(ctx, userID))
+	u, err = repo.GetUserByID(ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, u.FailedLogins)
+	assert.True(t, u.LockedUntil.IsZero())
+
+	missing := uuid.Must(uuid.NewV4())
+	_, err = repo.AddFailedLogin(ctx, missing)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.ErrorIs(t, repo.LockUser(ctx, missing, testTime(2)), storage.ErrUserNotFound)
+	assert.ErrorIs(t, repo.ResetFailedLogins(ctx, missing), storage.ErrUserNotFound)
+
+	// Попытка с незарегистрированным адресом пишется без пользователя
+	unknown := &entity.LoginAttempt{Email: "nobody-" + email, Reason: entity.LoginUnknownUser, CreatedAt: testTime(1)}
+	require.NoError(t, repo.AddLoginAttempt(ctx, unknown))
+	assert.NotZero(t, unknown.ID)
+	require.NoError(t, repo.AddLoginAttempt(ctx, &entity.LoginAttempt{Email: unknown.Email, Reason: entity.LoginUnknownUser, CreatedAt: testTime(3)}))
+	require.NoError(t, repo.AddLoginAttempt(ctx, &entity.LoginAttempt{Email: unknown.Email, Reason: entity.LoginLocked, CreatedAt: testTime(4)}))
+
+	// Неудачи с незарегистрированным адресом считаются по адресу и причине
+	count, last, err := repo.CountLoginAttempts(ctx, unknown.Email, entity.LoginUnknownUser)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.True(t, testTime(3).Equal(last), last)
+
+	count, last, err = repo.CountLoginAttempts(ctx, "never-"+email, entity.LoginUnknownUser)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.True(t, last.IsZero())
+
+	for day, reason := range []entity.LoginFailure{entity.LoginInvalidPassword, entity.LoginInvalidPassword, entity.LoginLocked} {
+		require.NoError(t, repo.AddLoginAttempt(ctx, &entity.LoginAttempt{
+			Email:     email,
+			UserID:    userID,
+			IP:        "10.0.0.1",
+			UserAgent: "curl",
+			Reason:    reason,
+			CreatedAt: testTime(day + 1),
+		}))
+	}
+
+	attempts, err := repo.ListLoginAttempts(ctx, userID, 2)
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, entity.LoginLocked, attempts[0].Reason)
+	assert.True(t, testTime(3).Equal(attempts[0].CreatedAt))
+	assert.Equal(t, userID, attempts[0].UserID)
+	assert.Equal(t, "10.0.0.1", attempts[0].IP)
+	assert.Equal(t, "curl", attempts[0].UserAgent)
+	assert.Equal(t, email, attempts[0].Email)
+	assert.Equal(t, entity.LoginInvalidPassword, attempts[1].Reason)
+}
+
+func testAccessTokens(t *testing.T, repo Repository) {
+	ctx := context.Background()
+	newUser := func() uuid.UUID {
+		id, err := repo.CreateUser(ctx, uuid.Must(uuid.NewV4()).String()+"@example.com", []byte("hash"))
+		require.NoError(t, err)
+		return uuid.FromStringOrNil(id)
+	}
+	owner, other := newUser(), newUser()
+	suffix := uuid.Must(uuid.NewV4()).String()
+
+	newToken := func(name string, day int, expires time.Time, scopes ...entity.Scope) *entity.AccessToken {
+		token := &entity.AccessToken{
+			ID:        uuid.Must(uuid.NewV4()),
+			UserID:    owner,
+			Name:      name,
+			Prefix:    "ahp_" + name,
+			Scopes:    scopes,
+			CreatedAt: testTime(day),
+			ExpiresAt: expires,
+		}
+		require.NoError(t, repo.CreateAccessToken(ctx, token, name+"-"+suffix))
+		return token
+	}
+	sync := newToken("sync", 1, time.Time{}, entity.ScopeUpload)
+	script := newToken("script", 2, testTime(10), entity.ScopeWorkoutsRead, entity.ScopeWorkoutsWrite)
+
+	got, err := repo.GetAccessToken(ctx, "script-"+suffix)
+	require.NoError(t, err)
+	assert.Equal(t, script.ID, got.ID)
+	assert.Equal(t, owner, got.UserID)
+	assert.Equal(t, "script", got.Name)
+	assert.Equal(t, "ahp_script", got.Prefix)
+	assert.Equal(t, []entity.Scope{entity.ScopeWorkoutsRead, entity.ScopeWorkoutsWrite}, got.Scopes)
+	assert.True(t, testTime(2).Equal(got.CreatedAt))
+	assert.True(t, testTime(10).Equal(got.ExpiresAt))
+	assert.True(t, got.LastUsedAt.IsZero())
+	assert.True(t, got.RevokedAt.IsZero())
+
+	_, err = repo.GetAccessToken(ctx, "missing-"+suffix)
+	assert.ErrorIs(t, err, storage.ErrTokenNotFound)
+
+	require.NoError(t, repo.TouchAccessToken(ctx, sync.ID, testTime(3)))
+	got, err = repo.GetAccessToken(ctx, "sync-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, testTime(3).Equal(got.LastUsedAt))
+	assert.True(t, got.ExpiresAt.IsZero(), "бессрочный токен")
+
+	tokens, err := repo.ListAccessTokens(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, script.ID, tokens[0].ID)
+	assert.Equal(t, sync.ID, tokens[1].ID)
+
+	// Чужой токен отозвать нельзя
+	assert.ErrorIs(t, repo.RevokeAccessToken(ctx, other, script.ID, testTime(4)), storage.ErrTokenNotFound)
+	require.NoError(t, repo.RevokeAccessToken(ctx, owner, script.ID, testTime(4)))
+	assert.ErrorIs(t, repo.RevokeAccessToken(ctx, owner, script.ID, testTime(4)), storage.ErrTokenNotFound)
+
+	got, err = repo.GetAccessToken(ctx, "script-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, testTime(4).Equal(got.RevokedAt))
+
+	tokens, err = repo.ListAccessTokens(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, sync.ID, tokens[0].ID)
+
+	// После сброса пароля отзываются все токены пользователя, но не чужие
+	foreign := &entity.AccessToken{
+		ID:        uuid.Must(uuid.NewV4()),
+		UserID:    other,
+		Name:      "foreign",
+		Prefix:    "ahp_foreign",
+		Scopes:    []entity.Scope{entity.ScopeWorkoutsRead},
+		CreatedAt: testTime(2),
+	}
+	require.NoError(t, repo.CreateAccessToken(ctx, foreign, "foreign-"+suffix))
+	require.NoError(t, repo.RevokeUserAccessTokens(ctx, owner, testTime(5)))
+
+	got, err = repo.GetAccessToken(ctx, "sync-"+suffix)
+	require.NoError(t, err)
+	assert.False(t, got.Active(testTime(6)), "токен отклоняется после сброса пароля")
+	assert.True(t, testTime(5).Equal(got.RevokedAt))
+
+	tokens, err = repo.ListAccessTokens(ctx, owner)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	got, err = repo.GetAccessToken(ctx, "foreign-"+suffix)
+	require.NoError(t, err)
+	assert.True(t, got.Active(testTime(6)))
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,12 +14,88 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Auth     AuthConfig
-	//Storage  StorageConfig
+	Files    FileStorageConfig
+	Trash    TrashConfig
+	Storage  StorageConfig
+	Mail     MailerConfig
+	Limits   RateLimitConfig
 	//Logging  LoggingConfig
 }
 
 type AuthConfig struct {
-	TokenTTL time.Duration
+	TokenTTL        time.Duration // срок жизни access-токена
+	RefreshTokenTTL time.Duration // сколько сессия устройства живёт без обновления токенов
+	AdminEmails     []string      // адреса, которые при регистрации получают роль admin
+
+	// Подпись токенов: закрытый ключ из JWTKeyFile (RS256 или EdDSA) или секрет HS256
+	JWTSecret          string   // секрет HS256 не короче 32 байт
+	JWTKeyFile         string   // PEM-файл закрытого ключа RSA или Ed25519
+	JWTKeyID           string   // kid ключа подписи; пустой - вычисляется из ключа
+	JWTVerifyKeyFiles  []string // прежние ключи (PEM, можно "kid=путь"), токены которых ещё принимаются
+	JWTPreviousSecrets []string // прежние секреты HS256, токены которых ещё принимаются
+
+	// Подтверждение почты и сброс пароля
+	RequireVerifiedEmail bool          // не пускать пользователей с неподтверждённой почтой
+	VerifyURL            string        // адрес, к которому в письме добавляется ?token=
+	PasswordResetURL     string        // страница фронтенда с формой нового пароля, тоже получает ?token=
+	VerifyTokenTTL       time.Duration // срок жизни ссылки подтверждения почты
+	ResetTokenTTL        time.Duration // срок жизни ссылки сброса пароля
+
+	// Прогрессивная блокировка: после LockoutThreshold неудачных попыток входа подряд
+	// вход блокируется на LockoutDuration, каждая следующая неудача удваивает срок до LockoutMaxDuration
+	LockoutThreshold   int
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+}
+
+// RateLimitConfig ограничения частоты запросов к входу и регистрации.
+// Лимит N за окно W: можно сделать N запросов подряд, дальше по одному раз в W/N
+type RateLimitConfig struct {
+	Backend       string // memory или redis (лимиты общие для нескольких экземпляров сервиса)
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	LoginPerIP      int // попыток входа с одного IP за LoginWindow; 0 - без ограничения
+	LoginPerAccount int // попыток входа в одну учётную запись за LoginWindow
+	LoginWindow     time.Duration
+	RegisterPerIP   int // регистраций и писем (сброс пароля, повтор подтверждения) с одного IP за RegisterWindow
+	RegisterWindow  time.Duration
+}
+
+// MailerConfig настройки отправки писем
+type MailerConfig struct {
+	Type         string // log (письма только в журнал), file (файлы .eml в FilePath) или smtp
+	From         string
+	FilePath     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// FileStorageConfig настройки хранилища исходных файлов тренировок
+type FileStorageConfig struct {
+	Type        string // local или s3
+	LocalPath   string
+	S3Endpoint  string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3Region    string
+	S3UseSSL    bool
+}
+
+// StorageConfig выбор хранилища тренировок и пользователей
+type StorageConfig struct {
+	Type       string // postgres, sqlite или memory (данные в памяти процесса, для тестов и демо)
+	SQLitePath string // файл базы для sqlite
+}
+
+// TrashConfig настройки корзины удалённых тренировок
+type TrashConfig struct {
+	Retention     time.Duration // сколько тренировку можно восстановить после удаления
+	PurgeInterval time.Duration // как часто окончательно удалять тренировки с истёкшим сроком
 }
 
 // ServerConfig настройки сервера
@@ -29,6 +106,7 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	Debug        bool
+	TrustProxy   bool // брать IP клиента из X-Forwarded-For; включать только за своим прокси
 }
 
 // DatabaseConfig настройки базы данных
@@ -61,6 +139,7 @@ func LoadConfig(envPath string) (*Config, error) {
 		ReadTimeout:  getEnvAsDuration("SERVER_READ_TIMEOUT", 30*time.Second),
 		WriteTimeout: getEnvAsDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
 		Debug:        getEnvAsBool("DEBUG", false),
+		TrustProxy:   getEnvAsBool("SERVER_TRUST_PROXY", false),
 	}
 
 	// Загружаем настройки базы данных
@@ -77,7 +156,69 @@ func LoadConfig(envPath string) (*Config, error) {
 	}
 
 	config.Auth = AuthConfig{
-		TokenTTL: getEnvAsDuration("AUTH_TOKEN_TTL", 30*time.Second),
+		TokenTTL:        getEnvAsDuration("AUTH_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvAsDuration("AUTH_REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		AdminEmails:     getEnvAsSlice("AUTH_ADMIN_EMAILS"),
+
+		JWTSecret:          getEnv("AUTH_JWT_SECRET", ""),
+		JWTKeyFile:         getEnv("AUTH_JWT_KEY_FILE", ""),
+		JWTKeyID:           getEnv("AUTH_JWT_KEY_ID", ""),
+		JWTVerifyKeyFiles:  getEnvAsSlice("AUTH_JWT_VERIFY_KEY_FILES"),
+		JWTPreviousSecrets: getEnvAsSlice("AUTH_JWT_PREVIOUS_SECRETS"),
+
+		RequireVerifiedEmail: getEnvAsBool("AUTH_REQUIRE_VERIFIED_EMAIL", true),
+		VerifyURL:            getEnv("AUTH_VERIFY_URL", "http://localhost:8080/auth/verify"),
+		PasswordResetURL:     getEnv("AUTH_PASSWORD_RESET_URL", "http://localhost:8080/reset-password"),
+		VerifyTokenTTL:       getEnvAsDuration("AUTH_VERIFY_TOKEN_TTL", 48*time.Hour),
+		ResetTokenTTL:        getEnvAsDuration("AUTH_RESET_TOKEN_TTL", time.Hour),
+
+		LockoutThreshold:   getEnvAsInt("AUTH_LOCKOUT_THRESHOLD", 5),
+		LockoutDuration:    getEnvAsDuration("AUTH_LOCKOUT_DURATION", time.Minute),
+		LockoutMaxDuration: getEnvAsDuration("AUTH_LOCKOUT_MAX_DURATION", time.Hour),
+	}
+
+	config.Limits = RateLimitConfig{
+		Backend:       getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+		RedisDB:       getEnvAsInt("REDIS_DB", 0),
+
+		LoginPerIP:      getEnvAsInt("RATE_LIMIT_LOGIN_PER_IP", 20),
+		LoginPerAccount: getEnvAsInt("RATE_LIMIT_LOGIN_PER_ACCOUNT", 10),
+		LoginWindow:     getEnvAsDuration("RATE_LIMIT_LOGIN_WINDOW", 15*time.Minute),
+		RegisterPerIP:   getEnvAsInt("RATE_LIMIT_REGISTER_PER_IP", 10),
+		RegisterWindow:  getEnvAsDuration("RATE_LIMIT_REGISTER_WINDOW", time.Hour),
+	}
+
+	config.Mail = MailerConfig{
+		Type:         getEnv("MAILER_TYPE", "log"),
+		From:         getEnv("MAIL_FROM", "AthleticHub <no-reply@localhost>"),
+		FilePath:     getEnv("MAILER_FILE_PATH", "data/mail"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+	}
+
+	config.Files = FileStorageConfig{
+		Type:        getEnv("FILE_STORAGE_TYPE", "local"),
+		LocalPath:   getEnv("FILE_STORAGE_PATH", "data/originals"),
+		S3Endpoint:  getEnv("FILE_STORAGE_S3_ENDPOINT", ""),
+		S3Bucket:    getEnv("FILE_STORAGE_S3_BUCKET", ""),
+		S3AccessKey: getEnv("FILE_STORAGE_S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("FILE_STORAGE_S3_SECRET_KEY", ""),
+		S3Region:    getEnv("FILE_STORAGE_S3_REGION", "us-east-1"),
+		S3UseSSL:    getEnvAsBool("FILE_STORAGE_S3_USE_SSL", true),
+	}
+
+	config.Storage = StorageConfig{
+		Type:       getEnv("STORAGE", "postgres"),
+		SQLitePath: getEnv("SQLITE_PATH", "data/athletichub.db"),
+	}
+
+	config.Trash = TrashConfig{
+		Retention:     getEnvAsDuration("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval: getEnvAsDuration("TRASH_PURGE_INTERVAL", time.Hour),
 	}
 
 	return config, nil
@@ -111,6 +252,17 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsSlice получает переменную окружения как список значений через запятую
+func getEnvAsSlice(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // getEnvAsDuration получает переменную окружения как time.Duration или возвращает значение по умолчанию
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -1,9 +1,18 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gofrs/uuid/v5"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"workout/internal/adapters/ratelimit"
+	"workout/internal/adapters/storage"
 	"workout/internal/controller/mapper"
 	"workout/internal/dto"
 	"workout/internal/service/activity"
@@ -12,6 +21,9 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// maxUploadSize - максимальный размер тела запроса загрузки тренировок (200MB)
+const maxUploadSize = 200 << 20
+
 const ctxKeyClaims = "claims"
 const ctxKeySub = "sub"
 
@@ -27,6 +39,11 @@ func CurrentUser(c echo.Context) (*UserClaims, bool) {
 type Handler struct {
 	workoutService *activity.WorkoutService
 	auth           *auth.AuthService
+
+	// Limiter и LoginAccountRule ограничивают попытки входа в одну учётную запись
+	// с любых адресов; nil - без ограничения. Лимиты по IP задаёт middleware RateLimit
+	Limiter          ratelimit.Limiter
+	LoginAccountRule ratelimit.Rule
 }
 
 func NewController(workoutService *activity.WorkoutService, authService *auth.AuthService) *Handler {
@@ -36,71 +53,226 @@ func NewController(workoutService *activity.WorkoutService, authService *auth.Au
 	}
 }
 
+// GetWorkouts возвращает страницу тренировок пользователя.
+// Фильтры: ?startDate=&endDate= (YYYY-MM-DD), ?type=, ?minDistance=&maxDistance= (км),
+// ?minDuration=&maxDuration= (секунды или 1h30m), ?q= (поиск по названию и описанию).
+// Сортировка: ?sort=date|distance|duration|avg_pace|avg_heart_rate|calories&order=asc|desc.
+// Пагинация: ?limit= и ?cursor= из next_cursor предыдущего ответа (или ?offset=)
 func (h *Handler) GetWorkouts(e echo.Context) error {
 	user, ok := CurrentUser(e)
 	if !ok {
 		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
 	}
 
-	workout, err := h.workoutService.GetWorkouts(e.Request().Context(), user.UID)
+	filter, err := workoutFilterParams(e)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	workouts, next, err := h.workoutService.GetWorkouts(e.Request().Context(), user.UID, filter)
 	if err != nil {
+		if errors.Is(err, activity.ErrInvalidFilter) || errors.Is(err, activity.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	result := []*dto.WorkoutDTO{}
+	result := &dto.WorkoutListDTO{Workouts: []*dto.WorkoutDTO{}, NextCursor: next}
+	for _, w := range workouts {
+		result.Workouts = append(result.Workouts, mapper.ConvertWorkoutToDTO(w))
+	}
+
+	return e.JSON(http.StatusOK, result)
+}
+
+// workoutFilterParams разбирает параметры фильтрации списка тренировок
+func workoutFilterParams(e echo.Context) (dto.WorkoutFilter, error) {
+	f := dto.WorkoutFilter{
+		SportType: e.QueryParam("type"),
+		Query:     e.QueryParam("q"),
+		SortBy:    e.QueryParam("sort"),
+		Cursor:    e.QueryParam("cursor"),
+	}
+
+	switch e.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		f.SortAsc = true
+	default:
+		return f, fmt.Errorf("order must be asc or desc")
+	}
+
+	var err error
+	if f.StartDate, err = dateParam(e, "startDate"); err != nil {
+		return f, err
+	}
+	if f.EndDate, err = dateParam(e, "endDate"); err != nil {
+		return f, err
+	}
+	if f.MinDistance, err = floatParam(e, "minDistance"); err != nil {
+		return f, err
+	}
+	if f.MaxDistance, err = floatParam(e, "maxDistance"); err != nil {
+		return f, err
+	}
+	if f.MinDuration, err = durationParam(e, "minDuration"); err != nil {
+		return f, err
+	}
+	if f.MaxDuration, err = durationParam(e, "maxDuration"); err != nil {
+		return f, err
+	}
+	if f.Limit, err = intParam(e, "limit"); err != nil {
+		return f, err
+	}
+	if f.Offset, err = intParam(e, "offset"); err != nil {
+		return f, err
+	}
+
+	return f, nil
+}
 
-	for _, w := range workout {
-		result = append(result, mapper.ConvertWorkoutToDTO(w))
+func dateParam(e echo.Context, name string) (time.Time, error) {
+	v := e.QueryParam(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)
 	}
+	return t, nil
+}
 
-	return e.JSON(http.StatusCreated, result)
+func floatParam(e echo.Context, name string) (float64, error) {
+	v := e.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return f, nil
 }
 
-// UploadHandler обрабатывает загрузку FIT-файлов через Echo фреймворк
-// с использованием новой библиотеки muktihari/fit
+func intParam(e echo.Context, name string) (int, error) {
+	v := e.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return i, nil
+}
+
+// durationParam принимает число секунд или длительность вида 1h30m
+func durationParam(e echo.Context, name string) (time.Duration, error) {
+	v := e.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(sec) * time.Second, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number of seconds or a duration like 1h30m", name)
+	}
+	return d, nil
+}
+
+// UploadHandler обрабатывает загрузку файлов тренировок (FIT, GPX, TCX) через Echo фреймворк.
+// Принимает несколько частей "file" и ZIP-архивы (выгрузки Garmin/Strava),
+// формат каждого файла определяется по содержимому.
+// Каждая тренировка сохраняется для текущего пользователя вместе с треком и кругами,
+// с ?dry_run=true файлы только разбираются для предпросмотра.
+// Уже загруженные тренировки (тот же файл или та же тренировка с другого устройства)
+// возвращаются со ссылкой duplicate_of или, с ?on_duplicate=merge, сливаются с существующей.
+// Возвращает результат по каждому файлу: успешно, ошибка разбора, дубликат или слияние
 func (h *Handler) UploadHandler(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
 	// Получаем HTTP запрос из контекста Echo
 	r := e.Request()
 
-	// Ограничиваем размер тела запроса до 10MB (10 << 20 = 10 * 2^20 = 10485760 байт)
-	// Это защищает сервер от загрузки слишком больших файлов
-	r.Body = http.MaxBytesReader(e.Response().Writer, r.Body, 10<<20)
+	// Ограничиваем размер тела запроса, чтобы защитить сервер от слишком больших загрузок.
+	// Лимит больше, чем для одного файла: в запросе может быть архив со всей историей тренировок
+	r.Body = http.MaxBytesReader(e.Response().Writer, r.Body, maxUploadSize)
 
-	// Парсим multipart/form-data с ограничением в 10MB
+	// Парсим multipart/form-data: до 32MB держим в памяти, остальное - во временных файлах
 	// Эта функция разбирает форму с файлами на составные части
-	err := r.ParseMultipartForm(10 << 20)
+	err := r.ParseMultipartForm(32 << 20)
 	if err != nil {
 		// Если парсинг не удался (файл слишком большой или неверный формат)
 		// Возвращаем ошибку 400 "Bad Request" через Echo
 		return e.String(http.StatusBadRequest, "Файл слишком большой или неверный формат")
 	}
+	defer r.MultipartForm.RemoveAll()
 
-	// Извлекаем файл из поля формы с именем "file"
-	// В Echo также можно использовать c.FormFile("file") как альтернативу
-	// file - интерфейс для чтения файла
-	file, _, err := r.FormFile("file")
-	if err != nil {
+	// Все части формы с именем "file"
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
 		return e.String(http.StatusBadRequest, "Ошибка при получении файла")
 	}
-	defer file.Close()
 
-	// Читаем всё содержимое файла в память
-	// io.ReadAll читает все данные из Reader до EOF
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return e.String(http.StatusInternalServerError, "Ошибка при чтении файла")
+	files := make([]activity.UploadedFile, 0, len(headers))
+	for _, fh := range headers {
+		data, err := readFormFile(fh)
+		if err != nil {
+			return e.String(http.StatusInternalServerError, "Ошибка при чтении файла")
+		}
+		files = append(files, activity.UploadedFile{Name: fh.Filename, Data: data})
 	}
 
-	workout, err := h.workoutService.UploadFile(e.Request().Context(), data)
+	// ?dry_run=true - только разобрать файлы и вернуть предпросмотр без сохранения
+	dryRun, _ := strconv.ParseBool(e.QueryParam("dry_run"))
+
+	// ?on_duplicate=merge - дополнить уже сохранённую тренировку вместо пропуска дубликата
+	results, err := h.workoutService.UploadFiles(r.Context(), files, activity.UploadOptions{
+		UserID:      user.UID,
+		DryRun:      dryRun,
+		OnDuplicate: e.QueryParam("on_duplicate"),
+	})
 	if err != nil {
-		return err
+		if errors.Is(err, activity.ErrInvalidDuplicateMode) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	// Отправляем JSON ответ с созданной тренировкой через Echo
+	response := make([]*dto.UploadResult, 0, len(results))
+	for _, res := range results {
+		response = append(response, mapper.ConvertUploadResultToDTO(res))
+	}
+	resp := dto.NewUploadResponse(response)
+
+	// Отправляем JSON ответ с результатами по каждому файлу через Echo
 	// c.JSON автоматически устанавливает Content-Type: application/json
-	// и HTTP статус 201 "Created" указывает что ресурс успешно создан
-	return e.JSON(http.StatusCreated, workout)
+	// и HTTP статус 201 "Created", если хотя бы одна тренировка сохранена или дополнена
+	status := http.StatusOK
+	if !dryRun && resp.Succeeded+resp.Merged > 0 {
+		status = http.StatusCreated
+	}
+	return e.JSON(status, resp)
+}
+
+// readFormFile читает всё содержимое загруженного файла в память
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// io.ReadAll читает все данные из Reader до EOF
+	return io.ReadAll(file)
 }
+
 func (h *Handler) CreateWorkout(e echo.Context) error {
 	user, ok := CurrentUser(e)
 	if !ok {
@@ -125,16 +297,244 @@ func (h *Handler) CreateWorkout(e echo.Context) error {
 	return e.JSON(http.StatusCreated, response)
 }
 
+// UpdateWorkout частично изменяет тренировку (PATCH) и возвращает её новую версию.
+// Версия, от которой клиент вносит правки, передаётся в If-Match (ETag из GET) или в поле version.
+// Если тренировку уже изменили с другого устройства, возвращается 412 и правки не применяются
 func (h *Handler) UpdateWorkout(c echo.Context) error {
-	var request dto.UpdateWorkout
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
 
+	var request dto.UpdateWorkout
 	if err := c.Bind(&request); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Некорректный формат данных"})
 	}
 
-	if err := h.workoutService.UpdateWorkout(c.Request().Context(), request); err != nil {
+	if v := c.Request().Header.Get("If-Match"); v != "" {
+		version, ok := parseETag(v)
+		if !ok {
+			return echo.NewHTTPError(http.StatusPreconditionFailed, "invalid If-Match header")
+		}
+		request.Version = version
+	}
+
+	workout, err := h.workoutService.UpdateWorkout(c.Request().Context(), user.UID, c.Param("id"), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, activity.ErrVersionRequired):
+			return echo.NewHTTPError(http.StatusPreconditionRequired, err.Error())
+		case errors.Is(err, storage.ErrVersionConflict):
+			return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+		case errors.Is(err, activity.ErrInvalidUpdate):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return workoutHTTPError(err)
+	}
+
+	c.Response().Header().Set("ETag", formatETag(workout.Version))
+	return c.JSON(http.StatusOK, mapper.ConvertWorkoutToDTO(*workout))
+}
+
+// formatETag и parseETag переводят версию тренировки в ETag и обратно
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+func parseETag(v string) (int, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+	unquoted, err := strconv.Unquote(v)
+	if err != nil {
+		unquoted = v
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *Handler) GetLaps(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	laps, err := h.workoutService.GetLaps(c.Request().Context(), user.UID, c.Param("id"))
+	if err != nil {
+		return workoutHTTPError(err)
+	}
+
+	result := []*dto.LapDTO{}
+	for i, l := range laps {
+		result = append(result, dto.NewLapDTO(i+1, l))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetSplits(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	unit, splitDistance, err := splitParams(c)
+	if err != nil {
+		return err
+	}
+
+	splits, err := h.workoutService.GetSplits(c.Request().Context(), user.UID, c.Param("id"), splitDistance)
+	if err != nil {
+		return workoutHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, dto.NewSplitsDTO(unit, splits))
+}
+
+// DeleteWorkout переносит тренировку в корзину, откуда её можно восстановить в течение срока хранения
+func (h *Handler) DeleteWorkout(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	if err := h.workoutService.DeleteWorkout(c.Request().Context(), user.UID, c.Param("id")); err != nil {
+		return workoutHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetTrash возвращает удалённые тренировки, которые ещё можно восстановить
+func (h *Handler) GetTrash(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	workouts, err := h.workoutService.GetTrash(c.Request().Context(), user.UID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	result := []*dto.TrashItemDTO{}
+	for _, w := range workouts {
+		result = append(result, mapper.ConvertTrashItemToDTO(w, h.workoutService.RestoreDeadline(w)))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// RestoreWorkout возвращает тренировку из корзины
+func (h *Handler) RestoreWorkout(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	workout, err := h.workoutService.RestoreWorkout(c.Request().Context(), user.UID, c.Param("id"))
+	if err != nil {
+		return workoutHTTPError(err)
+	}
+
+	return c.JSON(http.StatusOK, mapper.ConvertWorkoutToDTO(*workout))
+}
+
+// GetWorkout возвращает сводку тренировки, круги и отрезки (?unit=km|mi|custom&distance=м).
+// С ?points=N добавляется трек, равномерно прореженный до N точек
+func (h *Handler) GetWorkout(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	unit, splitDistance, err := splitParams(c)
+	if err != nil {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, "OK")
+	var points int
+	if v := c.QueryParam("points"); v != "" {
+		points, err = strconv.Atoi(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, activity.ErrInvalidPointsLimit.Error())
+		}
+	}
+
+	detail, err := h.workoutService.GetWorkoutDetail(c.Request().Context(), user.UID, c.Param("id"), activity.DetailOptions{
+		SplitDistance: splitDistance,
+		MaxPoints:     points,
+	})
+	if err != nil {
+		return workoutHTTPError(err)
+	}
+
+	c.Response().Header().Set("ETag", formatETag(detail.Workout.Version))
+	return c.JSON(http.StatusOK, mapper.ConvertWorkoutDetailToDTO(unit, detail))
+}
+
+// splitParams разбирает параметры отрезков ?unit=km|mi|custom&distance=м
+func splitParams(c echo.Context) (string, uint32, error) {
+	unit := c.QueryParam("unit")
+	var custom float64
+	if v := c.QueryParam("distance"); v != "" {
+		d, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", 0, echo.NewHTTPError(http.StatusBadRequest, "distance must be a number of meters")
+		}
+		custom = d
+	}
+
+	splitDistance, err := activity.SplitDistance(unit, custom)
+	if err != nil {
+		return "", 0, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if unit == "" {
+		unit = activity.SplitUnitKm
+	}
+	return unit, splitDistance, nil
+}
+
+// workoutHTTPError переводит ошибки доступа к тренировке в HTTP-ответ
+func workoutHTTPError(err error) error {
+	switch {
+	case errors.Is(err, activity.ErrInvalidWorkoutID),
+		errors.Is(err, activity.ErrInvalidPointsLimit):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, storage.ErrWorkoutNotFound),
+		errors.Is(err, activity.ErrOriginalNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	default:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetOriginal отдаёт исходный файл тренировки в том виде, в котором он был загружен
+func (h *Handler) GetOriginal(c echo.Context) error {
+	user, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	name, data, err := h.workoutService.GetOriginal(c.Request().Context(), user.UID, c.Param("id"))
+	if err != nil {
+		return workoutHTTPError(err)
+	}
+
+	var contentType string
+	switch activity.DetectFormat(data) {
+	case activity.FormatFIT:
+		contentType = "application/vnd.ant.fit"
+	case activity.FormatGPX:
+		contentType = "application/gpx+xml"
+	case activity.FormatTCX:
+		contentType = "application/vnd.garmin.tcx+xml"
+	default:
+		contentType = echo.MIMEOctetStream
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	return c.Blob(http.StatusOK, contentType, data)
 }
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"workout/internal/controller/mapper"
+	"workout/internal/dto"
+	"workout/internal/service/activity"
+	"workout/internal/service/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Reprocess заново разбирает сохранённые исходные файлы одного пользователя (?user_id=)
+// или всех пользователей и возвращает отчёт об изменениях (?dry_run=true - без сохранения)
+func (h *Handler) Reprocess(c echo.Context) error {
+	var dryRun bool
+	if v := c.QueryParam("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dry_run must be a boolean")
+		}
+		dryRun = b
+	}
+
+	results, err := h.workoutService.Reprocess(c.Request().Context(), activity.ReprocessOptions{
+		UserID: c.QueryParam("user_id"),
+		DryRun: dryRun,
+	})
+	if err != nil {
+		if errors.Is(err, activity.ErrInvalidUserID) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := []*dto.ReprocessResult{}
+	for _, r := range results {
+		response = append(response, mapper.ConvertReprocessResultToDTO(r))
+	}
+
+	return c.JSON(http.StatusOK, dto.NewReprocessResponse(dryRun, response))
+}
+
+// GetUsers возвращает всех пользователей с их ролями
+func (h *Handler) GetUsers(c echo.Context) error {
+	users, err := h.auth.Users(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, users)
+}
+
+// SetUserRoles заменяет роли пользователя. Изменения попадают в его токены при следующем обновлении
+func (h *Handler) SetUserRoles(c echo.Context) error {
+	admin, ok := CurrentUser(c)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	var request dto.UserRolesRequest
+	if err := c.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	user, err := h.auth.SetRoles(c.Request().Context(), admin.UID, c.Param("id"), request)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidUserID), errors.Is(err, auth.ErrInvalidRole):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, auth.ErrCannotDemoteSelf):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, auth.ErrUserNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// GetLoginAttempts возвращает последние неудачные попытки входа пользователя
+func (h *Handler) GetLoginAttempts(c echo.Context) error {
+	attempts, err := h.auth.LoginAttempts(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidUserID) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, attempts)
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"workout/internal/adapters/postgres"
+	"strings"
+	"time"
 	"workout/internal/dto"
 	"workout/internal/service/auth"
 )
@@ -21,11 +22,27 @@ func (h *Handler) Login(e echo.Context) error {
 		return err
 	}
 
+	if h.Limiter != nil {
+		key := "login:account:" + strings.ToLower(request.Login)
+		if err := allow(e, h.Limiter, key, h.LoginAccountRule); err != nil {
+			return err
+		}
+	}
+
+	request.UserAgent = e.Request().UserAgent()
+	request.IP = e.RealIP()
+
 	token, err := h.auth.Login(e.Request().Context(), request)
 	if err != nil {
+		var locked *auth.LockedError
+		if errors.As(err, &locked) {
+			return tooManyRequests(e, time.Until(locked.Until))
+		}
 		if errors.Is(err, auth.ErrInvalidCredentials) {
-			// todo: вынести ошибку в костанту
-			return fmt.Errorf("%s", "invalid credentials")
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid credentials")
+		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
 		}
 		return err
 	}
@@ -33,6 +50,142 @@ func (h *Handler) Login(e echo.Context) error {
 	return e.JSON(http.StatusCreated, token)
 }
 
+// Refresh меняет refresh-токен на новую пару токенов. Старый refresh-токен после этого
+// недействителен, а его повторное предъявление завершает сессию
+func (h *Handler) Refresh(e echo.Context) error {
+	var request dto.RefreshRequest
+	if err := e.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	tokens, err := h.auth.Refresh(e.Request().Context(), request)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.JSON(http.StatusOK, tokens)
+}
+
+// Logout завершает сессию, которой выдан access-токен запроса
+func (h *Handler) Logout(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	if err := h.auth.Logout(e.Request().Context(), user.UID, user.SessionID); err != nil {
+		if errors.Is(err, auth.ErrInvalidSessionID) {
+			return echo.NewHTTPError(http.StatusBadRequest, "token is not bound to a session")
+		}
+		// Повторный выход из уже завершённой сессии - не ошибка
+		if !errors.Is(err, auth.ErrSessionNotFound) {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// GetSessions возвращает активные сессии пользователя; текущая помечена current
+func (h *Handler) GetSessions(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	sessions, err := h.auth.Sessions(e.Request().Context(), user.UID, user.SessionID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession завершает сессию пользователя по id, например на потерянном устройстве
+func (h *Handler) RevokeSession(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	if err := h.auth.RevokeSession(e.Request().Context(), user.UID, e.Param("id")); err != nil {
+		if errors.Is(err, auth.ErrInvalidSessionID) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// CreateAccessToken выпускает персональный токен доступа. Токен есть только в этом ответе
+func (h *Handler) CreateAccessToken(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	var request dto.CreateAccessTokenRequest
+	if err := e.Bind(&request); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	token, err := h.auth.CreateAccessToken(e.Request().Context(), user.UID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidTokenName), errors.Is(err, auth.ErrInvalidTokenExpiry),
+			errors.Is(err, auth.ErrInvalidScope):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, auth.ErrScopeNotAllowed):
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.JSON(http.StatusCreated, token)
+}
+
+// GetAccessTokens возвращает персональные токены пользователя без самих токенов
+func (h *Handler) GetAccessTokens(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	tokens, err := h.auth.AccessTokens(e.Request().Context(), user.UID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.JSON(http.StatusOK, tokens)
+}
+
+// RevokeAccessToken отзывает персональный токен пользователя по id
+func (h *Handler) RevokeAccessToken(e echo.Context) error {
+	user, ok := CurrentUser(e)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+	}
+
+	if err := h.auth.RevokeAccessToken(e.Request().Context(), user.UID, e.Param("id")); err != nil {
+		if errors.Is(err, auth.ErrInvalidAccessTokenID) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, auth.ErrAccessTokenNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
 func (h *Handler) Register(e echo.Context) error {
 	var request dto.RegisterRequest
 
@@ -46,8 +199,8 @@ func (h *Handler) Register(e echo.Context) error {
 
 	userID, err := h.auth.RegisterNewUser(e.Request().Context(), request)
 	if err != nil {
-		if errors.Is(err, auth.ErrInvalidCredentials) {
-			return fmt.Errorf("%s", "user already exists")
+		if errors.Is(err, auth.ErrUserExists) {
+			return echo.NewHTTPError(http.StatusConflict, "user already exists")
 		}
 
 		return err
@@ -56,21 +209,70 @@ func (h *Handler) Register(e echo.Context) error {
 	return e.JSON(http.StatusCreated, userID)
 }
 
-func (h *Handler) IsAdmin(e echo.Context) error {
-	var request int64
+// VerifyEmail подтверждает почту. GET - переход по ссылке из письма (?token=),
+// POST - тот же токен из фронтенда в теле запроса
+func (h *Handler) VerifyEmail(e echo.Context) error {
+	request := dto.TokenRequest{Token: e.QueryParam("token")}
+	if e.Request().Method == http.MethodPost {
+		if err := e.Bind(&request); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	if err := h.auth.VerifyEmail(e.Request().Context(), request.Token); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.NoContent(http.StatusNoContent)
+}
+
+// ResendVerification повторно отправляет письмо подтверждения.
+// Ответ одинаковый для любого адреса, чтобы не раскрывать, кто зарегистрирован
+func (h *Handler) ResendVerification(e echo.Context) error {
+	var request dto.EmailRequest
+	if err := e.Bind(&request); err != nil || request.Email == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "email is required")
+	}
+
+	if err := h.auth.ResendVerification(e.Request().Context(), request.Email); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.NoContent(http.StatusAccepted)
+}
+
+// ForgotPassword отправляет ссылку сброса пароля, если адрес зарегистрирован
+func (h *Handler) ForgotPassword(e echo.Context) error {
+	var request dto.EmailRequest
+	if err := e.Bind(&request); err != nil || request.Email == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "email is required")
+	}
+
+	if err := h.auth.ForgotPassword(e.Request().Context(), request.Email); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return e.NoContent(http.StatusAccepted)
+}
+
+// ResetPassword задаёт новый пароль по токену из письма и завершает все сессии пользователя
+func (h *Handler) ResetPassword(e echo.Context) error {
+	var request dto.ResetPasswordRequest
 	if err := e.Bind(&request); err != nil {
-		return err
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
 
-	isAdmin, err := h.auth.IsAdmin(e.Request().Context(), request)
-	if err != nil {
-		if errors.Is(err, postgres.ErrUserNotFound) {
-			return fmt.Errorf("%s", "user not found")
+	if err := h.auth.ResetPassword(e.Request().Context(), request); err != nil {
+		if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrInvalidPassword) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
-		return err
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return e.JSON(http.StatusCreated, isAdmin)
+	return e.NoContent(http.StatusNoContent)
 }
 
 func validateLogin(req dto.LoginRequest) error {
@@ -90,3 +292,9 @@ func validateRegister(req dto.RegisterRequest) error {
 
 	return nil
 }
+
+// JWKS отдаёт открытые ключи, которыми другие сервисы проверяют наши access-токены
+func (h *Handler) JWKS(e echo.Context) error {
+	e.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return e.JSON(http.StatusOK, h.auth.JWKS())
+}
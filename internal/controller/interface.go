@@ -14,5 +14,5 @@ type Activity interface {
 type Auth interface {
 	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
 	RegisterNewUser(ctx context.Context, req dto.RegisterRequest) (int64, error)
-	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	IsAdmin(ctx context.Context, userID string) (bool, error)
 }
@@ -1,8 +1,10 @@
 package mapper
 
 import (
+	"time"
 	"workout/internal/dto"
 	"workout/internal/entity"
+	"workout/internal/service/activity"
 	"workout/internal/utils"
 )
 
@@ -22,5 +24,64 @@ func ConvertWorkoutToDTO(w entity.Workout) *dto.WorkoutDTO {
 		AvgCadence:   w.AvgCadence,
 		SportType:    w.SportType,
 		Calories:     w.Calories,
+		Version:      w.Version,
+	}
+}
+
+func ConvertUploadResultToDTO(r *activity.UploadResult) *dto.UploadResult {
+	result := &dto.UploadResult{
+		File:            r.File,
+		Status:          r.Status,
+		Preview:         r.Preview,
+		DuplicateOfFile: r.DuplicateOfFile,
+	}
+	if r.Workout != nil {
+		result.Workout = ConvertWorkoutToDTO(*r.Workout)
+	}
+	if r.Err != nil {
+		result.Error = r.Err.Error()
+	}
+	if !r.DuplicateOf.IsNil() {
+		id := r.DuplicateOf
+		result.DuplicateOf = &id
+	}
+	return result
+}
+
+func ConvertReprocessResultToDTO(r *activity.ReprocessResult) *dto.ReprocessResult {
+	result := &dto.ReprocessResult{
+		WorkoutID:     r.WorkoutID,
+		UserID:        r.UserID,
+		Status:        r.Status,
+		Changes:       r.Changes,
+		PointsChanged: r.PointsChanged,
+		LapsChanged:   r.LapsChanged,
+	}
+	if r.Err != nil {
+		result.Error = r.Err.Error()
+	}
+	return result
+}
+
+func ConvertWorkoutDetailToDTO(unit string, d *activity.WorkoutDetail) *dto.WorkoutDetailDTO {
+	result := &dto.WorkoutDetailDTO{
+		Summary: ConvertWorkoutToDTO(*d.Workout),
+		Laps:    make([]*dto.LapDTO, 0, len(d.Laps)),
+		Splits:  dto.NewSplitsDTO(unit, d.Splits),
+	}
+	for i, l := range d.Laps {
+		result.Laps = append(result.Laps, dto.NewLapDTO(i+1, l))
+	}
+	for _, p := range d.Points {
+		result.Points = append(result.Points, dto.NewTrackPointDTO(p))
+	}
+	return result
+}
+
+func ConvertTrashItemToDTO(w entity.Workout, restoreUntil time.Time) *dto.TrashItemDTO {
+	return &dto.TrashItemDTO{
+		WorkoutDTO:   ConvertWorkoutToDTO(w),
+		DeletedAt:    w.DeletedAt.Format(time.RFC3339),
+		RestoreUntil: restoreUntil.Format(time.RFC3339),
 	}
 }
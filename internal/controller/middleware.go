@@ -1,20 +1,35 @@
 package controller
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"workout/internal/adapters/ratelimit"
+	"workout/internal/entity"
+	jwtlib "workout/internal/lib/jwt"
+	"workout/internal/service/auth"
 )
 
-type UserClaims struct {
-	UID   string   `json:"uid"`
-	Roles []string `json:"roles,omitempty"`
-	jwt.RegisteredClaims
+// UserClaims - содержимое access-токена
+type UserClaims = jwtlib.UserClaims
+
+// AccessTokenVerifier проверяет персональные токены доступа
+type AccessTokenVerifier interface {
+	AuthenticateAccessToken(ctx context.Context, token string) (*UserClaims, error)
 }
 
-func JWTMiddleware(secret []byte) echo.MiddlewareFunc {
+// JWTMiddleware проверяет access-токен из заголовка Authorization ключами keys.
+// Если задан tokens, вместо JWT принимается и персональный токен доступа (с префиксом ahp_);
+// какие маршруты он открывает, определяет RequireScope
+func JWTMiddleware(keys *jwtlib.KeySet, tokens AccessTokenVerifier) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			h := c.Request().Header.Get("Authorization")
@@ -23,31 +38,126 @@ func JWTMiddleware(secret []byte) echo.MiddlewareFunc {
 			}
 			tokenStr := strings.TrimSpace(h[len("Bearer "):])
 
-			claims := new(UserClaims)
-			token, err := jwt.ParseWithClaims(
-				tokenStr, claims,
-				func(t *jwt.Token) (any, error) {
-					if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-						return nil, echo.NewHTTPError(http.StatusUnauthorized, "alg mismatch")
+			if strings.HasPrefix(tokenStr, entity.AccessTokenPrefix) {
+				if tokens == nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "personal access tokens are not accepted here")
+				}
+				claims, err := tokens.AuthenticateAccessToken(c.Request().Context(), tokenStr)
+				if err != nil {
+					if errors.Is(err, auth.ErrInvalidAccessToken) {
+						return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 					}
-					return secret, nil
-				},
-				jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
-				jwt.WithExpirationRequired(), // требуем exp
-			)
-			if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+				}
+
+				c.Set(ctxKeyClaims, claims)
+				c.Set(ctxKeySub, claims.Subject)
+				return next(c)
+			}
+
+			claims := new(UserClaims)
+			if err := keys.Parse(tokenStr, claims); err != nil {
 				if errors.Is(err, jwt.ErrTokenExpired) {
 					return echo.NewHTTPError(http.StatusUnauthorized, "token expired")
 				}
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 			}
-			if !token.Valid {
+			if claims.UID == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
 			}
 
-			c.Set("claims", claims)
-			c.Set("sub", claims.Subject)
+			c.Set(ctxKeyClaims, claims)
+			c.Set(ctxKeySub, claims.Subject)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope ограничивает запросы с персональным токеном доступа: такой запрос проходит,
+// только если у токена есть хотя бы одна из областей scopes. На access-токены сессий
+// ограничение не действует
+func RequireScope(scopes ...entity.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := CurrentUser(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+			}
+			if user.TokenID == "" {
+				return next(c)
+			}
+			for _, s := range user.Scopes {
+				if slices.Contains(scopes, s) {
+					return next(c)
+				}
+			}
+			return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("one of token scopes %v required", scopes))
+		}
+	}
+}
+
+// DenyAccessTokens закрывает маршрут для персональных токенов доступа: пройти можно
+// только с access-токеном сессии, полученным после входа по паролю
+func DenyAccessTokens() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := CurrentUser(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+			}
+			if user.TokenID != "" {
+				return echo.NewHTTPError(http.StatusForbidden, "personal access tokens are not allowed here")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequirePermission пропускает пользователей, роли которых дают право perm
+func RequirePermission(perm entity.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := CurrentUser(c)
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "no auth context")
+			}
+			if !entity.HasPermission(user.RoleList(), perm) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("permission %s required", perm))
+			}
 			return next(c)
 		}
 	}
 }
+
+// RateLimit ограничивает частоту запросов с одного IP. name разделяет корзины разных маршрутов
+func RateLimit(limiter ratelimit.Limiter, name string, rule ratelimit.Rule) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := allow(c, limiter, name+":ip:"+c.RealIP(), rule); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// allow забирает токен из корзины key и отвечает 429, если она пуста.
+// Недоступное хранилище лимитов не должно закрывать вход, поэтому его ошибки только логируются
+func allow(c echo.Context, limiter ratelimit.Limiter, key string, rule ratelimit.Rule) error {
+	allowed, retryAfter, err := limiter.Allow(c.Request().Context(), key, rule)
+	if err != nil {
+		c.Logger().Error("rate limit: ", err)
+		return nil
+	}
+	if !allowed {
+		return tooManyRequests(c, retryAfter)
+	}
+	return nil
+}
+
+// tooManyRequests отвечает 429 с заголовком Retry-After в целых секундах
+func tooManyRequests(c echo.Context, retryAfter time.Duration) error {
+	seconds := max(int64(math.Ceil(retryAfter.Seconds())), 1)
+	c.Response().Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	return echo.NewHTTPError(http.StatusTooManyRequests, "too many requests, retry later")
+}
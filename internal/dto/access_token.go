@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+// CreateAccessTokenRequest - новый персональный токен доступа.
+// ExpiresInDays == 0 - бессрочный токен
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// AccessTokenDTO - персональный токен в списке токенов пользователя, без самого токена
+type AccessTokenDTO struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // начало токена, по которому его можно узнать
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedAccessTokenDTO - только что созданный токен. Token показывается один раз,
+// на сервере хранится лишь его хэш
+type CreatedAccessTokenDTO struct {
+	AccessTokenDTO
+	Token string `json:"token"`
+}
@@ -1,12 +1,22 @@
 package dto
 
+import "time"
+
 type LoginRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+
+	// Заполняются контроллером и сохраняются в сессии устройства
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
+// LoginResponse - пара токенов. Access-токен живёт ExpiresIn секунд,
+// refresh-токен одноразовый: /auth/refresh меняет его на новую пару
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 type RegisterRequest struct {
@@ -14,3 +24,26 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 	Username string `json:"username"`
 }
+
+// TokenRequest - токен из ссылки в письме
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// LoginAttemptDTO - неудачная попытка входа из журнала: invalid_password или locked
+type LoginAttemptDTO struct {
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
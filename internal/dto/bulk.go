@@ -0,0 +1,48 @@
+package dto
+
+import "github.com/gofrs/uuid/v5"
+
+const (
+	UploadStatusOK        = "ok"
+	UploadStatusError     = "error"
+	UploadStatusDuplicate = "duplicate"
+	UploadStatusMerged    = "merged"
+)
+
+// UploadResult - результат обработки одного файла из пакетной загрузки
+type UploadResult struct {
+	File            string      `json:"file"`                        // имя файла; для архивов - "archive.zip/entry.fit"
+	Status          string      `json:"status"`                      // ok, error, duplicate или merged
+	Workout         *WorkoutDTO `json:"workout,omitempty"`           // сохранённая тренировка с её ID
+	Preview         *UploadFile `json:"preview,omitempty"`           // разобранная тренировка без сохранения (dry_run=true)
+	Error           string      `json:"error,omitempty"`             // причина ошибки разбора
+	DuplicateOf     *uuid.UUID  `json:"duplicate_of,omitempty"`      // уже сохранённая тренировка, которую повторяет файл
+	DuplicateOfFile string      `json:"duplicate_of_file,omitempty"` // файл из этой же загрузки с тем же содержимым или той же тренировкой
+}
+
+// UploadResponse - ответ на пакетную загрузку файлов
+type UploadResponse struct {
+	Results    []*UploadResult `json:"results"`
+	Total      int             `json:"total"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	Duplicates int             `json:"duplicates"`
+	Merged     int             `json:"merged"`
+}
+
+func NewUploadResponse(results []*UploadResult) *UploadResponse {
+	resp := &UploadResponse{Results: results, Total: len(results)}
+	for _, r := range results {
+		switch r.Status {
+		case UploadStatusOK:
+			resp.Succeeded++
+		case UploadStatusError:
+			resp.Failed++
+		case UploadStatusDuplicate:
+			resp.Duplicates++
+		case UploadStatusMerged:
+			resp.Merged++
+		}
+	}
+	return resp
+}
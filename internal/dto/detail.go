@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+// TrackPointDTO - точка трека в привычных единицах
+type TrackPointDTO struct {
+	Time        string   `json:"time"`                  // время точки в формате RFC3339
+	Latitude    *float64 `json:"latitude,omitempty"`    // широта в градусах
+	Longitude   *float64 `json:"longitude,omitempty"`   // долгота в градусах
+	Elevation   *float64 `json:"elevation,omitempty"`   // высота в метрах
+	Distance    float64  `json:"distance"`              // пройденная дистанция в метрах
+	Speed       float64  `json:"speed"`                 // скорость в м/с
+	HeartRate   uint8    `json:"heart_rate,omitempty"`  // пульс
+	Cadence     uint16   `json:"cadence,omitempty"`     // каденс
+	Power       uint16   `json:"power,omitempty"`       // мощность
+	Temperature int8     `json:"temperature,omitempty"` // температура
+}
+
+func NewTrackPointDTO(r entity.RecordData) *TrackPointDTO {
+	point := &TrackPointDTO{
+		Time:        r.Timestamp.Format(time.RFC3339),
+		Distance:    roundMeters(float64(r.Distance) / 100.0),
+		Speed:       float64(r.Speed) / 1000.0,
+		HeartRate:   r.HeartRate,
+		Cadence:     r.Cadence,
+		Power:       r.Power,
+		Temperature: r.Temperature,
+	}
+
+	if r.PositionLat != 0 || r.PositionLon != 0 {
+		lat, lon := utils.SemicirclesToDegrees(r.PositionLat), utils.SemicirclesToDegrees(r.PositionLon)
+		point.Latitude, point.Longitude = &lat, &lon
+	}
+	if r.Altitude != 0 {
+		elevation := roundMeters(utils.AltitudeToMeters(r.Altitude))
+		point.Elevation = &elevation
+	}
+
+	return point
+}
+
+// WorkoutDetailDTO - полная информация о тренировке
+type WorkoutDetailDTO struct {
+	Summary *WorkoutDTO      `json:"summary"`          // сводка тренировки
+	Laps    []*LapDTO        `json:"laps"`             // круги, размеченные устройством
+	Splits  *SplitsDTO       `json:"splits"`           // отрезки по 1 км / 1 миле / своей дистанции
+	Points  []*TrackPointDTO `json:"points,omitempty"` // прореженный трек (?points=N)
+}
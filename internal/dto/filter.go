@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Поля сортировки списка тренировок
+const (
+	SortByDate         = "date"
+	SortByDistance     = "distance"
+	SortByDuration     = "duration"
+	SortByAvgPace      = "avg_pace"
+	SortByAvgHeartRate = "avg_heart_rate"
+	SortByCalories     = "calories"
+)
+
+// WorkoutFilter - параметры выборки списка тренировок пользователя
+type WorkoutFilter struct {
+	StartDate   time.Time     // тренировки начиная с этой даты включительно
+	EndDate     time.Time     // тренировки по эту дату включительно
+	SportType   string        // тип спорта: "Бег", "Велосипед" ...
+	MinDistance float64       // минимальная дистанция в километрах
+	MaxDistance float64       // максимальная дистанция в километрах
+	MinDuration time.Duration // минимальная продолжительность
+	MaxDuration time.Duration // максимальная продолжительность
+	Query       string        // поиск по названию и описанию
+	SortBy      string        // одно из SortBy*, по умолчанию SortByDate
+	SortAsc     bool          // по умолчанию сортировка по убыванию
+	Limit       int           // размер страницы
+	Offset      int           // смещение (нельзя использовать вместе с курсором)
+	Cursor      string        // курсор следующей страницы из предыдущего ответа
+	After       *WorkoutCursor
+}
+
+// WorkoutCursor - позиция последней тренировки на странице для keyset-пагинации
+type WorkoutCursor struct {
+	Value string    // значение поля сортировки
+	ID    uuid.UUID // id тренировки, чтобы различать одинаковые значения
+}
+
+// WorkoutListDTO - страница списка тренировок
+type WorkoutListDTO struct {
+	Workouts   []*WorkoutDTO `json:"workouts"`
+	NextCursor string        `json:"next_cursor,omitempty"` // пусто - это последняя страница
+}
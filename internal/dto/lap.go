@@ -0,0 +1,41 @@
+package dto
+
+import (
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+type LapDTO struct {
+	Number       int    `json:"number"`         // порядковый номер круга, начиная с 1
+	StartTime    string `json:"start_time"`     // время начала круга в формате RFC3339
+	Duration     string `json:"duration"`       // активное время круга в формате ММ:СС или ЧЧ:ММ:СС
+	ElapsedTime  string `json:"elapsed_time"`   // общее время круга с паузами
+	Distance     string `json:"distance"`       // дистанция круга в километрах с 2 знаками после запятой
+	AvgPace      string `json:"avg_pace"`       // средний темп в формате "М:СС"
+	AvgHeartRate int    `json:"avg_heart_rate"` // средний пульс за круг
+	MaxHeartRate int    `json:"max_heart_rate"` // максимальный пульс за круг
+	AvgCadence   uint16 `json:"avg_cadence"`    // средний каденс
+	MaxCadence   uint16 `json:"max_cadence"`    // максимальный каденс
+	Calories     uint16 `json:"calories"`       // калории за круг
+	Trigger      string `json:"trigger"`        // способ завершения круга: manual, distance, time, session_end ...
+}
+
+func NewLapDTO(number int, lap entity.LapData) *LapDTO {
+	pace := utils.CalculatePace(lap.TotalDistance, lap.TotalTimerTime)
+
+	return &LapDTO{
+		Number:       number,
+		StartTime:    lap.StartTime.Format(time.RFC3339),
+		Duration:     utils.SecondsToHMS(int(lap.TotalTimerTime) / 1000),
+		ElapsedTime:  utils.SecondsToHMS(int(lap.TotalElapsedTime) / 1000),
+		Distance:     convertDistance(lap.TotalDistance),
+		AvgPace:      utils.FormatPace(pace),
+		AvgHeartRate: int(lap.AvgHeartRate),
+		MaxHeartRate: int(lap.MaxHeartRate),
+		AvgCadence:   lap.AvgCadence,
+		MaxCadence:   lap.MaxCadence,
+		Calories:     lap.TotalCalories,
+		Trigger:      lap.LapTrigger.String(),
+	}
+}
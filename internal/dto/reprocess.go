@@ -0,0 +1,56 @@
+package dto
+
+import "github.com/gofrs/uuid/v5"
+
+const (
+	ReprocessStatusUpdated   = "updated"
+	ReprocessStatusUnchanged = "unchanged"
+	ReprocessStatusSkipped   = "skipped"
+	ReprocessStatusError     = "error"
+)
+
+// FieldChange - изменение поля тренировки после повторного разбора файла
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ReprocessResult - результат повторного разбора исходного файла одной тренировки
+type ReprocessResult struct {
+	WorkoutID     uuid.UUID     `json:"workout_id"`
+	UserID        uuid.UUID     `json:"user_id"`
+	Status        string        `json:"status"` // updated, unchanged, skipped или error
+	Changes       []FieldChange `json:"changes,omitempty"`
+	PointsChanged int           `json:"points_changed,omitempty"`
+	LapsChanged   int           `json:"laps_changed,omitempty"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// ReprocessResponse - отчёт о повторном разборе исходных файлов
+type ReprocessResponse struct {
+	DryRun    bool               `json:"dry_run"`
+	Results   []*ReprocessResult `json:"results"`
+	Total     int                `json:"total"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Skipped   int                `json:"skipped"`
+	Failed    int                `json:"failed"`
+}
+
+func NewReprocessResponse(dryRun bool, results []*ReprocessResult) *ReprocessResponse {
+	resp := &ReprocessResponse{DryRun: dryRun, Results: results, Total: len(results)}
+	for _, r := range results {
+		switch r.Status {
+		case ReprocessStatusUpdated:
+			resp.Updated++
+		case ReprocessStatusUnchanged:
+			resp.Unchanged++
+		case ReprocessStatusSkipped:
+			resp.Skipped++
+		case ReprocessStatusError:
+			resp.Failed++
+		}
+	}
+	return resp
+}
@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"math"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+const kmPerMile = 1.609344
+
+type SplitDTO struct {
+	Number          int     `json:"number"`           // порядковый номер отрезка, начиная с 1
+	Distance        string  `json:"distance"`         // дистанция отрезка в километрах с 2 знаками после запятой
+	Duration        string  `json:"duration"`         // время отрезка в формате ММ:СС или ЧЧ:ММ:СС
+	Pace            string  `json:"pace"`             // темп в формате "М:СС" на километр (или милю для unit=mi)
+	AvgHeartRate    int     `json:"avg_heart_rate"`   // средний пульс
+	MaxHeartRate    int     `json:"max_heart_rate"`   // максимальный пульс
+	AvgCadence      uint16  `json:"avg_cadence"`      // средний каденс
+	ElevationGain   float64 `json:"elevation_gain"`   // набор высоты в метрах
+	ElevationLoss   float64 `json:"elevation_loss"`   // сброс высоты в метрах
+	ElevationChange float64 `json:"elevation_change"` // изменение высоты за отрезок в метрах
+}
+
+type SplitsDTO struct {
+	Unit          string      `json:"unit"`           // km, mi или custom
+	SplitDistance float64     `json:"split_distance"` // длина отрезка в метрах
+	Splits        []*SplitDTO `json:"splits"`
+	FirstHalf     string      `json:"first_half"`  // время первой половины дистанции
+	SecondHalf    string      `json:"second_half"` // время второй половины дистанции
+	SplitType     string      `json:"split_type"`  // negative, positive или even
+}
+
+func NewSplitsDTO(unit string, s *entity.Splits) *SplitsDTO {
+	result := &SplitsDTO{
+		Unit:          unit,
+		SplitDistance: float64(s.SplitDistance) / 100.0,
+		Splits:        make([]*SplitDTO, 0, len(s.Splits)),
+		FirstHalf:     utils.SecondsToHMS(int(s.FirstHalf) / 1000),
+		SecondHalf:    utils.SecondsToHMS(int(s.SecondHalf) / 1000),
+		SplitType:     s.SplitType,
+	}
+
+	for _, sp := range s.Splits {
+		// Темп в мин/км, для миль пересчитываем в мин/милю
+		pace := utils.CalculatePace(sp.Distance, sp.Duration)
+		if unit == "mi" {
+			pace *= kmPerMile
+		}
+
+		result.Splits = append(result.Splits, &SplitDTO{
+			Number:          sp.Number,
+			Distance:        convertDistance(sp.Distance),
+			Duration:        utils.SecondsToHMS(int(math.Round(float64(sp.Duration) / 1000))),
+			Pace:            utils.FormatPaceMMSS(int(math.Round(pace * 60))),
+			AvgHeartRate:    int(sp.AvgHeartRate),
+			MaxHeartRate:    int(sp.MaxHeartRate),
+			AvgCadence:      sp.AvgCadence,
+			ElevationGain:   roundMeters(sp.ElevationGain),
+			ElevationLoss:   roundMeters(sp.ElevationLoss),
+			ElevationChange: roundMeters(sp.ElevationGain - sp.ElevationLoss),
+		})
+	}
+
+	return result
+}
+
+func roundMeters(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,8 @@
+package dto
+
+// TrashItemDTO - тренировка в корзине
+type TrashItemDTO struct {
+	*WorkoutDTO
+	DeletedAt    string `json:"deleted_at"`    // время удаления в формате RFC3339
+	RestoreUntil string `json:"restore_until"` // до какого момента тренировку можно восстановить
+}
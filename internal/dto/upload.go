@@ -14,12 +14,13 @@ type UploadFile struct {
 	AvgPace      string              `json:"avg_pace" db:"avg_pace"`             // средний темп в формате "М:СС мин/км" Рассчитывается на основе дистанции и активного времени
 	AvgHeartRate int                 `json:"avg_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
 	MaxHeartRate int                 `json:"max_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
-	AvgCadence   uint8               `json:"avg_cadence"`                        // Средний каденс
+	AvgCadence   uint16              `json:"avg_cadence"`                        // Средний каденс
 	SportType    string              `json:"sport_type" db:"sport_type"`         // тип спорта в человеко-читаемом формате Примеры: "Бег", "Велосипед", "Плавание", "Ходьба"
 	Calories     uint16              `json:"calories" db:"calories"`             // Каллории
 	CreatedAt    time.Time           `json:"-" db:"created_at"`                  // время создания записи в системе Автоматически устанавливается при добавлении тренировки
 	UpdatedAt    time.Time           `json:"-" db:"updated_at"`                  // время последнего обновления записи
 	RecordData   []entity.RecordData `json:"record_data" db:"record_data"`
+	Laps         []entity.LapData    `json:"laps"`
 }
 
 func NewUploadFile(data *entity.ActivityData) *UploadFile {
@@ -39,6 +40,8 @@ func NewUploadFile(data *entity.ActivityData) *UploadFile {
 		AvgCadence:   data.AvgCadence,                                     // Средний каденс
 		SportType:    utils.GetSportName(data.Sport),                      // Добавляем информацию о типе спорта и способе запуска
 		Calories:     data.TotalCalories,
+		RecordData:   data.Records,
+		Laps:         data.Laps,
 	}
 
 	return workout
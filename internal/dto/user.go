@@ -1 +1,14 @@
 package dto
+
+// UserDTO - пользователь в списке администратора
+type UserDTO struct {
+	ID    string   `json:"id"`
+	Email string   `json:"email"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+// UserRolesRequest - новый набор ролей пользователя, заменяет текущий
+type UserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
@@ -1,7 +1,9 @@
 package dto
 
 import (
+	"fmt"
 	"github.com/gofrs/uuid/v5"
+	"math"
 	"time"
 	"workout/internal/entity"
 	"workout/internal/utils"
@@ -18,11 +20,15 @@ type WorkoutDTO struct {
 	AvgPace      string    `json:"avg_pace" db:"avg_pace"`             // средний темп в формате "М:СС мин/км" Рассчитывается на основе дистанции и активного времени
 	AvgHeartRate int       `json:"avg_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
 	MaxHeartRate int       `json:"max_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
-	AvgCadence   uint8     `json:"avg_cadence"`                        // Средний каденс
+	AvgCadence   uint16    `json:"avg_cadence"`                        // Средний каденс
 	SportType    string    `json:"sport_type" db:"sport_type"`         // тип спорта в человеко-читаемом формате Примеры: "Бег", "Велосипед", "Плавание", "Ходьба"
 	Calories     uint16    `json:"calories" db:"calories"`             // Каллории
 	CreatedAt    time.Time `json:"-" db:"created_at"`                  // время создания записи в системе Автоматически устанавливается при добавлении тренировки
 	UpdatedAt    time.Time `json:"-" db:"updated_at"`                  // время последнего обновления записи
+	Version      int       `json:"version"`                            // версия для оптимистичной блокировки (ETag)
+
+	RecordData []entity.RecordData `json:"record_data,omitempty"` // посекундные данные из загруженного файла
+	Laps       []entity.LapData    `json:"laps,omitempty"`        // круги из загруженного файла
 }
 
 func NewWorkoutDTO(data *entity.ActivityData) *WorkoutDTO {
@@ -47,6 +53,38 @@ func NewWorkoutDTO(data *entity.ActivityData) *WorkoutDTO {
 	return workout
 }
 
+// NewWorkoutFromActivity формирует тренировку для сохранения из данных загруженного файла
+func NewWorkoutFromActivity(userID uuid.UUID, data *entity.ActivityData) *entity.Workout {
+	// Вычисляем темп в секундах на километр
+	pace := utils.CalculatePace(data.TotalDistance, data.TotalTimerTime)
+	now := time.Now()
+
+	date := data.LocalTimestamp
+	if date.IsZero() {
+		date = data.Timestamp
+	}
+	sport := utils.GetSportName(data.Sport)
+
+	return &entity.Workout{
+		UserID:       userID,
+		Name:         fmt.Sprintf("%s %s", sport, date.Format("02.01.2006")), // имя по умолчанию, пользователь может его изменить
+		Date:         date,
+		Duration:     time.Duration(data.TotalTimerTime) * time.Millisecond,
+		Distance:     convertDistance(data.TotalDistance),
+		AvgPace:      int(math.Round(pace * 60)),
+		AvgHeartRate: int(data.AvgHeartRate),
+		MaxHeartRate: int(data.MaxHeartRate),
+		AvgCadence:   data.AvgCadence,
+		SportType:    sport,
+		Calories:     data.TotalCalories,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		StartTime:    data.Timestamp,
+		RecordData:   data.Records,
+		Laps:         data.Laps,
+	}
+}
+
 func WorkoutMapper(w WorkoutDTO) (*entity.Workout, error) {
 	// Конвертируем темп
 	pace, err := utils.ParsePaceMMSS(w.AvgPace)
@@ -78,6 +116,8 @@ func WorkoutMapper(w WorkoutDTO) (*entity.Workout, error) {
 		Calories:     w.Calories,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
+		RecordData:   w.RecordData,
+		Laps:         w.Laps,
 	}, nil
 }
 
@@ -92,17 +132,26 @@ func ParseHHMMSS(s string) (time.Duration, error) {
 		time.Duration(t.Second())*time.Second, nil
 }
 
+// UpdateWorkout - частичное изменение тренировки (PATCH). nil - поле не меняется
 type UpdateWorkout struct {
-	ID           uuid.UUID
-	Name         *string
-	SportType    *string
-	Duration     *int32
-	Distance     *float32
-	AvgPace      *float32
-	AvgHeartRate *int16
-	MaxHeartRate *int16
-	AvgCadence   *int16
-	Calories     *int32
-	Description  *string
-	DeviceName   *string
+	ID           uuid.UUID `json:"-"`
+	Version      int       `json:"version,omitempty"` // версия, которую видел клиент (или заголовок If-Match)
+	Name         *string   `json:"name"`
+	SportType    *string   `json:"sport_type"`
+	Duration     *int32    `json:"duration"`       // продолжительность в секундах
+	Distance     *float32  `json:"distance"`       // дистанция в километрах
+	AvgPace      *float32  `json:"avg_pace"`       // средний темп в секундах на километр
+	AvgHeartRate *int16    `json:"avg_heart_rate"` // средний пульс
+	MaxHeartRate *int16    `json:"max_heart_rate"` // максимальный пульс
+	AvgCadence   *int16    `json:"avg_cadence"`    // средний каденс
+	Calories     *int32    `json:"calories"`
+	Description  *string   `json:"description"`
+	DeviceName   *string   `json:"device_name"`
+}
+
+// EditsFileData сообщает, меняет ли правка поля, рассчитанные из исходного файла.
+// После такой правки пересборка из файла не должна затирать данные тренировки
+func (u UpdateWorkout) EditsFileData() bool {
+	return u.SportType != nil || u.Duration != nil || u.Distance != nil || u.AvgPace != nil ||
+		u.AvgHeartRate != nil || u.MaxHeartRate != nil || u.AvgCadence != nil || u.Calories != nil
 }
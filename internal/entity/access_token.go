@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// AccessTokenPrefix отличает персональный токен доступа от JWT в заголовке Authorization
+const AccessTokenPrefix = "ahp_"
+
+// Scope - область действия персонального токена доступа. Токен не даёт больше,
+// чем роли владельца: область только сужает права
+type Scope string
+
+const (
+	ScopeWorkoutsRead  Scope = "workouts:read"  // просмотр тренировок и исходных файлов
+	ScopeWorkoutsWrite Scope = "workouts:write" // создание, изменение, удаление и загрузка тренировок
+	ScopeUpload        Scope = "upload"         // только загрузка файлов тренировок, например с устройства синхронизации
+)
+
+// Scopes - все области персональных токенов
+var Scopes = []Scope{ScopeWorkoutsRead, ScopeWorkoutsWrite, ScopeUpload}
+
+// Valid сообщает, что область известна
+func (s Scope) Valid() bool {
+	return slices.Contains(Scopes, s)
+}
+
+// Permission - право роли, без которого область бесполезна; пустое - область доступна всем
+func (s Scope) Permission() Permission {
+	switch s {
+	case ScopeWorkoutsWrite, ScopeUpload:
+		return PermWorkoutsWrite
+	}
+	return ""
+}
+
+// AccessToken - персональный токен доступа для скриптов и устройств синхронизации.
+// Сам токен показывается один раз при создании, хранится только его sha256.
+// Нулевой ExpiresAt - бессрочный токен
+type AccessToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string // начало токена, по которому его узнают в списке
+	Scopes     []Scope
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  time.Time
+}
+
+// Active сообщает, что токен не отозван и не истёк
+func (t *AccessToken) Active(now time.Time) bool {
+	return t.RevokedAt.IsZero() && (t.ExpiresAt.IsZero() || now.Before(t.ExpiresAt))
+}
@@ -18,12 +18,21 @@ type Workout struct {
 	AvgPace      int           `json:"avg_pace" db:"avg_pace"`             // средний темп в формате "М:СС мин/км" Рассчитывается на основе дистанции и активного времени
 	AvgHeartRate int           `json:"avg_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
 	MaxHeartRate int           `json:"max_heart_rate" db:"avg_heart_rate"` // средний пульс за тренировку в ударах в минуту
-	AvgCadence   uint8         `json:"avg_cadence"`                        // Средний каденс
+	AvgCadence   uint16        `json:"avg_cadence"`                        // Средний каденс
 	SportType    string        `json:"sport_type" db:"sport_type"`         // тип спорта в человеко-читаемом формате Примеры: "Бег", "Велосипед", "Плавание", "Ходьба"
 	Calories     uint16        `json:"calories" db:"calories"`             // Каллории
 	CreatedAt    time.Time     `json:"-" db:"created_at"`                  // время создания записи в системе Автоматически устанавливается при добавлении тренировки
 	UpdatedAt    time.Time     `json:"-" db:"updated_at"`                  // время последнего обновления записи
+	StartTime    time.Time     `json:"start_time" db:"start_time"`         // точное время старта из файла (для поиска дубликатов)
+	FileHash     string        `json:"-" db:"file_hash"`                   // sha256 исходного файла
+	OriginalKey  string        `json:"-" db:"original_key"`                // ключ исходного файла в хранилище
+	OriginalName string        `json:"-" db:"original_name"`               // имя, под которым файл был загружен
+	DeletedAt    time.Time     `json:"-" db:"deleted_at"`                  // время удаления в корзину, пустое - не удалена
+	Version      int           `json:"version" db:"version"`               // увеличивается при каждом изменении
+	MergedAt     time.Time     `json:"-" db:"merged_at"`                   // время слияния с дубликатом с другого устройства, пустое - тренировка из одного файла
+	EditedAt     time.Time     `json:"-" db:"edited_at"`                   // время ручной правки полей из файла, пустое - данные совпадают с файлом
 	RecordData   []RecordData  `json:"record_data" db:"record_data"`
+	Laps         []LapData     `json:"laps"`
 }
 
 func convertDistance(distance uint32) string {
@@ -22,8 +22,8 @@ type ActivityData struct {
 	TotalCalories uint16 // Общие калории
 	AvgSpeed      uint16 // Средняя скорость в м/с * 1000
 	MaxSpeed      uint16 // Максимальная скорость
-	AvgCadence    uint8  // Средний каденс
-	MaxCadence    uint8  // Максимальный каденс
+	AvgCadence    uint16 // Средний каденс
+	MaxCadence    uint16 // Максимальный каденс
 	TotalAscent   uint16 // Общий подъем в метрах
 	TotalDescent  uint16 // Общий спуск в метрах
 	AvgPower      uint16 // Средняя мощность (для велосипеда)
@@ -41,36 +41,59 @@ type ActivityData struct {
 	Records []RecordData
 
 	// Круги (Laps)
-	// Laps []LapData
+	Laps []LapData
 }
 
 // RecordData содержит данные одной записи (обычно каждую секунду)
+// Нулевое значение поля означает, что устройство его не записало
 type RecordData struct {
-	Timestamp   time.Time
-	PositionLat int32  // Широта в semicircles
-	PositionLon int32  // Долгота в semicircles
-	Distance    uint32 // Накопленная дистанция в сантиметрах
-	Speed       uint16 // Скорость в м/с * 1000
-	HeartRate   uint8  // Пульс
-	Cadence     uint8  // Каденс
-	Power       uint16 // Мощность
-	Altitude    uint16 // Высота в метрах * 5 + 500
-	Temperature int8   // Температура
+	Timestamp   time.Time `json:"timestamp"`
+	PositionLat int32     `json:"position_lat"` // Широта в semicircles
+	PositionLon int32     `json:"position_lon"` // Долгота в semicircles
+	Distance    uint32    `json:"distance"`     // Накопленная дистанция в сантиметрах
+	Speed       uint16    `json:"speed"`        // Скорость в м/с * 1000
+	HeartRate   uint8     `json:"heart_rate"`   // Пульс
+	Cadence     uint16    `json:"cadence"`      // Каденс
+	Power       uint16    `json:"power"`        // Мощность
+	Altitude    uint16    `json:"altitude"`     // Высота в метрах * 5 + 500
+	Temperature int8      `json:"temperature"`  // Температура
 }
 
 // LapData содержит данные о круге/сегменте
 type LapData struct {
-	Timestamp        time.Time
-	StartTime        time.Time
-	TotalElapsedTime uint32             // Общее время в миллисекундах
-	TotalTimerTime   uint32             // Активное время в миллисекундах
-	TotalDistance    uint32             // Дистанция круга в сантиметрах
-	AvgSpeed         uint16             // Средняя скорость
-	MaxSpeed         uint16             // Максимальная скорость
-	AvgHeartRate     uint8              // Средний пульс
-	MaxHeartRate     uint8              // Максимальный пульс
-	AvgCadence       uint8              // Средний каденс
-	MaxCadence       uint8              // Максимальный каденс
-	TotalCalories    uint16             // Калории за круг
-	LapTrigger       typedef.LapTrigger // Способ завершения круга
+	Timestamp        time.Time          `json:"timestamp"`          // Время окончания круга
+	StartTime        time.Time          `json:"start_time"`         // Время начала круга
+	TotalElapsedTime uint32             `json:"total_elapsed_time"` // Общее время в миллисекундах
+	TotalTimerTime   uint32             `json:"total_timer_time"`   // Активное время в миллисекундах
+	TotalDistance    uint32             `json:"total_distance"`     // Дистанция круга в сантиметрах
+	AvgSpeed         uint16             `json:"avg_speed"`          // Средняя скорость
+	MaxSpeed         uint16             `json:"max_speed"`          // Максимальная скорость
+	AvgHeartRate     uint8              `json:"avg_heart_rate"`     // Средний пульс
+	MaxHeartRate     uint8              `json:"max_heart_rate"`     // Максимальный пульс
+	AvgCadence       uint16             `json:"avg_cadence"`        // Средний каденс
+	MaxCadence       uint16             `json:"max_cadence"`        // Максимальный каденс
+	TotalCalories    uint16             `json:"total_calories"`     // Калории за круг
+	LapTrigger       typedef.LapTrigger `json:"lap_trigger"`        // Способ завершения круга
+}
+
+// SplitData содержит данные одного отрезка фиксированной длины (1 км, 1 миля ...),
+// рассчитанного по посекундным записям
+type SplitData struct {
+	Number        int     // Порядковый номер отрезка, начиная с 1
+	Distance      uint32  // Дистанция отрезка в сантиметрах (последний может быть короче)
+	Duration      uint32  // Время отрезка в миллисекундах
+	AvgHeartRate  uint8   // Средний пульс
+	MaxHeartRate  uint8   // Максимальный пульс
+	AvgCadence    uint16  // Средний каденс
+	ElevationGain float64 // Набор высоты в метрах
+	ElevationLoss float64 // Сброс высоты в метрах
+}
+
+// Splits содержит разбивку тренировки на отрезки и сравнение половин дистанции
+type Splits struct {
+	SplitDistance uint32      // Длина отрезка в сантиметрах
+	Splits        []SplitData // Отрезки по порядку
+	FirstHalf     uint32      // Время первой половины дистанции в миллисекундах
+	SecondHalf    uint32      // Время второй половины дистанции в миллисекундах
+	SplitType     string      // negative, positive или even
 }
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// LoginFailure - причина неудачной попытки входа
+type LoginFailure string
+
+const (
+	LoginUnknownUser     LoginFailure = "unknown_user"
+	LoginInvalidPassword LoginFailure = "invalid_password"
+	LoginLocked          LoginFailure = "locked" // попытка во время блокировки учётной записи
+)
+
+// LoginAttempt - запись журнала неудачных попыток входа.
+// UserID равен uuid.Nil, если адрес не зарегистрирован
+type LoginAttempt struct {
+	ID        int64        `json:"id"`
+	Email     string       `json:"email"`
+	UserID    uuid.UUID    `json:"user_id"`
+	IP        string       `json:"ip"`
+	UserAgent string       `json:"user_agent"`
+	Reason    LoginFailure `json:"reason"`
+	CreatedAt time.Time    `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "slices"
+
+// Role - роль пользователя. У пользователя может быть несколько ролей, например тренер,
+// который сам тренируется, - athlete и coach
+type Role string
+
+const (
+	RoleAthlete Role = "athlete" // загружает и ведёт свои тренировки, роль по умолчанию
+	RoleCoach   Role = "coach"   // видит тренировки подтвердивших связь спортсменов
+	RoleAdmin   Role = "admin"   // управляет пользователями и обслуживанием данных
+)
+
+// Roles - все роли в порядке возрастания прав
+var Roles = []Role{RoleAthlete, RoleCoach, RoleAdmin}
+
+// Valid сообщает, что роль известна
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Permission - действие, которое разрешает одна или несколько ролей.
+// В токене хранятся роли, а права выводятся из них на сервере, поэтому
+// изменение набора прав роли не требует перевыпуска токенов
+type Permission string
+
+const (
+	PermWorkoutsWrite     Permission = "workouts:write"     // загрузка и изменение своих тренировок
+	PermAthletesRead      Permission = "athletes:read"      // просмотр тренировок своих спортсменов
+	PermUsersManage       Permission = "users:manage"       // список пользователей и назначение ролей
+	PermWorkoutsReprocess Permission = "workouts:reprocess" // повторный разбор исходных файлов
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleAthlete: {PermWorkoutsWrite},
+	RoleCoach:   {PermAthletesRead},
+	RoleAdmin:   {PermUsersManage, PermWorkoutsReprocess},
+}
+
+// HasPermission сообщает, что хотя бы одна из ролей разрешает perm
+func HasPermission(roles []Role, perm Permission) bool {
+	for _, r := range roles {
+		if slices.Contains(rolePermissions[r], perm) {
+			return true
+		}
+	}
+	return false
+}
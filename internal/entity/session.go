@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// Session - вход пользователя с одного устройства. Все refresh-токены, выданные этой сессии
+// при ротации, образуют одно семейство: отзыв сессии делает недействительными их все
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"-"`
+	UserAgent  string    `json:"user_agent"`   // User-Agent клиента при входе
+	IP         string    `json:"ip"`           // адрес клиента при входе
+	CreatedAt  time.Time `json:"created_at"`   // время входа
+	LastUsedAt time.Time `json:"last_used_at"` // время последнего обновления токенов
+	ExpiresAt  time.Time `json:"expires_at"`   // после этого refresh-токен не принимается
+	RevokedAt  time.Time `json:"-"`            // время выхода или отзыва, пустое - сессия активна
+}
+
+// Active сообщает, можно ли обновлять токены сессии в момент now
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken - выданный сессии refresh-токен. Сам токен не хранится, только его sha256
+type RefreshToken struct {
+	Hash      string
+	SessionID uuid.UUID
+	CreatedAt time.Time
+	UsedAt    time.Time // время обмена на новую пару токенов, пустое - ещё не использован
+}
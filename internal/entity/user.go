@@ -1,11 +1,17 @@
 package entity
 
+import "time"
+
 type User struct {
-	ID           string `json:"id"`
-	Email        string `json:"email"`
-	Password     string `json:"password"`
-	Name         string `json:"name"`
-	RefreshToken string `json:"refresh_token"`
-	CreatedAt    int64  `json:"created_at"`
-	UpdatedAt    int64  `json:"updated_at"`
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	Password      string    `json:"password"`
+	Name          string    `json:"name"`
+	RefreshToken  string    `json:"refresh_token"`
+	Roles         []Role    `json:"roles"`
+	EmailVerified bool      `json:"email_verified"`
+	FailedLogins  int       `json:"failed_logins"` // неудачных попыток входа подряд
+	LockedUntil   time.Time `json:"locked_until"`  // до этого времени вход заблокирован
+	CreatedAt     int64     `json:"created_at"`
+	UpdatedAt     int64     `json:"updated_at"`
 }
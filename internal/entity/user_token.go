@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// TokenPurpose - назначение одноразового токена из письма
+type TokenPurpose string
+
+const (
+	TokenVerifyEmail   TokenPurpose = "verify_email"   // подтверждение адреса почты
+	TokenResetPassword TokenPurpose = "reset_password" // сброс забытого пароля
+)
+
+// UserToken - одноразовый токен из письма пользователю. Сам токен не хранится, только его sha256
+type UserToken struct {
+	Hash      string
+	UserID    uuid.UUID
+	Purpose   TokenPurpose
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // пустое - ещё не использован
+}
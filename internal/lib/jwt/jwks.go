@@ -0,0 +1,57 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JWK - открытый ключ в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // модуль RSA
+	E   string `json:"e,omitempty"`   // экспонента RSA
+	Crv string `json:"crv,omitempty"` // кривая OKP
+	X   string `json:"x,omitempty"`   // открытый ключ Ed25519
+}
+
+// JWKS - набор открытых ключей для /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые ключи набора, которыми другие сервисы проверяют наши токены.
+// Секреты HS256 не публикуются: с ними токены может проверить только этот сервис
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch key := k.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(key)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	// Ключ подписи первым, остальные в постоянном порядке
+	sort.Slice(set.Keys, func(i, j int) bool {
+		if a, b := set.Keys[i].Kid == ks.signing.ID, set.Keys[j].Kid == ks.signing.ID; a != b {
+			return a
+		}
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
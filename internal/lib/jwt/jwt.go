@@ -6,19 +6,48 @@ import (
 	"workout/internal/entity"
 )
 
-func NewToken(user *entity.User, secret string, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// UserClaims - содержимое access-токена. Выпускается NewToken и читается JWT-мидлварой,
+// поэтому обе стороны используют одну структуру
+type UserClaims struct {
+	UID       string   `json:"uid"`
+	Email     string   `json:"email,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
 
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(duration).Unix()
+	// Заполняются только для запросов с персональным токеном доступа и в JWT не попадают
+	TokenID string         `json:"-"`
+	Scopes  []entity.Scope `json:"-"`
+}
 
-	tokenString, err := token.SignedString([]byte(secret))
-	if err != nil {
-		return "", err
+// RoleList возвращает роли из токена; неизвестные роли пропускаются
+func (c *UserClaims) RoleList() []entity.Role {
+	roles := make([]entity.Role, 0, len(c.Roles))
+	for _, name := range c.Roles {
+		if r := entity.Role(name); r.Valid() {
+			roles = append(roles, r)
+		}
 	}
+	return roles
+}
 
-	return tokenString, nil
+// NewToken выпускает access-токен пользователя. sessionID - сессия устройства,
+// которой выдан токен: по ней выход завершает именно эту сессию
+func NewToken(user *entity.User, sessionID string, keys *KeySet, duration time.Duration) (string, error) {
+	now := time.Now()
+	claims := &UserClaims{
+		UID:       user.ID,
+		Email:     user.Email,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+	for _, r := range user.Roles {
+		claims.Roles = append(claims.Roles, string(r))
+	}
 
+	return keys.Sign(claims)
 }
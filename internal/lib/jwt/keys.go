@@ -0,0 +1,238 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"workout/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minSecretLength - минимальная длина секрета HS256: ключ короче хэша SHA-256 ослабляет подпись
+const minSecretLength = 32
+
+var (
+	ErrNoSigningKey   = errors.New("no jwt signing key configured")
+	ErrWeakSecret     = fmt.Errorf("jwt secret must be at least %d bytes", minSecretLength)
+	ErrUnsupportedKey = errors.New("unsupported jwt key type")
+	ErrUnknownKey     = errors.New("unknown jwt key id")
+)
+
+// Key - ключ подписи или проверки токенов с идентификатором kid
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   any // []byte, *rsa.PrivateKey или ed25519.PrivateKey; nil - ключ только для проверки
+	verify any // []byte, *rsa.PublicKey или ed25519.PublicKey
+}
+
+// NewSecretKey создаёт ключ HS256. Пустой id вычисляется из секрета
+func NewSecretKey(id string, secret []byte) (*Key, error) {
+	if len(secret) < minSecretLength {
+		return nil, ErrWeakSecret
+	}
+	if id == "" {
+		id = keyID(secret)
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+}
+
+// ParseKey разбирает PEM-ключ RSA (RS256) или Ed25519 (EdDSA). Закрытым ключом можно подписывать,
+// открытым - только проверять. Пустой id вычисляется из открытого ключа
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrUnsupportedKey)
+	}
+
+	var (
+		parsed any
+		err    error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: PEM block %q", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k := &Key{ID: id}
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.Method, k.sign, k.verify = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Method, k.verify = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.Method, k.sign, k.verify = jwt.SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Method, k.verify = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, parsed)
+	}
+
+	if k.ID == "" {
+		der, err := x509.MarshalPKIXPublicKey(k.verify)
+		if err != nil {
+			return nil, err
+		}
+		k.ID = keyID(der)
+	}
+	return k, nil
+}
+
+// LoadKeyFile читает PEM-ключ из файла. В spec перед путём можно указать kid: "2024-06=keys/old.pem"
+func LoadKeyFile(spec string) (*Key, error) {
+	id, path, ok := strings.Cut(spec, "=")
+	if !ok {
+		id, path = "", spec
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseKey(id, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+// keyID - короткий идентификатор ключа по его содержимому: после замены ключа kid меняется сам
+func keyID(material []byte) string {
+	sum := sha256.Sum256(material)
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+// KeySet - ключ подписи новых токенов и ключи, которыми токены ещё проверяются.
+// При ротации новый ключ становится ключом подписи, а прежний остаётся для проверки,
+// пока не истекут выданные им токены
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet собирает набор из ключа подписи и дополнительных ключей проверки
+func NewKeySet(signing *Key, verification ...*Key) (*KeySet, error) {
+	if signing == nil || signing.sign == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	ks := &KeySet{signing: signing, keys: map[string]*Key{signing.ID: signing}}
+	for _, k := range verification {
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+	}
+	return ks, nil
+}
+
+// LoadKeys собирает набор ключей из конфигурации. Файл закрытого ключа важнее секрета HS256
+func LoadKeys(cfg config.AuthConfig) (*KeySet, error) {
+	var (
+		signing *Key
+		err     error
+	)
+	switch {
+	case cfg.JWTKeyFile != "":
+		signing, err = LoadKeyFile(cfg.JWTKeyFile)
+		if err == nil && signing.sign == nil {
+			err = fmt.Errorf("%s: signing key must be a private key", cfg.JWTKeyFile)
+		}
+	case cfg.JWTSecret != "":
+		signing, err = NewSecretKey("", []byte(cfg.JWTSecret))
+	default:
+		return nil, ErrNoSigningKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.JWTKeyID != "" {
+		signing.ID = cfg.JWTKeyID
+	}
+
+	var verification []*Key
+	for _, spec := range cfg.JWTVerifyKeyFiles {
+		k, err := LoadKeyFile(spec)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, k)
+	}
+	for _, secret := range cfg.JWTPreviousSecrets {
+		k, err := NewSecretKey("", []byte(secret))
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, k)
+	}
+
+	return NewKeySet(signing, verification...)
+}
+
+// NewEphemeralKeys создаёт набор со случайным секретом HS256. Токены перестают
+// приниматься после перезапуска, поэтому набор годится только для разработки
+func NewEphemeralKeys() (*KeySet, error) {
+	secret := make([]byte, minSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	k, err := NewSecretKey("", secret)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(k)
+}
+
+// Sign подписывает claims ключом подписи и указывает его kid в заголовке
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.sign)
+}
+
+// Parse проверяет подпись и срок действия токена и заполняет claims.
+// Ключ выбирается по kid, а алгоритм токена должен совпадать с алгоритмом ключа:
+// иначе открытый RSA-ключ можно было бы подсунуть как секрет HS256
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) error {
+	methods := make([]string, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		methods = append(methods, k.Method.Alg())
+	}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			k, ok := ks.keys[kid]
+			if !ok {
+				return nil, ErrUnknownKey
+			}
+			if t.Method.Alg() != k.Method.Alg() {
+				return nil, jwt.ErrTokenSignatureInvalid
+			}
+			return k.verify, nil
+		},
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	)
+	return err
+}
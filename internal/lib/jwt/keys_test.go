@@ -0,0 +1,147 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"workout/internal/config"
+	"workout/internal/entity"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = &entity.User{ID: "6f1c1f4e-3f5b-4d8e-9a51-2b7d1e0c9a10", Email: "runner@example.com", Roles: []entity.Role{entity.RoleAthlete}}
+
+// writePEM сохраняет ключ в PEM-файл во временном каталоге теста
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestSecretKeys(t *testing.T) {
+	_, err := LoadKeys(config.AuthConfig{})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+	_, err = LoadKeys(config.AuthConfig{JWTSecret: "short"})
+	assert.ErrorIs(t, err, ErrWeakSecret)
+
+	old, err := LoadKeys(config.AuthConfig{JWTSecret: "old-secret-old-secret-old-secret!"})
+	require.NoError(t, err)
+	oldToken, err := NewToken(testUser, "sid", old, time.Minute)
+	require.NoError(t, err)
+
+	// После ротации прежний секрет только проверяет токены
+	keys, err := LoadKeys(config.AuthConfig{
+		JWTSecret:          "new-secret-new-secret-new-secret!",
+		JWTPreviousSecrets: []string{"old-secret-old-secret-old-secret!"},
+	})
+	require.NoError(t, err)
+
+	token, err := NewToken(testUser, "sid", keys, time.Minute)
+	require.NoError(t, err)
+
+	claims := new(UserClaims)
+	require.NoError(t, keys.Parse(token, claims))
+	assert.Equal(t, testUser.ID, claims.UID)
+	assert.Equal(t, testUser.ID, claims.Subject)
+	assert.Equal(t, "sid", claims.SessionID)
+	assert.Equal(t, []entity.Role{entity.RoleAthlete}, claims.RoleList())
+
+	require.NoError(t, keys.Parse(oldToken, new(UserClaims)))
+	assert.Empty(t, keys.JWKS().Keys, "секреты HS256 не публикуются")
+
+	// Токен, подписанный неизвестным ключом, и просроченный токен не принимаются
+	other, err := NewEphemeralKeys()
+	require.NoError(t, err)
+	foreign, err := NewToken(testUser, "sid", other, time.Minute)
+	require.NoError(t, err)
+	assert.Error(t, keys.Parse(foreign, new(UserClaims)))
+
+	expired, err := NewToken(testUser, "sid", keys, -time.Minute)
+	require.NoError(t, err)
+	assert.ErrorIs(t, keys.Parse(expired, new(UserClaims)), jwt.ErrTokenExpired)
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPath := writePEM(t, "ed25519.pem", "PRIVATE KEY", der)
+	pubDER, err := x509.MarshalPKIXPublicKey(edPub)
+	require.NoError(t, err)
+	edPubPath := writePEM(t, "ed25519.pub.pem", "PUBLIC KEY", pubDER)
+
+	// Подписывали RSA, перешли на Ed25519: прежний ключ остаётся для проверки
+	old, err := LoadKeys(config.AuthConfig{JWTKeyFile: rsaPath, JWTKeyID: "2024"})
+	require.NoError(t, err)
+	oldToken, err := NewToken(testUser, "sid", old, time.Minute)
+	require.NoError(t, err)
+
+	keys, err := LoadKeys(config.AuthConfig{JWTKeyFile: edPath, JWTVerifyKeyFiles: []string{"2024=" + rsaPath}})
+	require.NoError(t, err)
+	token, err := NewToken(testUser, "sid", keys, time.Minute)
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, new(UserClaims))
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+	require.NoError(t, keys.Parse(token, new(UserClaims)))
+	require.NoError(t, keys.Parse(oldToken, new(UserClaims)))
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, parsed.Header["kid"], jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "2024", jwks.Keys[1].Kid)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	// Открытым ключом подписывать нельзя, но проверять им можно
+	_, err = LoadKeys(config.AuthConfig{JWTKeyFile: edPubPath})
+	assert.Error(t, err)
+	verifier, err := LoadKeys(config.AuthConfig{JWTSecret: "some-secret-some-secret-some-sec", JWTVerifyKeyFiles: []string{edPubPath}})
+	require.NoError(t, err)
+	require.NoError(t, verifier.Parse(token, new(UserClaims)))
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	key, err := ParseKey("rsa", pubPEM)
+	require.NoError(t, err)
+	secret, err := NewSecretKey("", []byte("some-secret-some-secret-some-sec"))
+	require.NoError(t, err)
+	keys, err := NewKeySet(secret, key)
+	require.NoError(t, err)
+
+	// Открытый ключ RSA общеизвестен: токен HS256, подписанный им как секретом, не принимается
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, &UserClaims{
+		UID:              testUser.ID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString(pubPEM)
+	require.NoError(t, err)
+
+	assert.Error(t, keys.Parse(signed, new(UserClaims)))
+}
@@ -3,8 +3,11 @@ package activity
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gofrs/uuid/v5"
+	"time"
+	"workout/internal/adapters/filestorage"
 	"workout/internal/dto"
 	"workout/internal/entity"
 
@@ -14,16 +17,70 @@ import (
 	"github.com/muktihari/fit/proto"
 )
 
+var (
+	ErrInvalidWorkoutID  = errors.New("invalid workout id")
+	ErrUnsupportedFormat = errors.New("unsupported file format, want .fit, .gpx or .tcx")
+	ErrParseFile         = errors.New("failed to parse activity file")
+	ErrOriginalNotFound  = errors.New("original file not found")
+)
+
 type WorkoutService struct {
 	// Здесь будет репозиторий для работы с базой данных
 	Activity Activity
+	// Хранилище исходных файлов; nil - исходники не сохраняются
+	Files FileStorage
+	// Сколько удалённая тренировка хранится в корзине
+	TrashRetention time.Duration
+	// Транзакции репозитория; nil - вызовы выполняются по отдельности
+	Tx Transactor
 }
 
-func NewWorkoutService(act Activity) *WorkoutService {
-	return &WorkoutService{Activity: act}
+// NewWorkoutService создаёт сервис. Если репозиторий умеет транзакции, они используются автоматически
+func NewWorkoutService(act Activity, files FileStorage) *WorkoutService {
+	s := &WorkoutService{Activity: act, Files: files, TrashRetention: DefaultTrashRetention}
+	if tx, ok := act.(Transactor); ok {
+		s.Tx = tx
+	}
+	return s
+}
+
+// withinTx выполняет fn в транзакции репозитория, если она доступна
+func (s *WorkoutService) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.Tx == nil {
+		return fn(ctx)
+	}
+	return s.Tx.WithinTx(ctx, fn)
 }
 
 func (s *WorkoutService) UploadFile(ctx context.Context, data []byte) (*dto.UploadFile, error) {
+	// Формат файла определяется по содержимому, а не по расширению
+	activityData, err := ParseActivity(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Формируем объект тренировки для сохранения
+	newWorkout := dto.NewUploadFile(activityData)
+
+	return newWorkout, nil
+}
+
+// ParseActivity определяет формат файла тренировки и извлекает из него данные активности
+func ParseActivity(data []byte) (*entity.ActivityData, error) {
+	switch DetectFormat(data) {
+	case FormatFIT:
+		return parseFIT(data)
+	case FormatGPX:
+		return parseGPX(data)
+	case FormatTCX:
+		return parseTCX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// parseFIT декодирует FIT-файл и извлекает данные активности
+func parseFIT(data []byte) (*entity.ActivityData, error) {
 	// Создаем декодер для библиотеки muktihari/fit
 	// Декодер позволяет парсить FIT-файлы с различными опциями
 	dec := decoder.New(bytes.NewReader(data))
@@ -34,21 +91,17 @@ func (s *WorkoutService) UploadFile(ctx context.Context, data []byte) (*dto.Uplo
 	fit, err := dec.Decode()
 	if err != nil {
 		// Если файл не является валидным FIT-файлом или произошла ошибка парсинга
-		// Возвращаем ошибку 400 "Bad Request" через Echo
-		return nil, fmt.Errorf("Ошибка при парсинге FIT-файла: %v", err)
+		return nil, fmt.Errorf("%w: Ошибка при парсинге FIT-файла: %v", ErrParseFile, err)
 	}
 
 	// Анализируем декодированные сообщения и извлекаем данные активности
 	// В новой библиотеке нужно самостоятельно обрабатывать массив сообщений
 	activityData, err := extractActivityData(fit.Messages)
 	if err != nil {
-		return nil, err //c.String(http.StatusInternalServerError, fmt.Sprintf("Ошибка при извлечении данных активности: %v", err))
+		return nil, fmt.Errorf("%w: %v", ErrParseFile, err)
 	}
 
-	// Формируем объект тренировки для сохранения
-	newWorkout := dto.NewUploadFile(activityData)
-
-	return newWorkout, nil
+	return activityData, nil
 }
 
 // extractActivityData извлекает данные активности из массива протокольных сообщений
@@ -57,9 +110,8 @@ func extractActivityData(messages []proto.Message) (*entity.ActivityData, error)
 	// Инициализируем структуру для хранения данных
 	data := &entity.ActivityData{}
 
-	// Флаги для отслеживания найденных сообщений
+	// Флаг для отслеживания найденной сессии
 	foundSession := false
-	foundActivity := false
 
 	// Перебираем все декодированные сообщения
 	for _, message := range messages {
@@ -76,8 +128,6 @@ func extractActivityData(messages []proto.Message) (*entity.ActivityData, error)
 			if !activityMsg.LocalTimestamp.IsZero() {
 				data.LocalTimestamp = activityMsg.LocalTimestamp
 			}
-
-			foundActivity = true
 		case mesgnum.Session:
 			// Извлекаем основные метрики из первой найденной сессии
 			// Высокоуровневый API: готовая структура с типизированными полями
@@ -91,19 +141,19 @@ func extractActivityData(messages []proto.Message) (*entity.ActivityData, error)
 			data.Sport = sessionMsg.Sport
 			data.TotalCalories = sessionMsg.TotalCalories
 			data.AvgSpeed = sessionMsg.AvgSpeed
-			data.AvgCadence = sessionMsg.AvgCadence * 2
+			data.AvgCadence = uint16(fitUint8(sessionMsg.AvgCadence))
 			data.TotalCalories = sessionMsg.TotalCalories
 			foundSession = true
 
+		case mesgnum.Lap:
+			// Сообщение Lap - круги, размеченные устройством (вручную, по дистанции, по времени)
+			lapMsg := mesgdef.NewLap(&message)
+			data.Laps = append(data.Laps, extractLap(lapMsg))
+
 		case mesgnum.Record:
 			// Сообщение Record - детальные данные каждой секунды
-			//recordMsg := mesgdef.NewRecord(&message)
-			record := entity.RecordData{}
-			data.Records = append(data.Records, record)
-			// // Если нашли оба ключевых сообщения, можно прекратить поиск
-			if foundSession && foundActivity {
-				break
-			}
+			recordMsg := mesgdef.NewRecord(&message)
+			data.Records = append(data.Records, extractRecord(recordMsg))
 		}
 	}
 
@@ -112,9 +162,79 @@ func extractActivityData(messages []proto.Message) (*entity.ActivityData, error)
 		return nil, fmt.Errorf("не найдено сообщение Session в FIT-файле")
 	}
 
+	// Для бега и ходьбы FIT хранит каденс одной ноги, для велосипеда - обороты шатуна.
+	// Вид спорта известен только из сессии, которая обычно идёт в конце файла
+	data.AvgCadence = stepCadence(data.AvgCadence, data.Sport)
+	for i := range data.Laps {
+		data.Laps[i].AvgCadence = stepCadence(data.Laps[i].AvgCadence, data.Sport)
+		data.Laps[i].MaxCadence = stepCadence(data.Laps[i].MaxCadence, data.Sport)
+	}
+	for i := range data.Records {
+		data.Records[i].Cadence = stepCadence(data.Records[i].Cadence, data.Sport)
+	}
+
 	return data, nil
 }
 
+// extractLap переводит сообщение Lap в LapData
+func extractLap(msg *mesgdef.Lap) entity.LapData {
+	lap := entity.LapData{
+		Timestamp:        msg.Timestamp,
+		StartTime:        msg.StartTime,
+		TotalElapsedTime: fitUint32(msg.TotalElapsedTime),
+		TotalTimerTime:   fitUint32(msg.TotalTimerTime),
+		TotalDistance:    fitUint32(msg.TotalDistance),
+		AvgSpeed:         fitUint16(msg.AvgSpeed),
+		MaxSpeed:         fitUint16(msg.MaxSpeed),
+		AvgHeartRate:     fitUint8(msg.AvgHeartRate),
+		MaxHeartRate:     fitUint8(msg.MaxHeartRate),
+		AvgCadence:       uint16(fitUint8(msg.AvgCadence)),
+		MaxCadence:       uint16(fitUint8(msg.MaxCadence)),
+		TotalCalories:    fitUint16(msg.TotalCalories),
+		LapTrigger:       msg.LapTrigger,
+	}
+
+	if lap.AvgSpeed == 0 && isValidFitUint32(msg.EnhancedAvgSpeed) && msg.EnhancedAvgSpeed < FIT_UINT16_INVALID {
+		lap.AvgSpeed = uint16(msg.EnhancedAvgSpeed)
+	}
+	if lap.MaxSpeed == 0 && isValidFitUint32(msg.EnhancedMaxSpeed) && msg.EnhancedMaxSpeed < FIT_UINT16_INVALID {
+		lap.MaxSpeed = uint16(msg.EnhancedMaxSpeed)
+	}
+
+	return lap
+}
+
+// extractRecord переводит сообщение Record в RecordData.
+// Невалидные значения FIT (0xFF, 0xFFFF и т.д.) заменяются нулями — "нет данных"
+func extractRecord(msg *mesgdef.Record) entity.RecordData {
+	record := entity.RecordData{
+		Timestamp:   msg.Timestamp,
+		Distance:    fitUint32(msg.Distance),
+		Speed:       fitUint16(msg.Speed),
+		HeartRate:   fitUint8(msg.HeartRate),
+		Cadence:     uint16(fitUint8(msg.Cadence)),
+		Power:       fitUint16(msg.Power),
+		Altitude:    fitUint16(msg.Altitude),
+		Temperature: fitSint8(msg.Temperature),
+	}
+
+	// Координаты пишутся только парой, без GPS (дорожка, манеж) их нет
+	if isValidFitSint32(msg.PositionLat) && isValidFitSint32(msg.PositionLong) {
+		record.PositionLat = msg.PositionLat
+		record.PositionLon = msg.PositionLong
+	}
+
+	// Современные устройства пишут скорость и высоту в enhanced-поля
+	if record.Speed == 0 && isValidFitUint32(msg.EnhancedSpeed) && msg.EnhancedSpeed < FIT_UINT16_INVALID {
+		record.Speed = uint16(msg.EnhancedSpeed)
+	}
+	if record.Altitude == 0 && isValidFitUint32(msg.EnhancedAltitude) && msg.EnhancedAltitude < FIT_UINT16_INVALID {
+		record.Altitude = uint16(msg.EnhancedAltitude)
+	}
+
+	return record
+}
+
 func (s *WorkoutService) CreateWorkout(ctx context.Context, w dto.WorkoutDTO) (*entity.Workout, error) {
 	workout, err := dto.WorkoutMapper(w)
 	if err != nil {
@@ -124,26 +244,70 @@ func (s *WorkoutService) CreateWorkout(ctx context.Context, w dto.WorkoutDTO) (*
 	return s.Activity.CreateWorkout(ctx, workout)
 }
 
-func (s *WorkoutService) GetWorkouts(ctx context.Context, userID string) ([]entity.Workout, error) {
+func (s *WorkoutService) GetLaps(ctx context.Context, userID, workoutID string) ([]entity.LapData, error) {
+	workout, err := s.authorizeWorkout(ctx, userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
 
-	uid, err := uuid.FromString(userID)
+	return s.Activity.GetLaps(ctx, workout.UserID, workout.ID)
+}
+
+// GetSplits рассчитывает отрезки длиной splitDistance (в сантиметрах) по треку тренировки
+func (s *WorkoutService) GetSplits(ctx context.Context, userID, workoutID string, splitDistance uint32) (*entity.Splits, error) {
+	workout, err := s.authorizeWorkout(ctx, userID, workoutID)
 	if err != nil {
 		return nil, err
 	}
 
-	workouts, err := s.Activity.GetWorkouts(ctx, uid)
+	records, err := s.Activity.GetTrackPoints(ctx, workout.UserID, workout.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return workouts, nil
+	return CalculateSplits(records, splitDistance), nil
 }
 
-func (s *WorkoutService) UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) error {
-	return s.Activity.UpdateWorkout(ctx, u)
+// GetOriginal возвращает исходный файл тренировки и имя, под которым он был загружен
+func (s *WorkoutService) GetOriginal(ctx context.Context, userID, workoutID string) (string, []byte, error) {
+	uid, wid, err := parseIDs(userID, workoutID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	workout, err := s.Activity.GetWorkoutOriginal(ctx, uid, wid)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Тренировки, созданные вручную или до появления хранилища, исходника не имеют
+	if workout.OriginalKey == "" || s.Files == nil {
+		return "", nil, ErrOriginalNotFound
+	}
+
+	data, err := s.Files.Load(ctx, workout.OriginalKey)
+	if err != nil {
+		if errors.Is(err, filestorage.ErrNotFound) {
+			return "", nil, ErrOriginalNotFound
+		}
+		return "", nil, err
+	}
+
+	return workout.OriginalName, data, nil
 }
 
-func (s *WorkoutService) DeleteWorkout(ctx context.Context, id int64) error {
-	// TODO: Реализовать удаление из базы данных
-	return nil
+// parseIDs разбирает идентификаторы пользователя и тренировки из строк
+func parseIDs(userID, workoutID string) (uuid.UUID, uuid.UUID, error) {
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	wid, err := uuid.FromString(workoutID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidWorkoutID, err)
+	}
+
+	return uid, wid, nil
 }
+
@@ -0,0 +1,147 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/mesgdef"
+	"github.com/muktihari/fit/profile/typedef"
+	"github.com/muktihari/fit/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractActivityData_Records(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+
+	messages := []proto.Message{
+		mesgdef.NewRecord(nil).
+			SetTimestamp(start).
+			SetPositionLatDegrees(55.75).
+			SetPositionLongDegrees(37.61).
+			SetDistance(0).
+			SetHeartRate(120).
+			SetCadence(85).
+			SetAltitudeScaled(150).
+			ToMesg(nil),
+		// Точка без GPS и пульса: невалидные значения должны стать нулями
+		mesgdef.NewRecord(nil).
+			SetTimestamp(start.Add(time.Second)).
+			SetDistance(350).
+			SetEnhancedSpeed(3500).
+			ToMesg(nil),
+		// Спринт: шагов в минуту больше 255
+		mesgdef.NewRecord(nil).
+			SetTimestamp(start.Add(2 * time.Second)).
+			SetDistance(350).
+			SetCadence(130).
+			ToMesg(nil),
+		mesgdef.NewSession(nil).
+			SetSport(typedef.SportRunning).
+			SetTotalDistance(350).
+			SetTotalTimerTime(1000).
+			SetAvgCadence(85).
+			ToMesg(nil),
+	}
+
+	data, err := extractActivityData(messages)
+	require.NoError(t, err)
+	require.Len(t, data.Records, 3)
+
+	first := data.Records[0]
+	assert.Equal(t, start, first.Timestamp)
+	assert.NotZero(t, first.PositionLat)
+	assert.NotZero(t, first.PositionLon)
+	assert.Equal(t, uint8(120), first.HeartRate)
+	assert.Equal(t, uint16(170), first.Cadence)
+	assert.Equal(t, uint16((150+500)*5), first.Altitude)
+
+	second := data.Records[1]
+	assert.Zero(t, second.PositionLat)
+	assert.Zero(t, second.PositionLon)
+	assert.Zero(t, second.HeartRate)
+	assert.Zero(t, second.Cadence)
+	assert.Zero(t, second.Power)
+	assert.Equal(t, uint32(350), second.Distance)
+	assert.Equal(t, uint16(3500), second.Speed)
+	assert.Equal(t, uint16(260), data.Records[2].Cadence)
+
+	assert.Equal(t, uint16(170), data.AvgCadence)
+}
+
+func TestExtractActivityData_NoSession(t *testing.T) {
+	messages := []proto.Message{
+		mesgdef.NewRecord(nil).SetTimestamp(time.Now()).ToMesg(nil),
+	}
+
+	_, err := extractActivityData(messages)
+	assert.Error(t, err)
+}
+
+func TestExtractActivityData_Laps(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+
+	messages := []proto.Message{
+		mesgdef.NewLap(nil).
+			SetStartTime(start).
+			SetTimestamp(start.Add(5 * time.Minute)).
+			SetTotalTimerTime(300000).
+			SetTotalDistance(100000).
+			SetAvgHeartRate(150).
+			SetAvgCadence(88).
+			SetLapTrigger(typedef.LapTriggerDistance).
+			ToMesg(nil),
+		mesgdef.NewLap(nil).
+			SetStartTime(start.Add(5 * time.Minute)).
+			SetTimestamp(start.Add(6 * time.Minute)).
+			SetTotalTimerTime(60000).
+			SetEnhancedAvgSpeed(2800).
+			SetLapTrigger(typedef.LapTriggerSessionEnd).
+			ToMesg(nil),
+		mesgdef.NewSession(nil).SetSport(typedef.SportRunning).SetTotalDistance(100000).ToMesg(nil),
+	}
+
+	data, err := extractActivityData(messages)
+	require.NoError(t, err)
+	require.Len(t, data.Laps, 2)
+
+	assert.Equal(t, start, data.Laps[0].StartTime)
+	assert.Equal(t, uint32(100000), data.Laps[0].TotalDistance)
+	assert.Equal(t, uint8(150), data.Laps[0].AvgHeartRate)
+	assert.Equal(t, uint16(176), data.Laps[0].AvgCadence)
+	assert.Equal(t, typedef.LapTriggerDistance, data.Laps[0].LapTrigger)
+
+	assert.Zero(t, data.Laps[1].AvgHeartRate)
+	assert.Equal(t, uint16(2800), data.Laps[1].AvgSpeed)
+	assert.Equal(t, typedef.LapTriggerSessionEnd, data.Laps[1].LapTrigger)
+}
+
+func TestExtractActivityData_CyclingCadence(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+
+	// У велосипеда каденс - обороты шатуна, удваивать его нельзя
+	messages := []proto.Message{
+		mesgdef.NewRecord(nil).SetTimestamp(start).SetCadence(90).SetPower(210).ToMesg(nil),
+		mesgdef.NewLap(nil).
+			SetStartTime(start).
+			SetTimestamp(start.Add(time.Minute)).
+			SetAvgCadence(88).
+			SetMaxCadence(105).
+			ToMesg(nil),
+		mesgdef.NewSession(nil).
+			SetSport(typedef.SportCycling).
+			SetTotalDistance(50000).
+			SetAvgCadence(88).
+			ToMesg(nil),
+	}
+
+	data, err := extractActivityData(messages)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(88), data.AvgCadence)
+	require.Len(t, data.Records, 1)
+	assert.Equal(t, uint16(90), data.Records[0].Cadence)
+	require.Len(t, data.Laps, 1)
+	assert.Equal(t, uint16(88), data.Laps[0].AvgCadence)
+	assert.Equal(t, uint16(105), data.Laps[0].MaxCadence)
+}
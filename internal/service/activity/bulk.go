@@ -0,0 +1,382 @@
+package activity
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"sync"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	maxArchiveEntries = 2000      // максимум файлов в одном архиве
+	maxEntrySize      = 64 << 20  // максимальный размер распакованного файла
+	maxUnpackedSize   = 200 << 20 // суммарный объём распакованных файлов одной загрузки, как лимит тела запроса
+	maxUploadWorkers  = 8         // верхняя граница числа параллельных парсеров
+)
+
+var (
+	ErrArchiveTooLarge = errors.New("archive contains too many files")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrUploadTooLarge  = errors.New("unpacked upload is too large")
+)
+
+// UploadedFile - файл, полученный из multipart-формы
+type UploadedFile struct {
+	Name string
+	Data []byte
+}
+
+// UploadOptions - параметры пакетной загрузки
+type UploadOptions struct {
+	UserID      string // владелец сохраняемых тренировок
+	DryRun      bool   // только разобрать файлы и вернуть предпросмотр, ничего не сохраняя
+	OnDuplicate string // DuplicateSkip (по умолчанию) или DuplicateMerge
+}
+
+// UploadResult - результат обработки одного файла
+type UploadResult struct {
+	File            string          // имя файла; для архивов - "archive.zip/entry.fit"
+	Status          string          // dto.UploadStatusOK, Error, Duplicate или Merged
+	Preview         *dto.UploadFile // разобранная тренировка (только для DryRun)
+	Workout         *entity.Workout // сохранённая (или дополненная при слиянии) тренировка
+	Err             error           // причина ошибки
+	DuplicateOf     uuid.UUID       // уже сохранённая тренировка, которую повторяет файл
+	DuplicateOfFile string          // файл из этой же загрузки с тем же содержимым или той же тренировкой
+}
+
+// uploadBatch - общее состояние файлов одной загрузки
+type uploadBatch struct {
+	// mu выстраивает поиск дубликатов и сохранение в очередь: разбор идёт параллельно,
+	// но каждый файл сверяется с уже сохранёнными из этой же загрузки
+	mu sync.Mutex
+	// previews - разобранные, но не сохранённые тренировки пробного прогона
+	previews []batchWorkout
+}
+
+type batchWorkout struct {
+	file    string
+	workout *entity.Workout
+}
+
+// findSimilar возвращает файл из этой загрузки с той же тренировкой с другого устройства
+func (b *uploadBatch) findSimilar(w *entity.Workout) string {
+	if w.StartTime.IsZero() {
+		return ""
+	}
+	for _, p := range b.previews {
+		if absDuration(p.workout.StartTime.Sub(w.StartTime)) <= duplicateStartWindow && isSimilarWorkout(p.workout, w) {
+			return p.file
+		}
+	}
+	return ""
+}
+
+// uploadEntry - файл после распаковки архивов, готовый к разбору
+type uploadEntry struct {
+	name   string
+	data   []byte
+	hash   string // sha256 содержимого в hex
+	result *UploadResult
+}
+
+// UploadFiles разбирает и сохраняет несколько файлов тренировок, включая ZIP-архивы
+// (например, выгрузки Garmin/Strava) и сжатые gzip файлы.
+// Файлы обрабатываются параллельно ограниченным пулом воркеров по мере распаковки,
+// каждая тренировка сохраняется в своей транзакции. Поиск дубликатов и сохранение выполняются
+// по одному файлу за раз, поэтому одна тренировка с двух устройств в одном архиве
+// сохраняется один раз. Результаты возвращаются в порядке загрузки
+func (s *WorkoutService) UploadFiles(ctx context.Context, files []UploadedFile, opts UploadOptions) ([]*UploadResult, error) {
+	userID, err := uuid.FromString(opts.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.OnDuplicate {
+	case "", DuplicateSkip, DuplicateMerge:
+	default:
+		return nil, ErrInvalidDuplicateMode
+	}
+
+	batch := &uploadBatch{}
+	workers := min(runtime.NumCPU(), maxUploadWorkers)
+	jobs := make(chan *uploadEntry)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range jobs {
+				e.result = s.importEntry(ctx, batch, e, userID, opts)
+				// Разобранный файл больше не нужен, не держим в памяти весь архив
+				e.data = nil
+			}
+		}()
+	}
+
+	// Одинаковые файлы в одной загрузке разбираем один раз
+	var entries []*uploadEntry
+	seen := make(map[[sha256.Size]byte]string)
+	enqueue := func(e *uploadEntry) {
+		entries = append(entries, e)
+		if e.result != nil {
+			return
+		}
+		sum := sha256.Sum256(e.data)
+		if first, ok := seen[sum]; ok {
+			e.result = &UploadResult{File: e.name, Status: dto.UploadStatusDuplicate, DuplicateOfFile: first}
+			e.data = nil
+			return
+		}
+		seen[sum] = e.name
+		e.hash = hex.EncodeToString(sum[:])
+		jobs <- e
+	}
+
+	budget := &unpackBudget{left: maxUnpackedSize}
+	for _, f := range files {
+		expandUpload(f, budget, enqueue)
+	}
+	close(jobs)
+	wg.Wait()
+
+	results := make([]*UploadResult, 0, len(entries))
+	for _, e := range entries {
+		results = append(results, e.result)
+	}
+	return results, nil
+}
+
+// importEntry разбирает один файл и сохраняет тренировку (или возвращает предпросмотр).
+// Если такая тренировка уже есть, файл помечается дубликатом или сливается с ней
+func (s *WorkoutService) importEntry(ctx context.Context, batch *uploadBatch, e *uploadEntry, userID uuid.UUID, opts UploadOptions) *UploadResult {
+	failed := func(err error) *UploadResult {
+		return &UploadResult{File: e.name, Status: dto.UploadStatusError, Err: err}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return failed(err)
+	}
+
+	data, err := ParseActivity(e.data)
+	if err != nil {
+		return failed(err)
+	}
+
+	workout := dto.NewWorkoutFromActivity(userID, data)
+	workout.FileHash = e.hash
+
+	var preview *dto.UploadFile
+	if opts.DryRun {
+		preview = dto.NewUploadFile(data)
+	}
+
+	// Иначе тот же файл с другого устройства, разобранный параллельно, не найдёт эту тренировку в базе
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+
+	existing, exact, err := s.findDuplicate(ctx, workout)
+	if err != nil {
+		return failed(err)
+	}
+
+	if existing != nil {
+		// Файл с тем же содержимым сливать не с чем
+		if exact || opts.DryRun || opts.OnDuplicate != DuplicateMerge {
+			return &UploadResult{File: e.name, Status: dto.UploadStatusDuplicate, DuplicateOf: existing.ID, Preview: preview}
+		}
+
+		// Трек читается и перезаписывается в одной транзакции, чтобы не потерять параллельные изменения
+		var merged *entity.Workout
+		err := s.withinTx(ctx, func(ctx context.Context) error {
+			var err error
+			merged, err = s.mergeWorkout(ctx, existing, workout)
+			return err
+		})
+		if err != nil {
+			return failed(err)
+		}
+		return &UploadResult{File: e.name, Status: dto.UploadStatusMerged, DuplicateOf: existing.ID, Workout: merged}
+	}
+
+	if opts.DryRun {
+		// В пробном прогоне ничего не сохраняется, поэтому сверяемся с разобранными файлами загрузки
+		if first := batch.findSimilar(workout); first != "" {
+			return &UploadResult{File: e.name, Status: dto.UploadStatusDuplicate, DuplicateOfFile: first, Preview: preview}
+		}
+		batch.previews = append(batch.previews, batchWorkout{file: e.name, workout: workout})
+		return &UploadResult{File: e.name, Status: dto.UploadStatusOK, Preview: preview}
+	}
+
+	if err := s.saveOriginal(ctx, e, workout); err != nil {
+		return failed(err)
+	}
+
+	saved, err := s.Activity.CreateWorkout(ctx, workout)
+	if err != nil {
+		// Одинаковые файлы пользователя лежат под одним ключом, поэтому удалять
+		// можно только если тренировку с этим файлом ещё никто не сохранил
+		if workout.OriginalKey != "" {
+			if _, findErr := s.Activity.FindWorkoutByHash(ctx, userID, e.hash); errors.Is(findErr, storage.ErrWorkoutNotFound) {
+				_ = s.Files.Delete(ctx, workout.OriginalKey)
+			}
+		}
+		return failed(err)
+	}
+
+	return &UploadResult{File: e.name, Status: dto.UploadStatusOK, Workout: saved}
+}
+
+// saveOriginal кладёт исходный файл в хранилище и запоминает ключ в тренировке.
+// Ключ строится по хэшу содержимого, поэтому повторная загрузка не создаёт копий
+func (s *WorkoutService) saveOriginal(ctx context.Context, e *uploadEntry, workout *entity.Workout) error {
+	if s.Files == nil {
+		return nil
+	}
+
+	key := originalKey(workout.UserID, e.hash)
+	if err := s.Files.Save(ctx, key, e.data); err != nil {
+		return fmt.Errorf("ошибка сохранения исходного файла: %w", err)
+	}
+
+	workout.OriginalKey = key
+	workout.OriginalName = path.Base(e.name)
+	return nil
+}
+
+func originalKey(userID uuid.UUID, hash string) string {
+	return path.Join("originals", userID.String(), hash)
+}
+
+// expandUpload распаковывает ZIP-архив или gzip-файл и передаёт файлы для разбора в emit
+// по одному, по мере распаковки. Ошибка распаковки передаётся как готовый результат для этого файла
+func expandUpload(f UploadedFile, budget *unpackBudget, emit func(*uploadEntry)) {
+	failed := func(name string, err error) {
+		emit(&uploadEntry{
+			name:   name,
+			result: &UploadResult{File: name, Status: dto.UploadStatusError, Err: err},
+		})
+	}
+
+	switch {
+	case isZip(f.Data):
+		if err := unzip(f.Name, f.Data, budget, emit, failed); err != nil {
+			failed(f.Name, err)
+		}
+	case isGzip(f.Data):
+		data, err := budget.gunzip(f.Data)
+		if err != nil {
+			failed(f.Name, err)
+			return
+		}
+		emit(&uploadEntry{name: f.Name, data: data})
+	default:
+		emit(&uploadEntry{name: f.Name, data: f.Data})
+	}
+}
+
+// unzip передаёт в emit файлы тренировок из архива. Файлы, не похожие на тренировки
+// (csv, фото и т.п. из выгрузки Strava), пропускаются. Если распакованные данные
+// превысили лимит загрузки, чтение архива прекращается с ErrUploadTooLarge,
+// уже переданные файлы обрабатываются как обычно
+func unzip(name string, data []byte, budget *unpackBudget, emit func(*uploadEntry), failed func(string, error)) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%w: некорректный ZIP-архив: %v", ErrParseFile, err)
+	}
+
+	if len(zr.File) > maxArchiveEntries {
+		return ErrArchiveTooLarge
+	}
+
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+
+		entryName := path.Join(name, zf.Name)
+		content, err := budget.readZipFile(zf)
+		if err == nil && isGzip(content) {
+			content, err = budget.gunzip(content)
+		}
+		if errors.Is(err, ErrUploadTooLarge) {
+			return err
+		}
+		if err != nil {
+			failed(entryName, err)
+			continue
+		}
+
+		if DetectFormat(content) == FormatUnknown {
+			continue
+		}
+		emit(&uploadEntry{name: entryName, data: content})
+	}
+
+	return nil
+}
+
+// unpackBudget - сколько байт ещё можно распаковать в рамках одной загрузки.
+// Вместе с maxEntrySize и maxArchiveEntries защищает от zip-бомб
+type unpackBudget struct {
+	left int64
+}
+
+func (b *unpackBudget) readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return b.read(rc)
+}
+
+func (b *unpackBudget) gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: некорректный gzip: %v", ErrParseFile, err)
+	}
+	defer zr.Close()
+
+	return b.read(zr)
+}
+
+// read читает не больше maxEntrySize байт и не больше остатка лимита загрузки
+func (b *unpackBudget) read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, min(maxEntrySize, b.left)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParseFile, err)
+	}
+
+	size := int64(len(data))
+	if size > b.left {
+		b.left = 0
+		return nil, ErrUploadTooLarge
+	}
+	b.left -= size
+	if size > maxEntrySize {
+		return nil, ErrFileTooLarge
+	}
+	return data, nil
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+func isGzip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte{0x1f, 0x8b})
+}
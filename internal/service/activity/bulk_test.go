@@ -0,0 +1,234 @@
+package activity
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"workout/internal/adapters/filestorage"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipFiles(t *testing.T, files map[string][]byte, order ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range order {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestUploadFiles(t *testing.T) {
+	archive := zipFiles(t, map[string][]byte{
+		"activities/1.gpx":    []byte(testGPX),
+		"activities/2.tcx.gz": gzipData(t, []byte(testTCX)),
+		"activities.csv":      []byte("id,name\n1,run\n"),
+	}, "activities/1.gpx", "activities/2.tcx.gz", "activities.csv")
+
+	svc := NewWorkoutService(&savingRepo{}, nil)
+	results, err := svc.UploadFiles(context.Background(), []UploadedFile{
+		{Name: "export.zip", Data: archive},
+		{Name: "run.gpx", Data: []byte(testGPX)},
+		{Name: "broken.fit", Data: []byte("not a workout")},
+	}, UploadOptions{UserID: uuid.Must(uuid.NewV4()).String(), DryRun: true})
+	require.NoError(t, err)
+
+	require.Len(t, results, 4)
+
+	assert.Equal(t, "export.zip/activities/1.gpx", results[0].File)
+	assert.Equal(t, dto.UploadStatusOK, results[0].Status)
+	require.NotNil(t, results[0].Preview)
+	assert.Nil(t, results[0].Workout)
+
+	assert.Equal(t, "export.zip/activities/2.tcx.gz", results[1].File)
+	assert.Equal(t, dto.UploadStatusOK, results[1].Status)
+	assert.Len(t, results[1].Preview.Laps, 2)
+
+	assert.Equal(t, "run.gpx", results[2].File)
+	assert.Equal(t, dto.UploadStatusDuplicate, results[2].Status)
+	assert.Equal(t, "export.zip/activities/1.gpx", results[2].DuplicateOfFile)
+
+	assert.Equal(t, "broken.fit", results[3].File)
+	assert.Equal(t, dto.UploadStatusError, results[3].Status)
+	assert.ErrorIs(t, results[3].Err, ErrUnsupportedFormat)
+}
+
+// savingRepo хранит тренировки в памяти, остальные методы репозитория не нужны
+type savingRepo struct {
+	Activity
+	mu      sync.Mutex
+	created []*entity.Workout
+	updated []*entity.Workout
+	coaches map[uuid.UUID]uuid.UUID // спортсмен -> тренер
+	roles   map[uuid.UUID][]entity.Role
+}
+
+func (r *savingRepo) CreateWorkout(_ context.Context, w *entity.Workout) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w.ID = uuid.Must(uuid.NewV4())
+	r.created = append(r.created, w)
+	return w, nil
+}
+
+func (r *savingRepo) FindWorkoutByHash(_ context.Context, userID uuid.UUID, hash string) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.UserID == userID && w.FileHash == hash {
+			return w, nil
+		}
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+func (r *savingRepo) FindWorkoutsByStartTime(_ context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []entity.Workout
+	for _, w := range r.created {
+		if w.UserID == userID && !w.StartTime.Before(from) && !w.StartTime.After(to) {
+			result = append(result, *w)
+		}
+	}
+	return result, nil
+}
+
+func (r *savingRepo) GetTrackPoints(_ context.Context, _, workoutID uuid.UUID) ([]entity.RecordData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID == workoutID {
+			return w.RecordData, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *savingRepo) UpdateWorkoutData(_ context.Context, w *entity.Workout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	version := w.Version + 1
+	for _, stored := range r.created {
+		if stored.ID == w.ID {
+			if stored.Version != w.Version {
+				return storage.ErrVersionConflict
+			}
+			stored.Version = version
+		}
+	}
+	w.Version = version
+	copied := *w
+	r.updated = append(r.updated, &copied)
+	return nil
+}
+
+func (r *savingRepo) GetWorkoutOriginal(_ context.Context, userID, workoutID uuid.UUID) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.UserID == userID && w.ID == workoutID {
+			return w, nil
+		}
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+func TestUploadFiles_Save(t *testing.T) {
+	repo := &savingRepo{}
+	svc := NewWorkoutService(repo, nil)
+	userID := uuid.Must(uuid.NewV4())
+
+	results, err := svc.UploadFiles(context.Background(), []UploadedFile{
+		{Name: "run.tcx", Data: []byte(testTCX)},
+	}, UploadOptions{UserID: userID.String()})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, dto.UploadStatusOK, results[0].Status)
+	assert.Nil(t, results[0].Preview)
+	require.NotNil(t, results[0].Workout)
+
+	require.Len(t, repo.created, 1)
+	w := repo.created[0]
+	assert.Equal(t, userID, w.UserID)
+	assert.Equal(t, "Бег 12.06.2025", w.Name)
+	assert.Equal(t, "1.30", w.Distance)
+	assert.Equal(t, 7*time.Minute, w.Duration)
+	assert.Len(t, w.Laps, 2)
+	assert.Len(t, w.RecordData, 2)
+}
+
+func TestUploadFiles_Original(t *testing.T) {
+	files, err := filestorage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	repo := &savingRepo{}
+	svc := NewWorkoutService(repo, files)
+	userID := uuid.Must(uuid.NewV4())
+
+	results, err := svc.UploadFiles(context.Background(), []UploadedFile{
+		{Name: "export.zip", Data: zipFiles(t, map[string][]byte{"activities/run.gpx": []byte(testGPX)}, "activities/run.gpx")},
+	}, UploadOptions{UserID: userID.String()})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, dto.UploadStatusOK, results[0].Status)
+
+	w := results[0].Workout
+	assert.Equal(t, "run.gpx", w.OriginalName)
+	assert.Equal(t, "originals/"+userID.String()+"/"+w.FileHash, w.OriginalKey)
+
+	name, data, err := svc.GetOriginal(context.Background(), userID.String(), w.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "run.gpx", name)
+	assert.Equal(t, []byte(testGPX), data)
+
+	// Чужой пользователь файл не получит
+	_, _, err = svc.GetOriginal(context.Background(), uuid.Must(uuid.NewV4()).String(), w.ID.String())
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+}
+
+func TestUnzip_TotalLimit(t *testing.T) {
+	archive := zipFiles(t, map[string][]byte{
+		"1.gpx": []byte(testGPX),
+		"2.tcx": []byte(testTCX),
+	}, "1.gpx", "2.tcx")
+
+	// Лимита хватает только на первый файл: он передаётся на разбор, архив дальше не читается
+	var names []string
+	emit := func(e *uploadEntry) { names = append(names, e.name) }
+	failed := func(name string, err error) { t.Fatalf("%s: %v", name, err) }
+
+	budget := &unpackBudget{left: int64(len(testGPX) + len(testTCX)/2)}
+	err := unzip("export.zip", archive, budget, emit, failed)
+	assert.ErrorIs(t, err, ErrUploadTooLarge)
+	assert.Equal(t, []string{"export.zip/1.gpx"}, names)
+
+	// Исчерпанный лимит действует на все следующие файлы загрузки
+	_, err = budget.gunzip(gzipData(t, []byte(testTCX)))
+	assert.ErrorIs(t, err, ErrUploadTooLarge)
+}
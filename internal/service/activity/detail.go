@@ -0,0 +1,119 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+)
+
+// MaxDetailPoints - максимальное число точек трека в детальной информации о тренировке
+const MaxDetailPoints = 5000
+
+var ErrInvalidPointsLimit = errors.New("points must be between 0 and 5000")
+
+// DetailOptions - что включить в детальную информацию о тренировке
+type DetailOptions struct {
+	SplitDistance uint32 // длина отрезка в сантиметрах
+	MaxPoints     int    // сколько точек трека вернуть; 0 - не возвращать трек
+}
+
+// WorkoutDetail - тренировка со всеми рассчитанными данными
+type WorkoutDetail struct {
+	Workout *entity.Workout
+	Laps    []entity.LapData
+	Splits  *entity.Splits
+	Points  []entity.RecordData // прореженный трек, если он был запрошен
+}
+
+// GetWorkoutDetail возвращает сводку тренировки, круги, отрезки и, по запросу, прореженный трек
+func (s *WorkoutService) GetWorkoutDetail(ctx context.Context, userID, workoutID string, opts DetailOptions) (*WorkoutDetail, error) {
+	if opts.MaxPoints < 0 || opts.MaxPoints > MaxDetailPoints {
+		return nil, ErrInvalidPointsLimit
+	}
+
+	workout, err := s.authorizeWorkout(ctx, userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	laps, err := s.Activity.GetLaps(ctx, workout.UserID, workout.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.Activity.GetTrackPoints(ctx, workout.UserID, workout.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &WorkoutDetail{
+		Workout: workout,
+		Laps:    laps,
+		Splits:  CalculateSplits(records, opts.SplitDistance),
+	}
+	if opts.MaxPoints > 0 {
+		detail.Points = DownsampleRecords(records, opts.MaxPoints)
+	}
+
+	return detail, nil
+}
+
+// authorizeWorkout возвращает тренировку, если её может просматривать пользователь:
+// владелец или его подтверждённый тренер, у которого есть право entity.PermAthletesRead.
+// Роли читаются из базы, поэтому снятая роль тренера действует сразу. Чужая тренировка выглядит как несуществующая,
+// чтобы по ответу нельзя было узнать, есть ли тренировка с таким id
+func (s *WorkoutService) authorizeWorkout(ctx context.Context, userID, workoutID string) (*entity.Workout, error) {
+	uid, wid, err := parseIDs(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	workout, err := s.Activity.GetWorkoutByID(ctx, wid)
+	if err != nil {
+		return nil, err
+	}
+
+	if workout.UserID == uid {
+		return workout, nil
+	}
+
+	isCoach, err := s.Activity.IsCoachOf(ctx, uid, workout.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isCoach {
+		return nil, storage.ErrWorkoutNotFound
+	}
+
+	roles, err := s.Activity.GetUserRoles(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if !entity.HasPermission(roles, entity.PermAthletesRead) {
+		return nil, storage.ErrWorkoutNotFound
+	}
+
+	return workout, nil
+}
+
+// DownsampleRecords равномерно прореживает трек до maxPoints точек,
+// сохраняя первую и последнюю точки
+func DownsampleRecords(records []entity.RecordData, maxPoints int) []entity.RecordData {
+	if maxPoints <= 0 {
+		return nil
+	}
+	if len(records) <= maxPoints {
+		return records
+	}
+	if maxPoints == 1 {
+		return records[:1]
+	}
+
+	result := make([]entity.RecordData, 0, maxPoints)
+	last := len(records) - 1
+	for i := 0; i < maxPoints; i++ {
+		result = append(result, records[i*last/(maxPoints-1)])
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *savingRepo) GetWorkoutByID(_ context.Context, id uuid.UUID) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+func (r *savingRepo) IsCoachOf(_ context.Context, coachID, athleteID uuid.UUID) (bool, error) {
+	return r.coaches[athleteID] == coachID, nil
+}
+
+func (r *savingRepo) GetUserRoles(_ context.Context, userID uuid.UUID) ([]entity.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.roles[userID], nil
+}
+
+func TestGetWorkoutDetail(t *testing.T) {
+	owner := uuid.Must(uuid.NewV4())
+	coach := uuid.Must(uuid.NewV4())
+	stranger := uuid.Must(uuid.NewV4())
+
+	repo := &savingRepo{
+		coaches: map[uuid.UUID]uuid.UUID{owner: coach},
+		roles:   map[uuid.UUID][]entity.Role{coach: {entity.RoleAthlete, entity.RoleCoach}},
+	}
+	svc := NewWorkoutService(repo, nil)
+
+	results, err := svc.UploadFiles(context.Background(), []UploadedFile{
+		{Name: "run.tcx", Data: []byte(testTCX)},
+	}, UploadOptions{UserID: owner.String()})
+	require.NoError(t, err)
+	require.Equal(t, dto.UploadStatusOK, results[0].Status)
+	workoutID := results[0].Workout.ID.String()
+
+	detail, err := svc.GetWorkoutDetail(context.Background(), owner.String(), workoutID, DetailOptions{SplitDistance: splitKmCm})
+	require.NoError(t, err)
+	assert.Equal(t, results[0].Workout.ID, detail.Workout.ID)
+	assert.Len(t, detail.Laps, 2)
+	assert.NotNil(t, detail.Splits)
+	assert.Nil(t, detail.Points, "трек возвращается только по запросу")
+
+	detail, err = svc.GetWorkoutDetail(context.Background(), coach.String(), workoutID, DetailOptions{SplitDistance: splitKmCm, MaxPoints: 1})
+	require.NoError(t, err)
+	assert.Len(t, detail.Points, 1)
+
+	// Без роли тренера связь со спортсменом доступа не даёт
+	repo.roles[coach] = []entity.Role{entity.RoleAthlete}
+	_, err = svc.GetWorkoutDetail(context.Background(), coach.String(), workoutID, DetailOptions{SplitDistance: splitKmCm})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	_, err = svc.GetWorkoutDetail(context.Background(), stranger.String(), workoutID, DetailOptions{SplitDistance: splitKmCm})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	_, err = svc.GetWorkoutDetail(context.Background(), owner.String(), uuid.Must(uuid.NewV4()).String(), DetailOptions{})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	_, err = svc.GetWorkoutDetail(context.Background(), owner.String(), "42", DetailOptions{})
+	assert.ErrorIs(t, err, ErrInvalidWorkoutID)
+
+	_, err = svc.GetWorkoutDetail(context.Background(), owner.String(), workoutID, DetailOptions{MaxPoints: MaxDetailPoints + 1})
+	assert.ErrorIs(t, err, ErrInvalidPointsLimit)
+}
+
+func TestDownsampleRecords(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+	records := make([]entity.RecordData, 101)
+	for i := range records {
+		records[i].Timestamp = start.Add(time.Duration(i) * time.Second)
+	}
+
+	points := DownsampleRecords(records, 11)
+	require.Len(t, points, 11)
+	assert.Equal(t, records[0].Timestamp, points[0].Timestamp)
+	assert.Equal(t, records[50].Timestamp, points[5].Timestamp)
+	assert.Equal(t, records[100].Timestamp, points[10].Timestamp)
+
+	assert.Len(t, DownsampleRecords(records, 500), 101)
+	assert.Nil(t, DownsampleRecords(records, 0))
+}
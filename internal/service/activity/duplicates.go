@@ -0,0 +1,191 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/entity"
+)
+
+const (
+	DuplicateSkip  = "skip"  // сообщить о дубликате и ничего не сохранять
+	DuplicateMerge = "merge" // дополнить существующую тренировку данными из файла
+
+	// Пороги нечёткого совпадения: одна и та же тренировка с двух устройств
+	// стартует почти одновременно и даёт близкие время и дистанцию
+	duplicateStartWindow       = 2 * time.Minute
+	duplicateDurationTolerance = 0.05
+	duplicateMinDuration       = time.Minute
+	duplicateDistanceTolerance = 0.03
+	duplicateMinDistanceKm     = 0.1
+
+	// mergeMaxGap - максимальная разница во времени между точками двух устройств при слиянии
+	mergeMaxGap = 2 * time.Second
+)
+
+var (
+	ErrInvalidDuplicateMode = errors.New("invalid on_duplicate mode, want skip or merge")
+)
+
+// findDuplicate ищет уже сохранённую тренировку пользователя, совпадающую с новой:
+// сначала по хэшу исходного файла (exact = true), затем по времени старта,
+// продолжительности и дистанции
+func (s *WorkoutService) findDuplicate(ctx context.Context, w *entity.Workout) (*entity.Workout, bool, error) {
+	if w.FileHash != "" {
+		existing, err := s.Activity.FindWorkoutByHash(ctx, w.UserID, w.FileHash)
+		if err == nil {
+			return existing, true, nil
+		}
+		if !errors.Is(err, storage.ErrWorkoutNotFound) {
+			return nil, false, err
+		}
+	}
+
+	if w.StartTime.IsZero() {
+		return nil, false, nil
+	}
+
+	candidates, err := s.Activity.FindWorkoutsByStartTime(ctx, w.UserID,
+		w.StartTime.Add(-duplicateStartWindow), w.StartTime.Add(duplicateStartWindow))
+	if err != nil {
+		return nil, false, err
+	}
+
+	for i := range candidates {
+		if isSimilarWorkout(&candidates[i], w) {
+			return &candidates[i], false, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// isSimilarWorkout сравнивает продолжительность и дистанцию двух тренировок с допуском
+func isSimilarWorkout(a, b *entity.Workout) bool {
+	durationTolerance := max(duplicateMinDuration, time.Duration(float64(max(a.Duration, b.Duration))*duplicateDurationTolerance))
+	if absDuration(a.Duration-b.Duration) > durationTolerance {
+		return false
+	}
+
+	da, errA := strconv.ParseFloat(a.Distance, 64)
+	db, errB := strconv.ParseFloat(b.Distance, 64)
+	if errA != nil || errB != nil {
+		return false
+	}
+	distanceTolerance := math.Max(duplicateMinDistanceKm, math.Max(da, db)*duplicateDistanceTolerance)
+
+	return math.Abs(da-db) <= distanceTolerance
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// mergeWorkout дополняет существующую тренировку данными той же тренировки с другого устройства.
+// Название, описание и круги существующей тренировки сохраняются, пустые метрики
+// заполняются из нового файла, а посекундные записи объединяются по времени
+func (s *WorkoutService) mergeWorkout(ctx context.Context, existing, incoming *entity.Workout) (*entity.Workout, error) {
+	records, err := s.Activity.GetTrackPoints(ctx, existing.UserID, existing.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *existing
+	if merged.AvgHeartRate == 0 {
+		merged.AvgHeartRate = incoming.AvgHeartRate
+	}
+	if merged.MaxHeartRate == 0 {
+		merged.MaxHeartRate = incoming.MaxHeartRate
+	}
+	if merged.AvgCadence == 0 {
+		merged.AvgCadence = incoming.AvgCadence
+	}
+	if merged.Calories == 0 {
+		merged.Calories = incoming.Calories
+	}
+	if merged.StartTime.IsZero() {
+		merged.StartTime = incoming.StartTime
+	}
+	if merged.FileHash == "" {
+		merged.FileHash = incoming.FileHash
+	}
+
+	// Тренировка собрана из нескольких файлов, повторный разбор одного из них её испортит
+	merged.MergedAt = time.Now()
+
+	merged.RecordData = mergeRecords(records, incoming.RecordData)
+	if len(records) == 0 && len(incoming.Laps) > 0 {
+		merged.Laps = incoming.Laps
+	} else {
+		// nil - круги в базе не трогаем
+		merged.Laps = nil
+	}
+
+	if err := s.Activity.UpdateWorkoutData(ctx, &merged); err != nil {
+		return nil, err
+	}
+
+	merged.RecordData, merged.Laps = nil, nil
+	return &merged, nil
+}
+
+// mergeRecords объединяет два потока записей одной тренировки: за основу берётся
+// более подробный, а пропущенные в нём пульс, каденс и мощность берутся
+// из ближайшей по времени записи второго потока
+func mergeRecords(a, b []entity.RecordData) []entity.RecordData {
+	base, other := a, b
+	if len(b) > len(a) {
+		base, other = b, a
+	}
+
+	result := make([]entity.RecordData, len(base))
+	copy(result, base)
+	if len(other) == 0 {
+		return result
+	}
+
+	for i := range result {
+		r := &result[i]
+		if r.HeartRate != 0 && r.Cadence != 0 && r.Power != 0 {
+			continue
+		}
+
+		// Ближайшая по времени запись второго потока
+		j := sort.Search(len(other), func(k int) bool { return !other[k].Timestamp.Before(r.Timestamp) })
+		nearest := -1
+		for _, k := range []int{j - 1, j} {
+			if k < 0 || k >= len(other) {
+				continue
+			}
+			if absDuration(other[k].Timestamp.Sub(r.Timestamp)) > mergeMaxGap {
+				continue
+			}
+			if nearest == -1 || absDuration(other[k].Timestamp.Sub(r.Timestamp)) < absDuration(other[nearest].Timestamp.Sub(r.Timestamp)) {
+				nearest = k
+			}
+		}
+		if nearest == -1 {
+			continue
+		}
+
+		o := other[nearest]
+		if r.HeartRate == 0 {
+			r.HeartRate = o.HeartRate
+		}
+		if r.Cadence == 0 {
+			r.Cadence = o.Cadence
+		}
+		if r.Power == 0 {
+			r.Power = o.Power
+		}
+	}
+
+	return result
+}
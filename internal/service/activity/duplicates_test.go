@@ -0,0 +1,133 @@
+package activity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTx считает транзакции и выполняет fn без них
+type countingTx struct{ calls int }
+
+func (c *countingTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	c.calls++
+	return fn(ctx)
+}
+
+func TestUploadFiles_Duplicates(t *testing.T) {
+	repo := &savingRepo{}
+	svc := NewWorkoutService(repo, nil)
+	tx := &countingTx{}
+	svc.Tx = tx
+	opts := UploadOptions{UserID: uuid.Must(uuid.NewV4()).String()}
+	ctx := context.Background()
+
+	first, err := svc.UploadFiles(ctx, []UploadedFile{{Name: "run.gpx", Data: []byte(testGPX)}}, opts)
+	require.NoError(t, err)
+	require.Equal(t, dto.UploadStatusOK, first[0].Status)
+	savedID := first[0].Workout.ID
+
+	// Тот же файл повторно
+	again, err := svc.UploadFiles(ctx, []UploadedFile{{Name: "run-copy.gpx", Data: []byte(testGPX)}}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, dto.UploadStatusDuplicate, again[0].Status)
+	assert.Equal(t, savedID, again[0].DuplicateOf)
+
+	// Та же тренировка с другого устройства: другой файл, близкие старт, время и дистанция
+	otherDevice := strings.ReplaceAll(testGPX, `creator="test"`, `creator="phone"`)
+	otherDevice = strings.ReplaceAll(otherDevice, "<gpxtpx:hr>120</gpxtpx:hr>", "")
+
+	fuzzy, err := svc.UploadFiles(ctx, []UploadedFile{{Name: "phone.gpx", Data: []byte(otherDevice)}}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, dto.UploadStatusDuplicate, fuzzy[0].Status)
+	assert.Equal(t, savedID, fuzzy[0].DuplicateOf)
+
+	opts.OnDuplicate = DuplicateMerge
+	merged, err := svc.UploadFiles(ctx, []UploadedFile{{Name: "phone.gpx", Data: []byte(otherDevice)}}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, dto.UploadStatusMerged, merged[0].Status)
+	assert.Equal(t, savedID, merged[0].Workout.ID)
+	require.Len(t, repo.updated, 1)
+	assert.False(t, repo.updated[0].MergedAt.IsZero(), "слитая тренировка не пересобирается из одного файла")
+	assert.Len(t, repo.created, 1)
+	assert.Equal(t, 1, tx.calls, "слияние выполняется в транзакции")
+
+	opts.OnDuplicate = "replace"
+	_, err = svc.UploadFiles(ctx, nil, opts)
+	assert.ErrorIs(t, err, ErrInvalidDuplicateMode)
+}
+
+// slowSearchRepo замедляет поиск дубликатов, чтобы параллельные файлы успели пересечься
+type slowSearchRepo struct{ *savingRepo }
+
+func (r slowSearchRepo) FindWorkoutsByStartTime(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error) {
+	time.Sleep(10 * time.Millisecond)
+	return r.savingRepo.FindWorkoutsByStartTime(ctx, userID, from, to)
+}
+
+func TestUploadFiles_ArchiveDuplicates(t *testing.T) {
+	// Одна тренировка с часов и с телефона в одном архиве
+	otherDevice := strings.ReplaceAll(testGPX, `creator="test"`, `creator="phone"`)
+	otherDevice = strings.ReplaceAll(otherDevice, "<gpxtpx:hr>120</gpxtpx:hr>", "")
+	archive := zipFiles(t, map[string][]byte{
+		"watch.gpx": []byte(testGPX),
+		"phone.gpx": []byte(otherDevice),
+	}, "watch.gpx", "phone.gpx")
+
+	for _, dryRun := range []bool{false, true} {
+		repo := &savingRepo{}
+		svc := NewWorkoutService(slowSearchRepo{repo}, nil)
+		opts := UploadOptions{UserID: uuid.Must(uuid.NewV4()).String(), DryRun: dryRun}
+
+		results, err := svc.UploadFiles(context.Background(), []UploadedFile{{Name: "export.zip", Data: archive}}, opts)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		// Файлы разбираются параллельно, поэтому первым может сохраниться любой из них
+		statuses := []string{results[0].Status, results[1].Status}
+		assert.ElementsMatch(t, []string{dto.UploadStatusOK, dto.UploadStatusDuplicate}, statuses, "dry run: %v", dryRun)
+		if dryRun {
+			assert.Empty(t, repo.created)
+		} else {
+			assert.Len(t, repo.created, 1)
+		}
+	}
+}
+
+func TestIsSimilarWorkout(t *testing.T) {
+	a := &entity.Workout{Duration: 60 * time.Minute, Distance: "10.00"}
+
+	assert.True(t, isSimilarWorkout(a, &entity.Workout{Duration: 61 * time.Minute, Distance: "10.20"}))
+	assert.False(t, isSimilarWorkout(a, &entity.Workout{Duration: 70 * time.Minute, Distance: "10.00"}))
+	assert.False(t, isSimilarWorkout(a, &entity.Workout{Duration: 60 * time.Minute, Distance: "11.00"}))
+}
+
+func TestMergeRecords(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+	watch := []entity.RecordData{
+		{Timestamp: start, HeartRate: 120},
+		{Timestamp: start.Add(2 * time.Second), HeartRate: 125},
+	}
+	phone := []entity.RecordData{
+		{Timestamp: start, Distance: 0},
+		{Timestamp: start.Add(time.Second), Distance: 300},
+		{Timestamp: start.Add(2 * time.Second), Distance: 600},
+		{Timestamp: start.Add(10 * time.Second), Distance: 3000},
+	}
+
+	merged := mergeRecords(watch, phone)
+	require.Len(t, merged, 4)
+	assert.Equal(t, uint8(120), merged[0].HeartRate)
+	assert.Equal(t, uint8(120), merged[1].HeartRate)
+	assert.Equal(t, uint8(125), merged[2].HeartRate)
+	assert.Zero(t, merged[3].HeartRate)
+	assert.Equal(t, uint32(3000), merged[3].Distance)
+}
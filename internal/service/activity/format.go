@@ -0,0 +1,48 @@
+package activity
+
+import (
+	"bytes"
+)
+
+// Format - формат файла тренировки
+type Format string
+
+const (
+	FormatUnknown Format = ""
+	FormatFIT     Format = "fit"
+	FormatGPX     Format = "gpx"
+	FormatTCX     Format = "tcx"
+)
+
+// xmlSniffLimit - сколько байт от начала XML-файла просматриваем в поисках корневого элемента
+const xmlSniffLimit = 1024
+
+// DetectFormat определяет формат файла по его содержимому.
+// Имя файла не учитывается: устройства и приложения часто отдают файлы
+// с неверным или отсутствующим расширением
+func DetectFormat(data []byte) Format {
+	// Заголовок FIT: размер заголовка (12 или 14 байт), затем сигнатура ".FIT" в байтах 8-11
+	if len(data) >= 12 && (data[0] == 12 || data[0] == 14) && string(data[8:12]) == ".FIT" {
+		return FormatFIT
+	}
+
+	head := data
+	if len(head) > xmlSniffLimit {
+		head = head[:xmlSniffLimit]
+	}
+	// Пропускаем BOM и пробельные символы перед XML
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimSpace(head)
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return FormatUnknown
+	}
+
+	switch {
+	case bytes.Contains(head, []byte("<TrainingCenterDatabase")):
+		return FormatTCX
+	case bytes.Contains(head, []byte("<gpx")):
+		return FormatGPX
+	default:
+		return FormatUnknown
+	}
+}
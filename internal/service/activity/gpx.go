@@ -0,0 +1,150 @@
+package activity
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// Структуры GPX 1.1. Элементы сопоставляются по локальному имени,
+// поэтому префикс пространства имён расширения (gpxtpx:, ns3: ...) не важен
+type gpxFile struct {
+	XMLName  xml.Name   `xml:"gpx"`
+	Metadata gpxMeta    `xml:"metadata"`
+	Tracks   []gpxTrack `xml:"trk"`
+}
+
+type gpxMeta struct {
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Type     string       `xml:"type"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat        float64       `xml:"lat,attr"`
+	Lon        float64       `xml:"lon,attr"`
+	Ele        *float64      `xml:"ele"`
+	Time       string        `xml:"time"`
+	Extensions gpxExtensions `xml:"extensions"`
+}
+
+type gpxExtensions struct {
+	TrackPoint gpxTrackPointExtension `xml:"TrackPointExtension"`
+}
+
+// gpxTrackPointExtension - Garmin TrackPointExtension v1/v2
+type gpxTrackPointExtension struct {
+	ATemp *float64 `xml:"atemp"`
+	HR    *float64 `xml:"hr"`
+	Cad   *float64 `xml:"cad"`
+}
+
+// parseGPX разбирает GPX-файл в ту же структуру ActivityData, что и FIT
+func parseGPX(raw []byte) (*entity.ActivityData, error) {
+	var gpx gpxFile
+	if err := xml.NewDecoder(bytes.NewReader(raw)).Decode(&gpx); err != nil {
+		return nil, fmt.Errorf("%w: Ошибка при парсинге GPX-файла: %v", ErrParseFile, err)
+	}
+
+	data := &entity.ActivityData{Sport: typedef.SportGeneric}
+
+	for i, trk := range gpx.Tracks {
+		if i == 0 && trk.Type != "" {
+			data.Sport = sportFromString(trk.Type)
+		}
+		for _, seg := range trk.Segments {
+			for _, pt := range seg.Points {
+				record, err := gpxRecord(pt)
+				if err != nil {
+					return nil, err
+				}
+				data.Records = append(data.Records, record)
+			}
+		}
+	}
+
+	if len(data.Records) == 0 {
+		return nil, fmt.Errorf("%w: в GPX-файле нет точек трека", ErrParseFile)
+	}
+
+	if data.Records[0].Timestamp.IsZero() && gpx.Metadata.Time != "" {
+		if t, err := time.Parse(time.RFC3339, gpx.Metadata.Time); err == nil {
+			data.Timestamp = t
+		}
+	}
+
+	// Как и в FIT, для бега и ходьбы устройство пишет каденс одной ноги
+	for i := range data.Records {
+		data.Records[i].Cadence = stepCadence(data.Records[i].Cadence, data.Sport)
+	}
+
+	fillRecordDistance(data.Records)
+	summarizeRecords(data)
+
+	return data, nil
+}
+
+func gpxRecord(pt gpxPoint) (entity.RecordData, error) {
+	record := entity.RecordData{
+		PositionLat: utils.DegreesToSemicircles(pt.Lat),
+		PositionLon: utils.DegreesToSemicircles(pt.Lon),
+	}
+
+	if pt.Time != "" {
+		t, err := time.Parse(time.RFC3339, strings.TrimSpace(pt.Time))
+		if err != nil {
+			return record, fmt.Errorf("%w: некорректное время точки %q", ErrParseFile, pt.Time)
+		}
+		record.Timestamp = t
+	}
+
+	if pt.Ele != nil {
+		record.Altitude = utils.MetersToAltitude(*pt.Ele)
+	}
+
+	ext := pt.Extensions.TrackPoint
+	if ext.HR != nil {
+		record.HeartRate = uint8(math.Min(math.Round(*ext.HR), FIT_UINT8_INVALID-1))
+	}
+	if ext.Cad != nil {
+		record.Cadence = uint16(math.Min(math.Round(*ext.Cad), FIT_UINT8_INVALID-1))
+	}
+	if ext.ATemp != nil {
+		record.Temperature = int8(math.Max(math.Min(math.Round(*ext.ATemp), FIT_SINT8_INVALID-1), -128))
+	}
+
+	return record, nil
+}
+
+// sportFromString сопоставляет тип трека из GPX/TCX с видом спорта FIT
+func sportFromString(s string) typedef.Sport {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "running", "run", "trail_running", "treadmill_running", "1":
+		return typedef.SportRunning
+	case "cycling", "biking", "ride", "road_biking", "mountain_biking", "2":
+		return typedef.SportCycling
+	case "walking", "walk", "11":
+		return typedef.SportWalking
+	case "hiking", "hike", "17":
+		return typedef.SportHiking
+	case "swimming", "swim", "open_water_swimming", "5":
+		return typedef.SportSwimming
+	default:
+		return typedef.SportGeneric
+	}
+}
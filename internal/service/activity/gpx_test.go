@@ -0,0 +1,73 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/typedef"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <trk>
+    <name>Morning Run</name>
+    <type>running</type>
+    <trkseg>
+      <trkpt lat="55.750000" lon="37.610000">
+        <ele>150.0</ele>
+        <time>2025-06-12T07:00:00Z</time>
+        <extensions><gpxtpx:TrackPointExtension>
+          <gpxtpx:atemp>18</gpxtpx:atemp><gpxtpx:hr>120</gpxtpx:hr><gpxtpx:cad>85</gpxtpx:cad>
+        </gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+      <trkpt lat="55.750900" lon="37.610000">
+        <ele>152.0</ele>
+        <time>2025-06-12T07:00:20Z</time>
+        <extensions><gpxtpx:TrackPointExtension>
+          <gpxtpx:hr>140</gpxtpx:hr><gpxtpx:cad>87</gpxtpx:cad>
+        </gpxtpx:TrackPointExtension></extensions>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, FormatGPX, DetectFormat([]byte("\xef\xbb\xbf\n"+testGPX)))
+	assert.Equal(t, FormatFIT, DetectFormat([]byte{14, 0x20, 0, 0, 0, 0, 0, 0, '.', 'F', 'I', 'T', 0, 0}))
+	assert.Equal(t, FormatUnknown, DetectFormat([]byte("hello")))
+	assert.Equal(t, FormatUnknown, DetectFormat(nil))
+}
+
+func TestParseActivity_GPX(t *testing.T) {
+	data, err := ParseActivity([]byte(testGPX))
+	require.NoError(t, err)
+	require.Len(t, data.Records, 2)
+
+	assert.Equal(t, typedef.SportRunning, data.Sport)
+	assert.Equal(t, time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC), data.Timestamp)
+	assert.Equal(t, uint32(20000), data.TotalTimerTime)
+
+	// 0.0009° широты ≈ 100 м
+	assert.InDelta(t, 10008, float64(data.TotalDistance), 10)
+	assert.Equal(t, uint8(130), data.AvgHeartRate)
+	assert.Equal(t, uint8(140), data.MaxHeartRate)
+	assert.Equal(t, uint16(172), data.AvgCadence)
+	assert.Equal(t, uint16(2), data.TotalAscent)
+
+	first := data.Records[0]
+	assert.Equal(t, int8(18), first.Temperature)
+	assert.Equal(t, uint16(170), first.Cadence)
+	assert.NotZero(t, first.PositionLat)
+	assert.InDelta(t, 5000, float64(data.Records[1].Speed), 5)
+}
+
+func TestParseActivity_Unsupported(t *testing.T) {
+	_, err := ParseActivity([]byte("<html></html>"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = ParseActivity([]byte(`<gpx><trk><trkseg></trkseg></trk></gpx>`))
+	assert.ErrorIs(t, err, ErrParseFile)
+}
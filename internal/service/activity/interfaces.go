@@ -3,13 +3,40 @@ package activity
 import (
 	"context"
 	"github.com/gofrs/uuid/v5"
+	"time"
 	"workout/internal/dto"
 	"workout/internal/entity"
 )
 
 type Activity interface {
-	GetWorkouts(ctx context.Context, userID uuid.UUID) ([]entity.Workout, error)
+	GetWorkouts(ctx context.Context, userID uuid.UUID, f dto.WorkoutFilter) ([]entity.Workout, error)
 	CreateWorkout(ctx context.Context, workout *entity.Workout) (*entity.Workout, error)
-	GetWorkoutByID(ctx context.Context, id int64) (*entity.Workout, error)
-	UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) error
+	GetWorkoutByID(ctx context.Context, id uuid.UUID) (*entity.Workout, error)
+	UpdateWorkout(ctx context.Context, u dto.UpdateWorkout) (*entity.Workout, error)
+	GetLaps(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.LapData, error)
+	GetTrackPoints(ctx context.Context, userID, workoutID uuid.UUID) ([]entity.RecordData, error)
+	FindWorkoutByHash(ctx context.Context, userID uuid.UUID, hash string) (*entity.Workout, error)
+	FindWorkoutsByStartTime(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]entity.Workout, error)
+	UpdateWorkoutData(ctx context.Context, w *entity.Workout) error
+	GetWorkoutOriginal(ctx context.Context, userID, workoutID uuid.UUID) (*entity.Workout, error)
+	ListWorkoutsWithOriginal(ctx context.Context, userID, after uuid.UUID, limit int) ([]entity.Workout, error)
+	IsCoachOf(ctx context.Context, coachID, athleteID uuid.UUID) (bool, error)
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error)
+	SoftDeleteWorkout(ctx context.Context, userID, workoutID uuid.UUID) error
+	GetDeletedWorkouts(ctx context.Context, userID uuid.UUID, deletedAfter time.Time) ([]entity.Workout, error)
+	RestoreWorkout(ctx context.Context, userID, workoutID uuid.UUID, deletedAfter time.Time) (*entity.Workout, error)
+	PurgeDeletedWorkouts(ctx context.Context, before time.Time) (int, []string, error)
+}
+
+// Transactor выполняет несколько вызовов репозитория атомарно.
+// Репозиторий узнаёт о транзакции из контекста, который получает fn
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// FileStorage хранит исходные файлы тренировок, чтобы их можно было скачать или разобрать заново
+type FileStorage interface {
+	Save(ctx context.Context, key string, data []byte) error
+	Load(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
 }
@@ -0,0 +1,146 @@
+package activity
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	DefaultListLimit = 50  // размер страницы по умолчанию
+	MaxListLimit     = 200 // максимальный размер страницы
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid workouts filter")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// cursorValues возвращают значение поля сортировки тренировки для курсора
+var cursorValues = map[string]func(w entity.Workout) string{
+	dto.SortByDate:         func(w entity.Workout) string { return w.Date.Format(time.RFC3339Nano) },
+	dto.SortByDistance:     func(w entity.Workout) string { return w.Distance },
+	dto.SortByDuration:     func(w entity.Workout) string { return strconv.FormatInt(int64(w.Duration), 10) },
+	dto.SortByAvgPace:      func(w entity.Workout) string { return strconv.Itoa(w.AvgPace) },
+	dto.SortByAvgHeartRate: func(w entity.Workout) string { return strconv.Itoa(w.AvgHeartRate) },
+	dto.SortByCalories:     func(w entity.Workout) string { return strconv.Itoa(int(w.Calories)) },
+}
+
+// cursor - содержимое курсора. Сортировка сохраняется в курсоре, чтобы нельзя было
+// продолжить выборку с другой сортировкой и получить пропуски или повторы
+type cursor struct {
+	SortBy  string    `json:"s"`
+	SortAsc bool      `json:"a,omitempty"`
+	Value   string    `json:"v"`
+	ID      uuid.UUID `json:"id"`
+}
+
+// GetWorkouts возвращает страницу тренировок пользователя и курсор следующей страницы
+// (пустой, если страница последняя)
+func (s *WorkoutService) GetWorkouts(ctx context.Context, userID string, f dto.WorkoutFilter) ([]entity.Workout, string, error) {
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := normalizeFilter(&f); err != nil {
+		return nil, "", err
+	}
+
+	// Запрашиваем на одну тренировку больше, чтобы понять, есть ли следующая страница
+	limit := f.Limit
+	f.Limit++
+
+	workouts, err := s.Activity.GetWorkouts(ctx, uid, f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(workouts) <= limit {
+		return workouts, "", nil
+	}
+
+	workouts = workouts[:limit]
+	last := workouts[len(workouts)-1]
+	next := encodeCursor(cursor{
+		SortBy:  f.SortBy,
+		SortAsc: f.SortAsc,
+		Value:   cursorValues[f.SortBy](last),
+		ID:      last.ID,
+	})
+
+	return workouts, next, nil
+}
+
+// normalizeFilter проверяет фильтр и подставляет значения по умолчанию
+func normalizeFilter(f *dto.WorkoutFilter) error {
+	if f.SortBy == "" {
+		f.SortBy = dto.SortByDate
+	}
+	if _, ok := cursorValues[f.SortBy]; !ok {
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidFilter, f.SortBy)
+	}
+
+	switch {
+	case f.Limit == 0:
+		f.Limit = DefaultListLimit
+	case f.Limit < 0 || f.Limit > MaxListLimit:
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxListLimit)
+	}
+
+	if f.Offset < 0 {
+		return fmt.Errorf("%w: offset must not be negative", ErrInvalidFilter)
+	}
+	if !f.StartDate.IsZero() && !f.EndDate.IsZero() && f.EndDate.Before(f.StartDate) {
+		return fmt.Errorf("%w: endDate is before startDate", ErrInvalidFilter)
+	}
+	if f.MinDistance < 0 || f.MaxDistance < 0 || (f.MaxDistance > 0 && f.MaxDistance < f.MinDistance) {
+		return fmt.Errorf("%w: invalid distance range", ErrInvalidFilter)
+	}
+	if f.MinDuration < 0 || f.MaxDuration < 0 || (f.MaxDuration > 0 && f.MaxDuration < f.MinDuration) {
+		return fmt.Errorf("%w: invalid duration range", ErrInvalidFilter)
+	}
+
+	if f.Cursor == "" {
+		return nil
+	}
+	if f.Offset > 0 {
+		return fmt.Errorf("%w: offset cannot be combined with cursor", ErrInvalidFilter)
+	}
+
+	c, err := decodeCursor(f.Cursor)
+	if err != nil {
+		return err
+	}
+	if c.SortBy != f.SortBy || c.SortAsc != f.SortAsc {
+		return fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+	f.After = &dto.WorkoutCursor{Value: c.Value, ID: c.ID}
+
+	return nil
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID.IsNil() {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,81 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listRepo отдаёт заранее заданные тренировки и запоминает фильтр запроса
+type listRepo struct {
+	Activity
+	workouts []entity.Workout
+	filter   dto.WorkoutFilter
+}
+
+func (r *listRepo) GetWorkouts(_ context.Context, _ uuid.UUID, f dto.WorkoutFilter) ([]entity.Workout, error) {
+	r.filter = f
+	return r.workouts[:min(len(r.workouts), f.Limit)], nil
+}
+
+func TestGetWorkouts_Cursor(t *testing.T) {
+	start := time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)
+	repo := &listRepo{}
+	for i := range 3 {
+		repo.workouts = append(repo.workouts, entity.Workout{
+			ID:       uuid.Must(uuid.NewV4()),
+			Date:     start.AddDate(0, 0, -i),
+			Distance: "5.00",
+		})
+	}
+	svc := NewWorkoutService(repo, nil)
+	userID := uuid.Must(uuid.NewV4()).String()
+
+	page, next, err := svc.GetWorkouts(context.Background(), userID, dto.WorkoutFilter{Limit: 2, SortBy: dto.SortByDistance})
+	require.NoError(t, err)
+	assert.Len(t, page, 2)
+	require.NotEmpty(t, next)
+	assert.Equal(t, 3, repo.filter.Limit, "запрашивается на одну строку больше")
+
+	_, _, err = svc.GetWorkouts(context.Background(), userID, dto.WorkoutFilter{Limit: 2, SortBy: dto.SortByDistance, Cursor: next})
+	require.NoError(t, err)
+	require.NotNil(t, repo.filter.After)
+	assert.Equal(t, "5.00", repo.filter.After.Value)
+	assert.Equal(t, page[1].ID, repo.filter.After.ID)
+
+	// Курсор нельзя использовать с другой сортировкой
+	_, _, err = svc.GetWorkouts(context.Background(), userID, dto.WorkoutFilter{Limit: 2, Cursor: next})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	page, next, err = svc.GetWorkouts(context.Background(), userID, dto.WorkoutFilter{})
+	require.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.Empty(t, next)
+	assert.Equal(t, dto.SortByDate, repo.filter.SortBy)
+	assert.Equal(t, DefaultListLimit+1, repo.filter.Limit)
+}
+
+func TestGetWorkouts_InvalidFilter(t *testing.T) {
+	svc := NewWorkoutService(&listRepo{}, nil)
+	userID := uuid.Must(uuid.NewV4()).String()
+
+	for name, f := range map[string]dto.WorkoutFilter{
+		"sort":     {SortBy: "name; DROP TABLE workouts"},
+		"limit":    {Limit: MaxListLimit + 1},
+		"offset":   {Offset: -1},
+		"dates":    {StartDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		"distance": {MinDistance: 10, MaxDistance: 5},
+		"duration": {MinDuration: time.Hour, MaxDuration: time.Minute},
+		"cursor":   {Cursor: "garbage"},
+		"both":     {Cursor: encodeCursor(cursor{SortBy: dto.SortByDate, ID: uuid.Must(uuid.NewV4())}), Offset: 10},
+	} {
+		_, _, err := svc.GetWorkouts(context.Background(), userID, f)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,163 @@
+package activity
+
+import (
+	"math"
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+// earthRadius - средний радиус Земли в метрах
+const earthRadius = 6371008.8
+
+// distanceBetween возвращает расстояние между двумя точками в метрах (формула гаверсинусов)
+func distanceBetween(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// fillRecordDistance рассчитывает накопленную дистанцию и скорость записей по GPS,
+// если файл не содержит их явно (GPX)
+func fillRecordDistance(records []entity.RecordData) {
+	var (
+		total    float64
+		prev     *entity.RecordData
+		prevTime time.Time
+	)
+
+	for i := range records {
+		r := &records[i]
+		if r.PositionLat == 0 && r.PositionLon == 0 {
+			r.Distance = uint32(math.Round(total * 100))
+			continue
+		}
+
+		if prev != nil {
+			step := distanceBetween(
+				utils.SemicirclesToDegrees(prev.PositionLat), utils.SemicirclesToDegrees(prev.PositionLon),
+				utils.SemicirclesToDegrees(r.PositionLat), utils.SemicirclesToDegrees(r.PositionLon),
+			)
+			total += step
+
+			if dt := r.Timestamp.Sub(prevTime).Seconds(); dt > 0 && r.Speed == 0 {
+				r.Speed = uint16(math.Min(math.Round(step/dt*1000), FIT_UINT16_INVALID-1))
+			}
+		}
+
+		r.Distance = uint32(math.Round(total * 100))
+		prev, prevTime = r, r.Timestamp
+	}
+}
+
+// summarizeRecords заполняет итоговые метрики активности по записям.
+// Уже заполненные поля (например, из итогов круга TCX) не перезаписываются
+func summarizeRecords(data *entity.ActivityData) {
+	if len(data.Records) == 0 {
+		return
+	}
+
+	first, last := data.Records[0], data.Records[len(data.Records)-1]
+
+	if data.Timestamp.IsZero() {
+		data.Timestamp = first.Timestamp
+	}
+	if data.LocalTimestamp.IsZero() {
+		data.LocalTimestamp = data.Timestamp
+	}
+	if data.TotalDistance == 0 {
+		data.TotalDistance = last.Distance
+	}
+	if data.TotalTimerTime == 0 && !last.Timestamp.IsZero() {
+		data.TotalTimerTime = uint32(last.Timestamp.Sub(first.Timestamp) / time.Millisecond)
+	}
+	if data.AvgSpeed == 0 && data.TotalTimerTime > 0 {
+		// см/мс * 10000 = мм/с
+		data.AvgSpeed = uint16(math.Min(float64(data.TotalDistance)*10000/float64(data.TotalTimerTime), FIT_UINT16_INVALID-1))
+	}
+
+	var (
+		hrSum, hrCount   int
+		cadSum, cadCount int
+		pwrSum, pwrCount int
+		maxHR            uint8
+		maxSpeed, maxPwr uint16
+		maxCad           uint16
+		ascent, descent  float64
+		prevAlt          uint16
+		hasStart         bool
+	)
+
+	for _, r := range data.Records {
+		if r.HeartRate > 0 {
+			hrSum += int(r.HeartRate)
+			hrCount++
+			maxHR = max(maxHR, r.HeartRate)
+		}
+		if r.Cadence > 0 {
+			cadSum += int(r.Cadence)
+			cadCount++
+			maxCad = max(maxCad, r.Cadence)
+		}
+		if r.Power > 0 {
+			pwrSum += int(r.Power)
+			pwrCount++
+			maxPwr = max(maxPwr, r.Power)
+		}
+		maxSpeed = max(maxSpeed, r.Speed)
+
+		if r.Altitude != 0 {
+			if prevAlt != 0 {
+				delta := utils.AltitudeToMeters(r.Altitude) - utils.AltitudeToMeters(prevAlt)
+				if delta > 0 {
+					ascent += delta
+				} else {
+					descent -= delta
+				}
+			}
+			prevAlt = r.Altitude
+		}
+
+		if r.PositionLat != 0 || r.PositionLon != 0 {
+			if !hasStart {
+				data.StartPositionLat, data.StartPositionLon = r.PositionLat, r.PositionLon
+				hasStart = true
+			}
+			data.EndPositionLat, data.EndPositionLon = r.PositionLat, r.PositionLon
+		}
+	}
+
+	if data.AvgHeartRate == 0 && hrCount > 0 {
+		data.AvgHeartRate = uint8(hrSum / hrCount)
+	}
+	if data.MaxHeartRate == 0 {
+		data.MaxHeartRate = maxHR
+	}
+	if data.AvgCadence == 0 && cadCount > 0 {
+		data.AvgCadence = uint16(cadSum / cadCount)
+	}
+	if data.MaxCadence == 0 {
+		data.MaxCadence = maxCad
+	}
+	if data.AvgPower == 0 && pwrCount > 0 {
+		data.AvgPower = uint16(pwrSum / pwrCount)
+	}
+	if data.MaxPower == 0 {
+		data.MaxPower = maxPwr
+	}
+	if data.MaxSpeed == 0 {
+		data.MaxSpeed = maxSpeed
+	}
+	data.MaxSpeedFromRecords = maxSpeed
+	if data.TotalAscent == 0 {
+		data.TotalAscent = uint16(math.Round(ascent))
+	}
+	if data.TotalDescent == 0 {
+		data.TotalDescent = uint16(math.Round(descent))
+	}
+}
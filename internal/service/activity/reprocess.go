@@ -0,0 +1,250 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	ErrInvalidUserID = errors.New("invalid user id")
+	ErrMergedWorkout = errors.New("workout is merged from several files and cannot be rebuilt from its original")
+	ErrEditedWorkout = errors.New("workout data was edited by the user and is not rebuilt from its original")
+)
+
+// reprocessBatchSize - сколько тренировок читается из базы за один запрос
+const reprocessBatchSize = 100
+
+// ReprocessOptions - параметры повторного разбора исходных файлов
+type ReprocessOptions struct {
+	UserID string // пусто - тренировки всех пользователей
+	DryRun bool   // только посчитать изменения, ничего не сохраняя
+}
+
+// ReprocessResult - результат повторного разбора одной тренировки
+type ReprocessResult struct {
+	WorkoutID     uuid.UUID
+	UserID        uuid.UUID
+	Status        string            // dto.ReprocessStatusUpdated, Unchanged, Skipped или Error
+	Changes       []dto.FieldChange // изменившиеся поля сводки
+	PointsChanged int               // число точек трека, которые изменятся или изменились
+	LapsChanged   int               // число кругов, которые изменятся или изменились
+	Err           error             // причина ошибки или пропуска
+}
+
+// Reprocess заново разбирает сохранённые исходные файлы и обновляет рассчитанные поля,
+// трек и круги тренировок. Нужен, чтобы исправления парсера применялись к уже
+// загруженным тренировкам без повторной загрузки файлов.
+// Название и описание, заданные пользователем, не меняются. Пропускаются тренировки, слитые
+// с дубликатом с другого устройства (в исходном файле нет данных второго устройства),
+// тренировки с ручными правками полей из файла и тренировки, изменённые во время разбора
+func (s *WorkoutService) Reprocess(ctx context.Context, opts ReprocessOptions) ([]*ReprocessResult, error) {
+	if s.Files == nil {
+		return nil, ErrOriginalNotFound
+	}
+
+	var userID uuid.UUID
+	if opts.UserID != "" {
+		uid, err := uuid.FromString(opts.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidUserID, err)
+		}
+		userID = uid
+	}
+
+	var (
+		results []*ReprocessResult
+		after   uuid.UUID
+	)
+	for {
+		workouts, err := s.Activity.ListWorkoutsWithOriginal(ctx, userID, after, reprocessBatchSize)
+		if err != nil {
+			return results, err
+		}
+
+		for i := range workouts {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			results = append(results, s.reprocessWorkout(ctx, &workouts[i], opts.DryRun))
+		}
+
+		if len(workouts) < reprocessBatchSize {
+			return results, nil
+		}
+		after = workouts[len(workouts)-1].ID
+	}
+}
+
+// reprocessWorkout разбирает исходный файл одной тренировки и сравнивает результат с сохранённым
+func (s *WorkoutService) reprocessWorkout(ctx context.Context, w *entity.Workout, dryRun bool) *ReprocessResult {
+	result := &ReprocessResult{WorkoutID: w.ID, UserID: w.UserID}
+	failed := func(err error) *ReprocessResult {
+		result.Status = dto.ReprocessStatusError
+		result.Err = err
+		return result
+	}
+
+	if !w.MergedAt.IsZero() {
+		result.Status = dto.ReprocessStatusSkipped
+		result.Err = ErrMergedWorkout
+		return result
+	}
+	if !w.EditedAt.IsZero() {
+		// Пользователь исправил данные вручную, разбор файла вернул бы старые значения
+		result.Status = dto.ReprocessStatusSkipped
+		result.Err = ErrEditedWorkout
+		return result
+	}
+
+	raw, err := s.Files.Load(ctx, w.OriginalKey)
+	if err != nil {
+		return failed(err)
+	}
+
+	data, err := ParseActivity(raw)
+	if err != nil {
+		return failed(err)
+	}
+
+	fresh := dto.NewWorkoutFromActivity(w.UserID, data)
+	fresh.ID = w.ID
+	fresh.FileHash = w.FileHash
+	// Сохраняем, только если тренировку не изменили после чтения
+	fresh.Version = w.Version
+
+	// Сравнение с сохранёнными данными и их замена выполняются в одной транзакции
+	if err := s.withinTx(ctx, func(ctx context.Context) error {
+		return s.applyReprocess(ctx, w, fresh, result, dryRun)
+	}); err != nil {
+		if errors.Is(err, storage.ErrVersionConflict) {
+			// Правка пользователя пришла между чтением и сохранением - её не затираем
+			result.Status = dto.ReprocessStatusSkipped
+			result.Err = err
+			return result
+		}
+		return failed(err)
+	}
+	return result
+}
+
+// applyReprocess заполняет result различиями между сохранённой тренировкой и fresh
+// и, если это не пробный прогон, сохраняет fresh
+func (s *WorkoutService) applyReprocess(ctx context.Context, w, fresh *entity.Workout, result *ReprocessResult, dryRun bool) error {
+	oldRecords, err := s.Activity.GetTrackPoints(ctx, w.UserID, w.ID)
+	if err != nil {
+		return err
+	}
+	oldLaps, err := s.Activity.GetLaps(ctx, w.UserID, w.ID)
+	if err != nil {
+		return err
+	}
+
+	result.Changes = diffWorkout(w, fresh)
+	result.PointsChanged = diffRecords(oldRecords, fresh.RecordData)
+	result.LapsChanged = diffLaps(oldLaps, fresh.Laps)
+
+	if len(result.Changes) == 0 && result.PointsChanged == 0 && result.LapsChanged == 0 {
+		result.Status = dto.ReprocessStatusUnchanged
+		return nil
+	}
+
+	result.Status = dto.ReprocessStatusUpdated
+	if dryRun {
+		return nil
+	}
+
+	// nil - оставить как есть, пустой срез - удалить сохранённые
+	if result.PointsChanged == 0 {
+		fresh.RecordData = nil
+	} else if fresh.RecordData == nil {
+		fresh.RecordData = []entity.RecordData{}
+	}
+	if result.LapsChanged == 0 {
+		fresh.Laps = nil
+	} else if fresh.Laps == nil {
+		fresh.Laps = []entity.LapData{}
+	}
+
+	return s.Activity.UpdateWorkoutData(ctx, fresh)
+}
+
+// diffWorkout сравнивает рассчитанные из файла поля сводки
+func diffWorkout(old, fresh *entity.Workout) []dto.FieldChange {
+	var changes []dto.FieldChange
+	add := func(field string, a, b any) {
+		oldValue, newValue := fmt.Sprint(a), fmt.Sprint(b)
+		if oldValue != newValue {
+			changes = append(changes, dto.FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("sport_type", old.SportType, fresh.SportType)
+	add("duration", old.Duration, fresh.Duration)
+	add("distance", old.Distance, fresh.Distance)
+	add("avg_pace", old.AvgPace, fresh.AvgPace)
+	add("avg_heart_rate", old.AvgHeartRate, fresh.AvgHeartRate)
+	add("max_heart_rate", old.MaxHeartRate, fresh.MaxHeartRate)
+	add("avg_cadence", old.AvgCadence, fresh.AvgCadence)
+	add("calories", old.Calories, fresh.Calories)
+	add("start_time", formatTime(old.StartTime), formatTime(fresh.StartTime))
+
+	return changes
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// coordTolerance - допустимое расхождение координат в semicircles. В базе координаты
+// хранятся в градусах с 8 знаками, а один semicircle - около 8.4e-8 градуса,
+// поэтому после чтения из базы значение может отличаться на единицу
+const coordTolerance = 1
+
+// diffRecords возвращает число точек трека, которые отличаются после разбора.
+// Сравниваются все сохраняемые поля. Скорость, дистанция и высота хранятся с точностью
+// своих единиц и сравниваются точно, координаты - с точностью хранения
+func diffRecords(old, fresh []entity.RecordData) int {
+	changed := absInt(len(old) - len(fresh))
+	for i := range min(len(old), len(fresh)) {
+		a, b := old[i], fresh[i]
+		if !a.Timestamp.Equal(b.Timestamp) || a.HeartRate != b.HeartRate || a.Cadence != b.Cadence ||
+			a.Power != b.Power || a.Distance != b.Distance || a.Temperature != b.Temperature ||
+			a.Speed != b.Speed || a.Altitude != b.Altitude ||
+			absInt(int(a.PositionLat)-int(b.PositionLat)) > coordTolerance ||
+			absInt(int(a.PositionLon)-int(b.PositionLon)) > coordTolerance {
+			changed++
+		}
+	}
+	return changed
+}
+
+// diffLaps возвращает число кругов, которые отличаются после разбора
+func diffLaps(old, fresh []entity.LapData) int {
+	changed := absInt(len(old) - len(fresh))
+	for i := range min(len(old), len(fresh)) {
+		a, b := old[i], fresh[i]
+		if a.TotalTimerTime != b.TotalTimerTime || a.TotalDistance != b.TotalDistance ||
+			a.AvgHeartRate != b.AvgHeartRate || a.MaxHeartRate != b.MaxHeartRate ||
+			a.AvgCadence != b.AvgCadence || a.MaxCadence != b.MaxCadence || a.LapTrigger != b.LapTrigger {
+			changed++
+		}
+	}
+	return changed
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/filestorage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *savingRepo) GetLaps(_ context.Context, _, workoutID uuid.UUID) ([]entity.LapData, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID == workoutID {
+			return w.Laps, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *savingRepo) ListWorkoutsWithOriginal(_ context.Context, userID, after uuid.UUID, limit int) ([]entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []entity.Workout
+	for _, w := range r.created {
+		if w.OriginalKey != "" && (userID.IsNil() || w.UserID == userID) && w.ID.String() > after.String() && len(result) < limit {
+			result = append(result, *w)
+		}
+	}
+	return result, nil
+}
+
+func TestReprocess(t *testing.T) {
+	files, err := filestorage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	repo := &savingRepo{}
+	svc := NewWorkoutService(repo, files)
+	userID := uuid.Must(uuid.NewV4())
+
+	results, err := svc.UploadFiles(context.Background(), []UploadedFile{
+		{Name: "run.tcx", Data: []byte(testTCX)},
+	}, UploadOptions{UserID: userID.String()})
+	require.NoError(t, err)
+	require.Equal(t, dto.UploadStatusOK, results[0].Status)
+
+	// Только что загруженная тренировка совпадает с результатом разбора
+	report, err := svc.Reprocess(context.Background(), ReprocessOptions{UserID: userID.String()})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, dto.ReprocessStatusUnchanged, report[0].Status)
+
+	// Имитируем данные, сохранённые старой версией парсера
+	w := repo.created[0]
+	w.AvgHeartRate = 70
+	w.RecordData = append([]entity.RecordData(nil), w.RecordData...)
+	w.RecordData[0].HeartRate = 0
+
+	report, err = svc.Reprocess(context.Background(), ReprocessOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, dto.ReprocessStatusUpdated, report[0].Status)
+	assert.Equal(t, 1, report[0].PointsChanged)
+	assert.Zero(t, report[0].LapsChanged)
+	require.Len(t, report[0].Changes, 1)
+	assert.Equal(t, "avg_heart_rate", report[0].Changes[0].Field)
+	assert.Equal(t, "70", report[0].Changes[0].Old)
+	assert.Empty(t, repo.updated)
+
+	report, err = svc.Reprocess(context.Background(), ReprocessOptions{})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, dto.ReprocessStatusUpdated, report[0].Status)
+
+	require.Len(t, repo.updated, 1)
+	updated := repo.updated[0]
+	assert.Equal(t, w.ID, updated.ID)
+	assert.NotEqual(t, 70, updated.AvgHeartRate)
+	assert.Len(t, updated.RecordData, 2)
+	assert.Nil(t, updated.Laps, "круги не изменились и не перезаписываются")
+
+	// Слитую с дубликатом тренировку не пересобрать из одного файла
+	w.MergedAt = time.Now()
+	w.AvgHeartRate = 70
+	report, err = svc.Reprocess(context.Background(), ReprocessOptions{})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, dto.ReprocessStatusSkipped, report[0].Status)
+	assert.ErrorIs(t, report[0].Err, ErrMergedWorkout)
+	assert.Len(t, repo.updated, 1)
+
+	// Ручные правки пользователя пересборка не затирает
+	w.MergedAt = time.Time{}
+	w.EditedAt = time.Now()
+	report, err = svc.Reprocess(context.Background(), ReprocessOptions{})
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, dto.ReprocessStatusSkipped, report[0].Status)
+	assert.ErrorIs(t, report[0].Err, ErrEditedWorkout)
+	assert.Len(t, repo.updated, 1)
+
+	_, err = svc.Reprocess(context.Background(), ReprocessOptions{UserID: "bad"})
+	assert.ErrorIs(t, err, ErrInvalidUserID)
+}
+
+func TestDiffRecords(t *testing.T) {
+	base := entity.RecordData{PositionLat: 665_000_000, PositionLon: 448_000_000, Speed: 3300, Altitude: 3250, Distance: 100}
+
+	// Округление координат при хранении не считается изменением
+	rounded := base
+	rounded.PositionLat++
+	rounded.PositionLon--
+	assert.Zero(t, diffRecords([]entity.RecordData{base}, []entity.RecordData{rounded}))
+
+	for name, change := range map[string]func(*entity.RecordData){
+		"speed":    func(r *entity.RecordData) { r.Speed = 3400 },
+		"altitude": func(r *entity.RecordData) { r.Altitude = 3260 },
+		"lat":      func(r *entity.RecordData) { r.PositionLat += 1000 },
+		"lon":      func(r *entity.RecordData) { r.PositionLon = 0 },
+	} {
+		fresh := base
+		change(&fresh)
+		assert.Equal(t, 1, diffRecords([]entity.RecordData{base}, []entity.RecordData{fresh}), name)
+	}
+
+	assert.Equal(t, 1, diffRecords([]entity.RecordData{base}, nil))
+}
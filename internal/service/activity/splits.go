@@ -0,0 +1,199 @@
+package activity
+
+import (
+	"errors"
+	"math"
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+)
+
+const (
+	SplitUnitKm     = "km"
+	SplitUnitMi     = "mi"
+	SplitUnitCustom = "custom"
+
+	splitKmCm = 100000 // 1 км в сантиметрах
+	splitMiCm = 160934 // 1 миля в сантиметрах
+
+	SplitTypeNegative = "negative" // вторая половина быстрее первой
+	SplitTypePositive = "positive" // вторая половина медленнее первой
+	SplitTypeEven     = "even"     // половины отличаются меньше чем на evenSplitTolerance
+
+	evenSplitTolerance = 0.01
+)
+
+var (
+	ErrInvalidSplitUnit     = errors.New("invalid split unit, want km, mi or custom")
+	ErrInvalidSplitDistance = errors.New("custom split distance must be positive")
+)
+
+// SplitDistance возвращает длину отрезка в сантиметрах.
+// Для custom используется distance в метрах
+func SplitDistance(unit string, distance float64) (uint32, error) {
+	switch unit {
+	case "", SplitUnitKm:
+		return splitKmCm, nil
+	case SplitUnitMi:
+		return splitMiCm, nil
+	case SplitUnitCustom:
+		if distance <= 0 || distance > 1000000 {
+			return 0, ErrInvalidSplitDistance
+		}
+		return uint32(math.Round(distance * 100)), nil
+	default:
+		return 0, ErrInvalidSplitUnit
+	}
+}
+
+// splitAccumulator накапливает значения записей, попавших в один отрезок
+type splitAccumulator struct {
+	split          entity.SplitData
+	startTime      float64 // мс от начала тренировки
+	startDistance  float64 // см от начала тренировки
+	heartRateSum   int
+	heartRateCount int
+	cadenceSum     int
+	cadenceCount   int
+}
+
+func (a *splitAccumulator) add(r entity.RecordData) {
+	if r.HeartRate > 0 {
+		a.heartRateSum += int(r.HeartRate)
+		a.heartRateCount++
+		if r.HeartRate > a.split.MaxHeartRate {
+			a.split.MaxHeartRate = r.HeartRate
+		}
+	}
+	if r.Cadence > 0 {
+		a.cadenceSum += int(r.Cadence)
+		a.cadenceCount++
+	}
+}
+
+func (a *splitAccumulator) close(endTime, endDistance float64) entity.SplitData {
+	a.split.Distance = uint32(math.Round(endDistance - a.startDistance))
+	a.split.Duration = uint32(math.Round(endTime - a.startTime))
+	if a.heartRateCount > 0 {
+		a.split.AvgHeartRate = uint8(a.heartRateSum / a.heartRateCount)
+	}
+	if a.cadenceCount > 0 {
+		a.split.AvgCadence = uint16(a.cadenceSum / a.cadenceCount)
+	}
+	return a.split
+}
+
+// CalculateSplits разбивает тренировку на отрезки длиной splitDistance (в сантиметрах)
+// по накопленной дистанции записей. Время пересечения границы отрезка
+// интерполируется между соседними записями
+func CalculateSplits(records []entity.RecordData, splitDistance uint32) *entity.Splits {
+	result := &entity.Splits{SplitDistance: splitDistance, Splits: []entity.SplitData{}}
+	if splitDistance == 0 || len(records) == 0 {
+		return result
+	}
+
+	start := records[0].Timestamp
+	step := float64(splitDistance)
+	boundary := step
+
+	acc := &splitAccumulator{split: entity.SplitData{Number: 1}}
+
+	var (
+		prevTime, prevDistance float64
+		prevAltitude           float64
+		hasAltitude            bool
+	)
+
+	for _, r := range records {
+		t := float64(r.Timestamp.Sub(start) / time.Millisecond)
+		d := float64(r.Distance)
+		// Запись без дистанции (или с откатом назад) не двигает спортсмена
+		if d < prevDistance {
+			d = prevDistance
+		}
+
+		// Закрываем все отрезки, границы которых пересекли с прошлой записи
+		for d >= boundary {
+			bt := interpolate(prevTime, prevDistance, t, d, boundary)
+			result.Splits = append(result.Splits, acc.close(bt, boundary))
+			acc = &splitAccumulator{
+				split:         entity.SplitData{Number: acc.split.Number + 1},
+				startTime:     bt,
+				startDistance: boundary,
+			}
+			boundary += step
+		}
+
+		acc.add(r)
+
+		if r.Altitude != 0 {
+			altitude := utils.AltitudeToMeters(r.Altitude)
+			if hasAltitude {
+				if delta := altitude - prevAltitude; delta > 0 {
+					acc.split.ElevationGain += delta
+				} else {
+					acc.split.ElevationLoss -= delta
+				}
+			}
+			prevAltitude, hasAltitude = altitude, true
+		}
+
+		prevTime, prevDistance = t, d
+	}
+
+	// Последний неполный отрезок
+	if prevDistance > acc.startDistance {
+		result.Splits = append(result.Splits, acc.close(prevTime, prevDistance))
+	}
+
+	result.FirstHalf, result.SecondHalf = halves(records, start, prevDistance, prevTime)
+	result.SplitType = splitType(result.FirstHalf, result.SecondHalf)
+
+	return result
+}
+
+// halves возвращает время первой и второй половины дистанции в миллисекундах
+func halves(records []entity.RecordData, start time.Time, totalDistance, totalTime float64) (uint32, uint32) {
+	if totalDistance == 0 {
+		return 0, 0
+	}
+
+	half := totalDistance / 2
+	var prevTime, prevDistance float64
+	for _, r := range records {
+		t := float64(r.Timestamp.Sub(start) / time.Millisecond)
+		d := math.Max(float64(r.Distance), prevDistance)
+		if d >= half {
+			ht := interpolate(prevTime, prevDistance, t, d, half)
+			return uint32(math.Round(ht)), uint32(math.Round(totalTime - ht))
+		}
+		prevTime, prevDistance = t, d
+	}
+
+	return 0, 0
+}
+
+func splitType(firstHalf, secondHalf uint32) string {
+	if firstHalf == 0 || secondHalf == 0 {
+		return ""
+	}
+
+	diff := float64(secondHalf) - float64(firstHalf)
+	switch {
+	case math.Abs(diff) <= float64(firstHalf)*evenSplitTolerance:
+		return SplitTypeEven
+	case diff < 0:
+		return SplitTypeNegative
+	default:
+		return SplitTypePositive
+	}
+}
+
+// interpolate возвращает время, в которое была пройдена дистанция target,
+// считая скорость между двумя записями постоянной
+func interpolate(t1, d1, t2, d2, target float64) float64 {
+	if d2 == d1 {
+		return t2
+	}
+	return t1 + (t2-t1)*(target-d1)/(d2-d1)
+}
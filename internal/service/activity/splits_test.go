@@ -0,0 +1,82 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"workout/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stream строит записи раз в секунду с заданной скоростью (м/с) на каждом участке
+func stream(start time.Time, speeds ...float64) []entity.RecordData {
+	records := []entity.RecordData{{Timestamp: start, HeartRate: 140}}
+	var distance float64
+	for i, v := range speeds {
+		distance += v * 100
+		records = append(records, entity.RecordData{
+			Timestamp: start.Add(time.Duration(i+1) * time.Second),
+			Distance:  uint32(distance),
+			HeartRate: uint8(140 + i%20),
+		})
+	}
+	return records
+}
+
+func repeat(v float64, n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
+
+func TestCalculateSplits(t *testing.T) {
+	start := time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC)
+	// 1250 м по 5 м/с, затем 1250 м по 2.5 м/с
+	speeds := append(repeat(5, 250), repeat(2.5, 500)...)
+
+	splits := CalculateSplits(stream(start, speeds...), splitKmCm)
+	require.Len(t, splits.Splits, 3)
+
+	assert.Equal(t, 1, splits.Splits[0].Number)
+	assert.Equal(t, uint32(100000), splits.Splits[0].Distance)
+	assert.Equal(t, uint32(200000), splits.Splits[0].Duration)
+
+	// 250 м по 5 м/с + 750 м по 2.5 м/с
+	assert.Equal(t, uint32(100000), splits.Splits[1].Distance)
+	assert.Equal(t, uint32(350000), splits.Splits[1].Duration)
+
+	// Неполный последний отрезок
+	assert.Equal(t, uint32(50000), splits.Splits[2].Distance)
+	assert.Equal(t, uint32(200000), splits.Splits[2].Duration)
+	assert.NotZero(t, splits.Splits[2].AvgHeartRate)
+
+	assert.Equal(t, uint32(250000), splits.FirstHalf)
+	assert.Equal(t, uint32(500000), splits.SecondHalf)
+	assert.Equal(t, SplitTypePositive, splits.SplitType)
+}
+
+func TestCalculateSplits_Empty(t *testing.T) {
+	splits := CalculateSplits(nil, splitKmCm)
+	assert.Empty(t, splits.Splits)
+	assert.Empty(t, splits.SplitType)
+}
+
+func TestSplitDistance(t *testing.T) {
+	d, err := SplitDistance(SplitUnitMi, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(splitMiCm), d)
+
+	d, err = SplitDistance(SplitUnitCustom, 400)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(40000), d)
+
+	_, err = SplitDistance(SplitUnitCustom, 0)
+	assert.ErrorIs(t, err, ErrInvalidSplitDistance)
+
+	_, err = SplitDistance("yd", 0)
+	assert.ErrorIs(t, err, ErrInvalidSplitUnit)
+}
@@ -0,0 +1,260 @@
+package activity
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"workout/internal/entity"
+	"workout/internal/utils"
+
+	"github.com/muktihari/fit/profile/typedef"
+)
+
+// Структуры Training Center XML v2. Расширения ActivityExtension v2 (TPX, LX)
+// сопоставляются по локальному имени независимо от префикса
+type tcxFile struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string        `xml:"StartTime,attr"`
+	TotalTimeSeconds float64       `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64       `xml:"DistanceMeters"`
+	MaximumSpeed     float64       `xml:"MaximumSpeed"`
+	Calories         float64       `xml:"Calories"`
+	AvgHeartRate     *tcxValue     `xml:"AverageHeartRateBpm"`
+	MaxHeartRate     *tcxValue     `xml:"MaximumHeartRateBpm"`
+	Cadence          *float64      `xml:"Cadence"`
+	TriggerMethod    string        `xml:"TriggerMethod"`
+	Trackpoints      []tcxPoint    `xml:"Track>Trackpoint"`
+	Extensions       tcxLapExtList `xml:"Extensions"`
+}
+
+type tcxLapExtList struct {
+	LX struct {
+		AvgSpeed      *float64 `xml:"AvgSpeed"`
+		AvgRunCadence *float64 `xml:"AvgRunCadence"`
+		MaxRunCadence *float64 `xml:"MaxRunCadence"`
+	} `xml:"LX"`
+}
+
+type tcxValue struct {
+	Value float64 `xml:"Value"`
+}
+
+type tcxPoint struct {
+	Time           string    `xml:"Time"`
+	Latitude       *float64  `xml:"Position>LatitudeDegrees"`
+	Longitude      *float64  `xml:"Position>LongitudeDegrees"`
+	AltitudeMeters *float64  `xml:"AltitudeMeters"`
+	DistanceMeters *float64  `xml:"DistanceMeters"`
+	HeartRate      *tcxValue `xml:"HeartRateBpm"`
+	Cadence        *float64  `xml:"Cadence"`
+	Extensions     struct {
+		TPX struct {
+			Speed      *float64 `xml:"Speed"`
+			Watts      *float64 `xml:"Watts"`
+			RunCadence *float64 `xml:"RunCadence"`
+		} `xml:"TPX"`
+	} `xml:"Extensions"`
+}
+
+// parseTCX разбирает TCX-файл: круг <Lap> превращается в LapData, точка <Trackpoint> - в RecordData
+func parseTCX(raw []byte) (*entity.ActivityData, error) {
+	var tcx tcxFile
+	if err := xml.NewDecoder(bytes.NewReader(raw)).Decode(&tcx); err != nil {
+		return nil, fmt.Errorf("%w: Ошибка при парсинге TCX-файла: %v", ErrParseFile, err)
+	}
+
+	if len(tcx.Activities) == 0 {
+		return nil, fmt.Errorf("%w: в TCX-файле нет активностей", ErrParseFile)
+	}
+
+	// В файле может быть несколько активностей (мультиспорт), берём первую
+	act := tcx.Activities[0]
+	data := &entity.ActivityData{Sport: sportFromString(act.Sport)}
+
+	if act.ID != "" {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(act.ID)); err == nil {
+			data.Timestamp = t
+		}
+	}
+
+	var (
+		hrWeighted  float64
+		hasDistance = true
+	)
+
+	for _, l := range act.Laps {
+		lap, err := tcxLapData(l, data.Sport)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pt := range l.Trackpoints {
+			record, err := tcxRecord(pt, data.Sport)
+			if err != nil {
+				return nil, err
+			}
+			if pt.DistanceMeters == nil {
+				hasDistance = false
+			}
+			data.Records = append(data.Records, record)
+		}
+
+		// Конец круга - последняя точка трека, если она есть
+		if len(l.Trackpoints) > 0 {
+			if end := data.Records[len(data.Records)-1].Timestamp; end.After(lap.StartTime) {
+				lap.Timestamp = end
+				lap.TotalElapsedTime = uint32(end.Sub(lap.StartTime) / time.Millisecond)
+			}
+		}
+
+		data.Laps = append(data.Laps, lap)
+		data.TotalDistance += lap.TotalDistance
+		data.TotalTimerTime += lap.TotalTimerTime
+		data.TotalCalories += lap.TotalCalories
+		data.MaxHeartRate = max(data.MaxHeartRate, lap.MaxHeartRate)
+		hrWeighted += float64(lap.AvgHeartRate) * float64(lap.TotalTimerTime)
+	}
+
+	if len(data.Laps) == 0 {
+		return nil, fmt.Errorf("%w: в TCX-файле нет кругов", ErrParseFile)
+	}
+
+	if data.TotalTimerTime > 0 && hrWeighted > 0 {
+		data.AvgHeartRate = uint8(math.Round(hrWeighted / float64(data.TotalTimerTime)))
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = data.Laps[0].StartTime
+	}
+
+	// Некоторые приложения не пишут дистанцию в точках - считаем её по GPS
+	if !hasDistance {
+		fillRecordDistance(data.Records)
+	}
+	summarizeRecords(data)
+
+	return data, nil
+}
+
+// tcxLapData переводит круг TCX в LapData. RunCadence - шаги одной ноги,
+// стандартный <Cadence> - обороты педалей, он не удваивается
+func tcxLapData(l tcxLap, sport typedef.Sport) (entity.LapData, error) {
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(l.StartTime))
+	if err != nil {
+		return entity.LapData{}, fmt.Errorf("%w: некорректное время начала круга %q", ErrParseFile, l.StartTime)
+	}
+
+	timer := uint32(math.Round(l.TotalTimeSeconds * 1000))
+	lap := entity.LapData{
+		StartTime:        start,
+		Timestamp:        start.Add(time.Duration(timer) * time.Millisecond),
+		TotalElapsedTime: timer,
+		TotalTimerTime:   timer,
+		TotalDistance:    uint32(math.Round(l.DistanceMeters * 100)),
+		MaxSpeed:         speedToFit(l.MaximumSpeed),
+		TotalCalories:    uint16(math.Min(math.Round(l.Calories), FIT_UINT16_INVALID-1)),
+		LapTrigger:       lapTriggerFromTCX(l.TriggerMethod),
+	}
+
+	if l.AvgHeartRate != nil {
+		lap.AvgHeartRate = toUint8(l.AvgHeartRate.Value)
+	}
+	if l.MaxHeartRate != nil {
+		lap.MaxHeartRate = toUint8(l.MaxHeartRate.Value)
+	}
+
+	if avg := l.Extensions.LX.AvgSpeed; avg != nil {
+		lap.AvgSpeed = speedToFit(*avg)
+	} else if timer > 0 {
+		lap.AvgSpeed = speedToFit(l.DistanceMeters / l.TotalTimeSeconds)
+	}
+
+	switch {
+	case l.Extensions.LX.AvgRunCadence != nil:
+		lap.AvgCadence = stepCadence(uint16(toUint8(*l.Extensions.LX.AvgRunCadence)), sport)
+	case l.Cadence != nil:
+		lap.AvgCadence = uint16(toUint8(*l.Cadence))
+	}
+	if l.Extensions.LX.MaxRunCadence != nil {
+		lap.MaxCadence = stepCadence(uint16(toUint8(*l.Extensions.LX.MaxRunCadence)), sport)
+	}
+
+	return lap, nil
+}
+
+func tcxRecord(pt tcxPoint, sport typedef.Sport) (entity.RecordData, error) {
+	var record entity.RecordData
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(pt.Time))
+	if err != nil {
+		return record, fmt.Errorf("%w: некорректное время точки %q", ErrParseFile, pt.Time)
+	}
+	record.Timestamp = t
+
+	if pt.Latitude != nil && pt.Longitude != nil {
+		record.PositionLat = utils.DegreesToSemicircles(*pt.Latitude)
+		record.PositionLon = utils.DegreesToSemicircles(*pt.Longitude)
+	}
+	if pt.AltitudeMeters != nil {
+		record.Altitude = utils.MetersToAltitude(*pt.AltitudeMeters)
+	}
+	if pt.DistanceMeters != nil {
+		record.Distance = uint32(math.Round(*pt.DistanceMeters * 100))
+	}
+	if pt.HeartRate != nil {
+		record.HeartRate = toUint8(pt.HeartRate.Value)
+	}
+
+	tpx := pt.Extensions.TPX
+	switch {
+	case tpx.RunCadence != nil:
+		record.Cadence = stepCadence(uint16(toUint8(*tpx.RunCadence)), sport)
+	case pt.Cadence != nil:
+		record.Cadence = uint16(toUint8(*pt.Cadence))
+	}
+	if tpx.Speed != nil {
+		record.Speed = speedToFit(*tpx.Speed)
+	}
+	if tpx.Watts != nil {
+		record.Power = uint16(math.Min(math.Max(math.Round(*tpx.Watts), 0), FIT_UINT16_INVALID-1))
+	}
+
+	return record, nil
+}
+
+// lapTriggerFromTCX сопоставляет TriggerMethod из TCX со способом завершения круга FIT
+func lapTriggerFromTCX(s string) typedef.LapTrigger {
+	switch strings.TrimSpace(s) {
+	case "Manual":
+		return typedef.LapTriggerManual
+	case "Distance":
+		return typedef.LapTriggerDistance
+	case "Location":
+		return typedef.LapTriggerPositionLap
+	case "Time":
+		return typedef.LapTriggerTime
+	default:
+		return typedef.LapTriggerManual
+	}
+}
+
+// speedToFit переводит скорость из м/с в формат FIT (м/с * 1000)
+func speedToFit(v float64) uint16 {
+	return uint16(math.Min(math.Max(math.Round(v*1000), 0), FIT_UINT16_INVALID-1))
+}
+
+func toUint8(v float64) uint8 {
+	return uint8(math.Min(math.Max(math.Round(v), 0), FIT_UINT8_INVALID-1))
+}
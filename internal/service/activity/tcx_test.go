@@ -0,0 +1,113 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/muktihari/fit/profile/typedef"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTCX = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+  xmlns:ns3="http://www.garmin.com/xmlschemas/ActivityExtension/v2">
+  <Activities>
+    <Activity Sport="Running">
+      <Id>2025-06-12T07:00:00Z</Id>
+      <Lap StartTime="2025-06-12T07:00:00Z">
+        <TotalTimeSeconds>300.0</TotalTimeSeconds>
+        <DistanceMeters>1000.0</DistanceMeters>
+        <Calories>70</Calories>
+        <AverageHeartRateBpm><Value>150</Value></AverageHeartRateBpm>
+        <MaximumHeartRateBpm><Value>160</Value></MaximumHeartRateBpm>
+        <TriggerMethod>Distance</TriggerMethod>
+        <Track>
+          <Trackpoint>
+            <Time>2025-06-12T07:00:00Z</Time>
+            <Position><LatitudeDegrees>55.75</LatitudeDegrees><LongitudeDegrees>37.61</LongitudeDegrees></Position>
+            <AltitudeMeters>150.0</AltitudeMeters>
+            <DistanceMeters>0.0</DistanceMeters>
+            <HeartRateBpm><Value>140</Value></HeartRateBpm>
+            <Extensions><ns3:TPX><ns3:Speed>3.3</ns3:Speed><ns3:RunCadence>85</ns3:RunCadence></ns3:TPX></Extensions>
+          </Trackpoint>
+          <Trackpoint>
+            <Time>2025-06-12T07:05:00Z</Time>
+            <DistanceMeters>1000.0</DistanceMeters>
+            <HeartRateBpm><Value>160</Value></HeartRateBpm>
+            <Extensions><ns3:TPX><ns3:Watts>250</ns3:Watts></ns3:TPX></Extensions>
+          </Trackpoint>
+        </Track>
+        <Extensions><ns3:LX><ns3:AvgRunCadence>86</ns3:AvgRunCadence></ns3:LX></Extensions>
+      </Lap>
+      <Lap StartTime="2025-06-12T07:05:00Z">
+        <TotalTimeSeconds>120.0</TotalTimeSeconds>
+        <DistanceMeters>300.0</DistanceMeters>
+        <Calories>25</Calories>
+        <AverageHeartRateBpm><Value>165</Value></AverageHeartRateBpm>
+        <MaximumHeartRateBpm><Value>170</Value></MaximumHeartRateBpm>
+        <TriggerMethod>Manual</TriggerMethod>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+func TestParseActivity_TCX(t *testing.T) {
+	assert.Equal(t, FormatTCX, DetectFormat([]byte(testTCX)))
+
+	data, err := ParseActivity([]byte(testTCX))
+	require.NoError(t, err)
+
+	assert.Equal(t, typedef.SportRunning, data.Sport)
+	assert.Equal(t, time.Date(2025, 6, 12, 7, 0, 0, 0, time.UTC), data.Timestamp)
+	assert.Equal(t, uint32(130000), data.TotalDistance)
+	assert.Equal(t, uint32(420000), data.TotalTimerTime)
+	assert.Equal(t, uint16(95), data.TotalCalories)
+	assert.Equal(t, uint8(170), data.MaxHeartRate)
+	assert.Equal(t, uint8(154), data.AvgHeartRate)
+
+	require.Len(t, data.Laps, 2)
+	assert.Equal(t, typedef.LapTriggerDistance, data.Laps[0].LapTrigger)
+	assert.Equal(t, uint32(100000), data.Laps[0].TotalDistance)
+	assert.Equal(t, uint16(172), data.Laps[0].AvgCadence)
+	assert.Equal(t, uint16(3333), data.Laps[0].AvgSpeed)
+	assert.Equal(t, typedef.LapTriggerManual, data.Laps[1].LapTrigger)
+
+	require.Len(t, data.Records, 2)
+	assert.Equal(t, uint16(3300), data.Records[0].Speed)
+	assert.Equal(t, uint16(170), data.Records[0].Cadence)
+	assert.NotZero(t, data.Records[0].PositionLat)
+	assert.Zero(t, data.Records[1].PositionLat)
+	assert.Equal(t, uint32(100000), data.Records[1].Distance)
+	assert.Equal(t, uint16(250), data.Records[1].Power)
+}
+
+func TestParseActivity_TCXCycling(t *testing.T) {
+	const ride = `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2025-06-12T07:00:00Z</Id>
+      <Lap StartTime="2025-06-12T07:00:00Z">
+        <TotalTimeSeconds>600.0</TotalTimeSeconds>
+        <DistanceMeters>5000.0</DistanceMeters>
+        <Cadence>88</Cadence>
+        <Track>
+          <Trackpoint><Time>2025-06-12T07:00:00Z</Time><DistanceMeters>0.0</DistanceMeters><Cadence>90</Cadence></Trackpoint>
+          <Trackpoint><Time>2025-06-12T07:10:00Z</Time><DistanceMeters>5000.0</DistanceMeters><Cadence>86</Cadence></Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`
+
+	data, err := ParseActivity([]byte(ride))
+	require.NoError(t, err)
+
+	assert.Equal(t, typedef.SportCycling, data.Sport)
+	require.Len(t, data.Laps, 1)
+	assert.Equal(t, uint16(88), data.Laps[0].AvgCadence)
+	require.Len(t, data.Records, 2)
+	assert.Equal(t, uint16(90), data.Records[0].Cadence)
+	assert.Equal(t, uint16(86), data.Records[1].Cadence)
+}
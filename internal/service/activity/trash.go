@@ -0,0 +1,97 @@
+package activity
+
+import (
+	"context"
+	"log"
+	"time"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// DefaultTrashRetention - срок хранения удалённых тренировок по умолчанию
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// DeleteWorkout переносит тренировку в корзину. Удалить тренировку может только владелец
+func (s *WorkoutService) DeleteWorkout(ctx context.Context, userID, workoutID string) error {
+	uid, wid, err := parseIDs(userID, workoutID)
+	if err != nil {
+		return err
+	}
+
+	return s.Activity.SoftDeleteWorkout(ctx, uid, wid)
+}
+
+// GetTrash возвращает тренировки пользователя, которые ещё можно восстановить
+func (s *WorkoutService) GetTrash(ctx context.Context, userID string) ([]entity.Workout, error) {
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Activity.GetDeletedWorkouts(ctx, uid, s.trashCutoff())
+}
+
+// RestoreWorkout возвращает тренировку из корзины, пока не истёк срок хранения
+func (s *WorkoutService) RestoreWorkout(ctx context.Context, userID, workoutID string) (*entity.Workout, error) {
+	uid, wid, err := parseIDs(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Activity.RestoreWorkout(ctx, uid, wid, s.trashCutoff())
+}
+
+// RestoreDeadline - до какого момента можно восстановить удалённую тренировку
+func (s *WorkoutService) RestoreDeadline(w entity.Workout) time.Time {
+	return w.DeletedAt.Add(s.TrashRetention)
+}
+
+// PurgeTrash окончательно удаляет тренировки с истёкшим сроком хранения и их исходные файлы
+func (s *WorkoutService) PurgeTrash(ctx context.Context) (int, error) {
+	purged, keys, err := s.Activity.PurgeDeletedWorkouts(ctx, s.trashCutoff())
+	if err != nil {
+		return 0, err
+	}
+
+	if s.Files != nil {
+		for _, key := range keys {
+			// Оставшийся файл не мешает работе, поэтому ошибка только логируется
+			if err := s.Files.Delete(ctx, key); err != nil {
+				log.Printf("ошибка удаления исходного файла %s: %v", key, err)
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// RunTrashPurge периодически очищает корзину, пока не отменён контекст
+func (s *WorkoutService) RunTrashPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeTrash(ctx)
+			if err != nil {
+				log.Printf("ошибка очистки корзины: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("из корзины окончательно удалено тренировок: %d", purged)
+			}
+		}
+	}
+}
+
+func (s *WorkoutService) trashCutoff() time.Time {
+	return time.Now().Add(-s.TrashRetention)
+}
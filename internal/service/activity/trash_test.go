@@ -0,0 +1,121 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/filestorage"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *savingRepo) SoftDeleteWorkout(_ context.Context, userID, workoutID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID == workoutID && w.UserID == userID && w.DeletedAt.IsZero() {
+			w.DeletedAt = time.Now()
+			return nil
+		}
+	}
+	return storage.ErrWorkoutNotFound
+}
+
+func (r *savingRepo) GetDeletedWorkouts(_ context.Context, userID uuid.UUID, deletedAfter time.Time) ([]entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []entity.Workout
+	for _, w := range r.created {
+		if w.UserID == userID && w.DeletedAt.After(deletedAfter) {
+			result = append(result, *w)
+		}
+	}
+	return result, nil
+}
+
+func (r *savingRepo) RestoreWorkout(_ context.Context, userID, workoutID uuid.UUID, deletedAfter time.Time) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID == workoutID && w.UserID == userID && w.DeletedAt.After(deletedAfter) {
+			w.DeletedAt = time.Time{}
+			return w, nil
+		}
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+func (r *savingRepo) PurgeDeletedWorkouts(_ context.Context, before time.Time) (int, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var (
+		kept []*entity.Workout
+		keys []string
+	)
+	for _, w := range r.created {
+		if !w.DeletedAt.IsZero() && w.DeletedAt.Before(before) {
+			keys = append(keys, w.OriginalKey)
+			continue
+		}
+		kept = append(kept, w)
+	}
+	purged := len(r.created) - len(kept)
+	r.created = kept
+	return purged, keys, nil
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	files, err := filestorage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	repo := &savingRepo{}
+	svc := NewWorkoutService(repo, files)
+	userID := uuid.Must(uuid.NewV4()).String()
+
+	results, err := svc.UploadFiles(ctx, []UploadedFile{{Name: "race.tcx", Data: []byte(testTCX)}}, UploadOptions{UserID: userID})
+	require.NoError(t, err)
+	require.Equal(t, dto.UploadStatusOK, results[0].Status)
+	w := results[0].Workout
+	workoutID := w.ID.String()
+
+	// Удалить чужую тренировку нельзя
+	err = svc.DeleteWorkout(ctx, uuid.Must(uuid.NewV4()).String(), workoutID)
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	require.NoError(t, svc.DeleteWorkout(ctx, userID, workoutID))
+	assert.ErrorIs(t, svc.DeleteWorkout(ctx, userID, workoutID), storage.ErrWorkoutNotFound)
+
+	trash, err := svc.GetTrash(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.WithinDuration(t, time.Now().Add(DefaultTrashRetention), svc.RestoreDeadline(trash[0]), time.Minute)
+
+	restored, err := svc.RestoreWorkout(ctx, userID, workoutID)
+	require.NoError(t, err)
+	assert.True(t, restored.DeletedAt.IsZero())
+
+	trash, err = svc.GetTrash(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// После истечения срока хранения тренировка и исходный файл удаляются окончательно
+	require.NoError(t, svc.DeleteWorkout(ctx, userID, workoutID))
+	w.DeletedAt = time.Now().Add(-DefaultTrashRetention - time.Hour)
+
+	_, err = svc.RestoreWorkout(ctx, userID, workoutID)
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	purged, err := svc.PurgeTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Empty(t, repo.created)
+
+	_, err = files.Load(ctx, w.OriginalKey)
+	assert.ErrorIs(t, err, filestorage.ErrNotFound)
+}
@@ -0,0 +1,168 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+)
+
+// Допустимые значения полей при редактировании тренировки
+const (
+	maxNameLength        = 255
+	maxSportTypeLength   = 100
+	maxDescriptionLength = 5000
+	maxDurationSec       = 7 * 24 * 60 * 60 // неделя - с запасом для ультрамарафонов и походов
+	maxDistanceKm        = 2000
+	minHeartRate         = 25
+	maxHeartRate         = 250
+	maxCadence           = 2 * (FIT_UINT8_INVALID - 1) // удвоенный каденс одной ноги у бега и ходьбы
+	maxCalories          = math.MaxUint16
+	paceTolerance        = 0.05 // допустимое расхождение темпа с дистанцией и временем
+)
+
+var (
+	ErrInvalidUpdate   = errors.New("invalid workout update")
+	ErrVersionRequired = errors.New("workout version is required: send If-Match header or version field")
+)
+
+// UpdateWorkout меняет переданные поля тренировки, если пользователь её владелец.
+// Тренеру тренировки подопечных доступны только для чтения, для него, как и для остальных,
+// чужая тренировка выглядит как несуществующая.
+// u.Version - версия, которую видел клиент; если тренировку успели изменить,
+// возвращается storage.ErrVersionConflict, чтобы не затереть чужие правки
+func (s *WorkoutService) UpdateWorkout(ctx context.Context, userID, workoutID string, u dto.UpdateWorkout) (*entity.Workout, error) {
+	if u.Version <= 0 {
+		return nil, ErrVersionRequired
+	}
+
+	uid, wid, err := parseIDs(userID, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.Activity.GetWorkoutByID(ctx, wid)
+	if err != nil {
+		return nil, err
+	}
+	if current.UserID != uid {
+		return nil, storage.ErrWorkoutNotFound
+	}
+	if current.Version != u.Version {
+		return nil, storage.ErrVersionConflict
+	}
+
+	if err := validateUpdate(current, &u); err != nil {
+		return nil, err
+	}
+
+	u.ID = current.ID
+	return s.Activity.UpdateWorkout(ctx, u)
+}
+
+// validateUpdate проверяет новые значения с учётом тех полей, которые не меняются.
+// Если изменились дистанция или время, а темп не передан, темп пересчитывается
+func validateUpdate(current *entity.Workout, u *dto.UpdateWorkout) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidUpdate, fmt.Sprintf(format, args...))
+	}
+
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return invalid("name must be 1-%d characters", maxNameLength)
+		}
+		u.Name = &name
+	}
+	if u.SportType != nil {
+		sport := strings.TrimSpace(*u.SportType)
+		if sport == "" || utf8.RuneCountInString(sport) > maxSportTypeLength {
+			return invalid("sport_type must be 1-%d characters", maxSportTypeLength)
+		}
+		u.SportType = &sport
+	}
+	if u.Description != nil && utf8.RuneCountInString(*u.Description) > maxDescriptionLength {
+		return invalid("description must be at most %d characters", maxDescriptionLength)
+	}
+	if u.DeviceName != nil && utf8.RuneCountInString(*u.DeviceName) > maxNameLength {
+		return invalid("device_name must be at most %d characters", maxNameLength)
+	}
+
+	if u.Duration != nil && (*u.Duration <= 0 || *u.Duration > maxDurationSec) {
+		return invalid("duration must be between 1 and %d seconds", maxDurationSec)
+	}
+	if u.Distance != nil && (*u.Distance < 0 || *u.Distance > maxDistanceKm || math.IsNaN(float64(*u.Distance))) {
+		return invalid("distance must be between 0 and %d km", maxDistanceKm)
+	}
+	if u.AvgCadence != nil && (*u.AvgCadence < 0 || *u.AvgCadence > maxCadence) {
+		return invalid("avg_cadence must be between 0 and %d", maxCadence)
+	}
+	if u.Calories != nil && (*u.Calories < 0 || *u.Calories > maxCalories) {
+		return invalid("calories must be between 0 and %d", maxCalories)
+	}
+
+	// Пульс: 0 - нет данных, иначе физиологически возможный диапазон, средний не выше максимального
+	if !validHeartRate(u.AvgHeartRate) {
+		return invalid("avg_heart_rate must be 0 or between %d and %d bpm", minHeartRate, maxHeartRate)
+	}
+	if !validHeartRate(u.MaxHeartRate) {
+		return invalid("max_heart_rate must be 0 or between %d and %d bpm", minHeartRate, maxHeartRate)
+	}
+	avgHR, maxHR := int16(current.AvgHeartRate), int16(current.MaxHeartRate)
+	if u.AvgHeartRate != nil {
+		avgHR = *u.AvgHeartRate
+	}
+	if u.MaxHeartRate != nil {
+		maxHR = *u.MaxHeartRate
+	}
+	if avgHR > 0 && maxHR > 0 && avgHR > maxHR {
+		return invalid("avg_heart_rate must not exceed max_heart_rate")
+	}
+
+	return validatePace(current, u, invalid)
+}
+
+func validHeartRate(hr *int16) bool {
+	return hr == nil || *hr == 0 || (*hr >= minHeartRate && *hr <= maxHeartRate)
+}
+
+// validatePace проверяет, что темп согласуется с дистанцией и временем
+func validatePace(current *entity.Workout, u *dto.UpdateWorkout, invalid func(string, ...any) error) error {
+	if u.AvgPace != nil && (*u.AvgPace < 0 || math.IsNaN(float64(*u.AvgPace))) {
+		return invalid("avg_pace must not be negative")
+	}
+
+	distance, _ := strconv.ParseFloat(current.Distance, 64)
+	if u.Distance != nil {
+		distance = float64(*u.Distance)
+	}
+	duration := current.Duration.Seconds()
+	if u.Duration != nil {
+		duration = float64(*u.Duration)
+	}
+
+	// Без дистанции темп не определён (силовая, манеж без датчика)
+	if distance <= 0 || duration <= 0 {
+		return nil
+	}
+	expected := duration / distance
+
+	if u.AvgPace == nil {
+		if u.Distance != nil || u.Duration != nil {
+			pace := float32(math.Round(expected))
+			u.AvgPace = &pace
+		}
+		return nil
+	}
+
+	if math.Abs(float64(*u.AvgPace)-expected) > expected*paceTolerance {
+		return invalid("avg_pace %.0f s/km does not match distance and duration (expected about %.0f s/km)", *u.AvgPace, expected)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package activity
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *savingRepo) UpdateWorkout(_ context.Context, u dto.UpdateWorkout) (*entity.Workout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.created {
+		if w.ID != u.ID {
+			continue
+		}
+		if w.Version != u.Version {
+			return nil, storage.ErrVersionConflict
+		}
+		if u.Name != nil {
+			w.Name = *u.Name
+		}
+		if u.AvgPace != nil {
+			w.AvgPace = int(*u.AvgPace)
+		}
+		if u.Distance != nil {
+			w.Distance = "updated"
+		}
+		w.Version++
+		return w, nil
+	}
+	return nil, storage.ErrWorkoutNotFound
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestUpdateWorkout(t *testing.T) {
+	ctx := context.Background()
+	owner := uuid.Must(uuid.NewV4())
+	coach := uuid.Must(uuid.NewV4())
+	w := &entity.Workout{
+		ID:           uuid.Must(uuid.NewV4()),
+		UserID:       owner,
+		Distance:     "10.00",
+		Duration:     50 * time.Minute,
+		AvgPace:      300,
+		AvgHeartRate: 150,
+		MaxHeartRate: 170,
+		Version:      1,
+	}
+	repo := &savingRepo{created: []*entity.Workout{w}, coaches: map[uuid.UUID]uuid.UUID{owner: coach}}
+	svc := NewWorkoutService(repo, nil)
+	update := func(u dto.UpdateWorkout) (*entity.Workout, error) {
+		return svc.UpdateWorkout(ctx, owner.String(), w.ID.String(), u)
+	}
+
+	_, err := update(dto.UpdateWorkout{Name: ptr("Забег")})
+	assert.ErrorIs(t, err, ErrVersionRequired)
+
+	_, err = update(dto.UpdateWorkout{Version: 2, Name: ptr("Забег")})
+	assert.ErrorIs(t, err, storage.ErrVersionConflict)
+
+	for name, u := range map[string]dto.UpdateWorkout{
+		"empty name":    {Name: ptr("  ")},
+		"hr range":      {AvgHeartRate: ptr[int16](400)},
+		"avg above max": {AvgHeartRate: ptr[int16](180)},
+		"duration":      {Duration: ptr[int32](-1)},
+		"cadence range": {AvgCadence: ptr[int16](600)},
+		"pace mismatch": {AvgPace: ptr[float32](240)},
+	} {
+		u.Version = 1
+		_, err := update(u)
+		assert.ErrorIs(t, err, ErrInvalidUpdate, name)
+	}
+
+	updated, err := update(dto.UpdateWorkout{Version: 1, Name: ptr("  Забег  "), AvgPace: ptr[float32](305)})
+	require.NoError(t, err)
+	assert.Equal(t, "Забег", updated.Name)
+	assert.Equal(t, 2, updated.Version)
+
+	// При изменении дистанции темп пересчитывается
+	updated, err = update(dto.UpdateWorkout{Version: 2, Distance: ptr[float32](12.5)})
+	require.NoError(t, err)
+	assert.Equal(t, 240, updated.AvgPace)
+
+	_, err = svc.UpdateWorkout(ctx, uuid.Must(uuid.NewV4()).String(), w.ID.String(), dto.UpdateWorkout{Version: 3, Name: ptr("Чужая")})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+
+	// Тренер видит тренировку подопечного, но менять её не может
+	_, err = svc.UpdateWorkout(ctx, coach.String(), w.ID.String(), dto.UpdateWorkout{Version: 3, Name: ptr("Тренерская")})
+	assert.ErrorIs(t, err, storage.ErrWorkoutNotFound)
+	assert.Equal(t, "Забег", w.Name)
+}
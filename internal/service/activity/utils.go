@@ -2,16 +2,82 @@ package activity
 
 import (
 	"fmt"
+
+	"github.com/muktihari/fit/profile/typedef"
 )
 
 const (
-	FIT_UINT16_INVALID = 0xFFFF // 65535 - для скорости, мощности
+	FIT_UINT8_INVALID  = 0xFF       // 255 - для пульса, каденса
+	FIT_SINT8_INVALID  = 0x7F       // 127 - для температуры
+	FIT_UINT16_INVALID = 0xFFFF     // 65535 - для скорости, мощности
+	FIT_UINT32_INVALID = 0xFFFFFFFF // для дистанции
+	FIT_SINT32_INVALID = 0x7FFFFFFF // для координат
 )
 
+func isValidFitUint8(value uint8) bool {
+	return value != FIT_UINT8_INVALID && value > 0
+}
+
 func isValidFitUint16(value uint16) bool {
 	return value != 65535 && value > 0
 }
 
+func isValidFitUint32(value uint32) bool {
+	return value != FIT_UINT32_INVALID && value > 0
+}
+
+func isValidFitSint32(value int32) bool {
+	return value != FIT_SINT32_INVALID
+}
+
+// fitUint8 возвращает значение поля или 0, если поле не заполнено
+func fitUint8(value uint8) uint8 {
+	if !isValidFitUint8(value) {
+		return 0
+	}
+	return value
+}
+
+// fitUint16 возвращает значение поля или 0, если поле не заполнено
+func fitUint16(value uint16) uint16 {
+	if !isValidFitUint16(value) {
+		return 0
+	}
+	return value
+}
+
+// fitUint32 возвращает значение поля или 0, если поле не заполнено
+func fitUint32(value uint32) uint32 {
+	if !isValidFitUint32(value) {
+		return 0
+	}
+	return value
+}
+
+// fitSint8 возвращает значение поля или 0, если поле не заполнено
+func fitSint8(value int8) int8 {
+	if value == FIT_SINT8_INVALID {
+		return 0
+	}
+	return value
+}
+
+// isStepSport сообщает, что каденс вида спорта считается в шагах (бег, ходьба).
+// Устройства пишут его для одной ноги, а велосипедный каденс - уже полные обороты шатуна
+func isStepSport(sport typedef.Sport) bool {
+	return sport == typedef.SportRunning || sport == typedef.SportWalking
+}
+
+// stepCadence переводит каденс одной ноги в шаги в минуту для бега и ходьбы,
+// у остальных видов спорта значение не меняется. Шаги в минуту при спринте
+// превышают 255, поэтому каденс хранится в uint16
+func stepCadence(value uint16, sport typedef.Sport) uint16 {
+	if !isStepSport(sport) {
+		return value
+	}
+	return value * 2
+}
+
 // formatPace форматирует темп в строку вида "5:30 мин/км"
 func formatPace(pace float64) string {
 	if pace <= 0 {
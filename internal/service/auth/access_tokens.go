@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+	"workout/internal/lib/jwt"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+const (
+	// accessTokenNameMax - наибольшая длина названия токена в символах
+	accessTokenNameMax = 100
+	// accessTokenMaxDays - наибольший срок действия токена; бессрочный токен задаётся нулём
+	accessTokenMaxDays = 3650
+	// accessTokenPrefixLen - сколько символов токена показывать в списке, чтобы его можно было узнать
+	accessTokenPrefixLen = len(entity.AccessTokenPrefix) + 6
+	// accessTokenTouchInterval - как часто обновлять время последнего использования:
+	// запись при каждом запросе нагружала бы базу при частой синхронизации
+	accessTokenTouchInterval = time.Minute
+)
+
+var (
+	ErrInvalidAccessToken   = errors.New("invalid access token")
+	ErrAccessTokenNotFound  = errors.New("access token not found")
+	ErrInvalidAccessTokenID = errors.New("invalid access token id")
+	ErrInvalidScope         = errors.New("invalid scope")
+	ErrScopeNotAllowed      = errors.New("scope not allowed for user roles")
+	ErrInvalidTokenName     = errors.New("invalid access token name")
+	ErrInvalidTokenExpiry   = errors.New("invalid access token expiry")
+)
+
+// CreateAccessToken выпускает персональный токен доступа пользователя userID.
+// Область, для которой у ролей пользователя нет права, выдать нельзя
+func (a *AuthService) CreateAccessToken(ctx context.Context, userID string, req dto.CreateAccessTokenRequest) (*dto.CreatedAccessTokenDTO, error) {
+	const op = "Auth.CreateAccessToken"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidUserID)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > accessTokenNameMax {
+		return nil, fmt.Errorf("%s: %w: name is required, up to %d characters", op, ErrInvalidTokenName, accessTokenNameMax)
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > accessTokenMaxDays {
+		return nil, fmt.Errorf("%s: %w: expires_in_days must be from 0 to %d", op, ErrInvalidTokenExpiry, accessTokenMaxDays)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("%s: %w: at least one scope is required", op, ErrInvalidScope)
+	}
+
+	roles, err := a.auth.GetUserRoles(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	scopes := make([]entity.Scope, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		scope := entity.Scope(s)
+		if !scope.Valid() {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidScope, s)
+		}
+		if perm := scope.Permission(); perm != "" && !entity.HasPermission(roles, perm) {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrScopeNotAllowed, s)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	secret, _, err := newRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	token := entity.AccessTokenPrefix + secret
+
+	now := time.Now()
+	t := &entity.AccessToken{
+		ID:        id,
+		UserID:    uid,
+		Name:      name,
+		Prefix:    token[:accessTokenPrefixLen],
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		t.ExpiresAt = now.AddDate(0, 0, req.ExpiresInDays)
+	}
+	if err := a.auth.CreateAccessToken(ctx, t, hashToken(token)); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &dto.CreatedAccessTokenDTO{AccessTokenDTO: *accessTokenToDTO(t), Token: token}, nil
+}
+
+// AccessTokens возвращает действующие и истёкшие, но не отозванные токены пользователя
+func (a *AuthService) AccessTokens(ctx context.Context, userID string) ([]*dto.AccessTokenDTO, error) {
+	const op = "Auth.AccessTokens"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidUserID)
+	}
+
+	tokens, err := a.auth.ListAccessTokens(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]*dto.AccessTokenDTO, 0, len(tokens))
+	for i := range tokens {
+		result = append(result, accessTokenToDTO(&tokens[i]))
+	}
+	return result, nil
+}
+
+// RevokeAccessToken отзывает токен пользователя. Запросы с ним сразу перестают проходить
+func (a *AuthService) RevokeAccessToken(ctx context.Context, userID, tokenID string) error {
+	const op = "Auth.RevokeAccessToken"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, ErrInvalidUserID)
+	}
+	tid, err := uuid.FromString(tokenID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, ErrInvalidAccessTokenID)
+	}
+
+	if err := a.auth.RevokeAccessToken(ctx, uid, tid, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrAccessTokenNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// AuthenticateAccessToken проверяет персональный токен и возвращает данные запроса
+// в том же виде, что и у access-токена сессии. Роли читаются из базы при каждом запросе,
+// поэтому их изменение действует на токен сразу. Как и при входе по паролю,
+// токен не действует, пока учётная запись заблокирована или почта не подтверждена
+func (a *AuthService) AuthenticateAccessToken(ctx context.Context, token string) (*jwt.UserClaims, error) {
+	const op = "Auth.AuthenticateAccessToken"
+
+	if !strings.HasPrefix(token, entity.AccessTokenPrefix) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+	}
+
+	now := time.Now()
+	t, err := a.auth.GetAccessToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !t.Active(now) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+	}
+
+	user, err := a.auth.GetUserByID(ctx, t.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if now.Before(user.LockedUntil) || (a.RequireVerifiedEmail && !user.EmailVerified) {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidAccessToken)
+	}
+	roles, err := a.auth.GetUserRoles(ctx, t.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if now.Sub(t.LastUsedAt) >= accessTokenTouchInterval {
+		if err := a.auth.TouchAccessToken(ctx, t.ID, now); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	claims := &jwt.UserClaims{
+		UID:     user.ID,
+		Email:   user.Email,
+		TokenID: t.ID.String(),
+		Scopes:  t.Scopes,
+	}
+	claims.Subject = user.ID
+	for _, r := range roles {
+		claims.Roles = append(claims.Roles, string(r))
+	}
+	return claims, nil
+}
+
+func accessTokenToDTO(t *entity.AccessToken) *dto.AccessTokenDTO {
+	d := &dto.AccessTokenDTO{
+		ID:        t.ID.String(),
+		Name:      t.Name,
+		Prefix:    t.Prefix,
+		Scopes:    make([]string, 0, len(t.Scopes)),
+		CreatedAt: t.CreatedAt,
+	}
+	for _, s := range t.Scopes {
+		d.Scopes = append(d.Scopes, string(s))
+	}
+	if !t.LastUsedAt.IsZero() {
+		lastUsed := t.LastUsedAt
+		d.LastUsedAt = &lastUsed
+	}
+	if !t.ExpiresAt.IsZero() {
+		expires := t.ExpiresAt
+		d.ExpiresAt = &expires
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"workout/internal/adapters/memory"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	userID, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	created, err := svc.CreateAccessToken(ctx, userID, dto.CreateAccessTokenRequest{
+		Name:          " sync box ",
+		Scopes:        []string{"upload", "workouts:read", "upload"},
+		ExpiresInDays: 30,
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, entity.AccessTokenPrefix))
+	assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+	assert.Equal(t, "sync box", created.Name)
+	assert.Equal(t, []string{"upload", "workouts:read"}, created.Scopes)
+	require.NotNil(t, created.ExpiresAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), *created.ExpiresAt, time.Minute)
+
+	claims, err := svc.AuthenticateAccessToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UID)
+	assert.Equal(t, userID, claims.Subject)
+	assert.Equal(t, "runner@example.com", claims.Email)
+	assert.Equal(t, created.ID, claims.TokenID)
+	assert.Empty(t, claims.SessionID)
+	assert.Equal(t, []entity.Scope{entity.ScopeUpload, entity.ScopeWorkoutsRead}, claims.Scopes)
+	assert.Equal(t, []entity.Role{entity.RoleAthlete}, claims.RoleList())
+
+	tokens, err := svc.AccessTokens(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, created.ID, tokens[0].ID)
+	assert.NotNil(t, tokens[0].LastUsedAt, "время последнего использования обновлено")
+
+	_, err = svc.AuthenticateAccessToken(ctx, entity.AccessTokenPrefix+"unknown")
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	require.NoError(t, svc.RevokeAccessToken(ctx, userID, created.ID))
+	_, err = svc.AuthenticateAccessToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	assert.ErrorIs(t, svc.RevokeAccessToken(ctx, userID, created.ID), ErrAccessTokenNotFound)
+	assert.ErrorIs(t, svc.RevokeAccessToken(ctx, userID, "bad"), ErrInvalidAccessTokenID)
+
+	tokens, err = svc.AccessTokens(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// Истёкший токен не принимается
+	expired := entity.AccessTokenPrefix + "expired"
+	require.NoError(t, svc.auth.CreateAccessToken(ctx, &entity.AccessToken{
+		ID:        uuid.Must(uuid.NewV4()),
+		UserID:    uuid.FromStringOrNil(userID),
+		Name:      "old",
+		Scopes:    []entity.Scope{entity.ScopeWorkoutsRead},
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}, hashToken(expired)))
+	_, err = svc.AuthenticateAccessToken(ctx, expired)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+}
+
+func TestCreateAccessTokenValidation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	userID, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "coach@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		req  dto.CreateAccessTokenRequest
+		want error
+	}{
+		"без названия":       {dto.CreateAccessTokenRequest{Scopes: []string{"upload"}}, ErrInvalidTokenName},
+		"без областей":       {dto.CreateAccessTokenRequest{Name: "script"}, ErrInvalidScope},
+		"неизвестная":        {dto.CreateAccessTokenRequest{Name: "script", Scopes: []string{"admin"}}, ErrInvalidScope},
+		"отрицательный срок": {dto.CreateAccessTokenRequest{Name: "script", Scopes: []string{"upload"}, ExpiresInDays: -1}, ErrInvalidTokenExpiry},
+	} {
+		_, err := svc.CreateAccessToken(ctx, userID, tc.req)
+		assert.ErrorIs(t, err, tc.want, name)
+	}
+
+	// Тренер без роли athlete не может загружать тренировки, значит и токен на загрузку не получит
+	_, err = svc.SetRoles(ctx, userID, userID, dto.UserRolesRequest{Roles: []string{"coach", "admin"}})
+	require.NoError(t, err)
+	_, err = svc.CreateAccessToken(ctx, userID, dto.CreateAccessTokenRequest{Name: "sync", Scopes: []string{"upload"}})
+	assert.ErrorIs(t, err, ErrScopeNotAllowed)
+	_, err = svc.CreateAccessToken(ctx, userID, dto.CreateAccessTokenRequest{Name: "report", Scopes: []string{"workouts:read"}})
+	assert.NoError(t, err)
+}
+
+func TestAccessTokensAfterPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	svc, mail := newMailService(t)
+	svc.RequireVerifiedEmail = false
+	userID, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	created, err := svc.CreateAccessToken(ctx, userID, dto.CreateAccessTokenRequest{Name: "sync", Scopes: []string{"upload"}})
+	require.NoError(t, err)
+
+	// Пока учётная запись заблокирована, токен не действует
+	require.NoError(t, svc.auth.LockUser(ctx, uuid.FromStringOrNil(userID), time.Now().Add(time.Minute)))
+	_, err = svc.AuthenticateAccessToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	// Сброс пароля снимает блокировку, но токен, выпущенный до него, больше не действует
+	require.NoError(t, svc.ForgotPassword(ctx, "runner@example.com"))
+	require.NoError(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: mail.lastToken(t), Password: "new-secret"}))
+	_, err = svc.AuthenticateAccessToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAccessToken)
+
+	fresh, err := svc.CreateAccessToken(ctx, userID, dto.CreateAccessTokenRequest{Name: "sync", Scopes: []string{"upload"}})
+	require.NoError(t, err)
+	_, err = svc.AuthenticateAccessToken(ctx, fresh.Token)
+	assert.NoError(t, err)
+}
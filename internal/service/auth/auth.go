@@ -6,29 +6,76 @@ import (
 	"fmt"
 	"github.com/labstack/gommon/log"
 	"golang.org/x/crypto/bcrypt"
+	"slices"
 	"time"
-	"workout/internal/adapters/postgres"
+	"workout/internal/adapters/storage"
 	"workout/internal/dto"
+	"workout/internal/entity"
 	"workout/internal/lib/jwt"
+
+	"github.com/gofrs/uuid/v5"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidAppID       = errors.New("invalid app id")
 	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidUserID      = errors.New("invalid user id")
 )
 
 type AuthService struct {
-	auth     Auth
-	tokenTTL time.Duration
-	secret   string
+	auth       Auth
+	tokenTTL   time.Duration
+	refreshTTL time.Duration
+	keys       *jwt.KeySet
+
+	// AdminEmails - адреса, которые при регистрации получают роль admin вместе с athlete.
+	// Так на новой установке появляется первый администратор
+	AdminEmails []string
+
+	// Tx - транзакции репозитория для ротации refresh-токенов; nil - без транзакций
+	Tx Transactor
+
+	// Mailer отправляет письма подтверждения почты и сброса пароля; nil - письма не отправляются.
+	// VerifyURL и ResetURL - адреса ссылок в письмах, токен добавляется параметром token
+	Mailer    Mailer
+	VerifyURL string
+	ResetURL  string
+	VerifyTTL time.Duration
+	ResetTTL  time.Duration
+
+	// RequireVerifiedEmail запрещает вход, пока почта не подтверждена
+	RequireVerifiedEmail bool
+
+	// Lockout - блокировка входа после серии неверных паролей
+	Lockout LockoutPolicy
+}
+
+// NewAuthService создаёт сервис. keys подписывают access-токены, ttl - срок их жизни,
+// refreshTTL - сколько сессия устройства живёт без обновления токенов.
+// Если репозиторий умеет транзакции, они используются автоматически
+func NewAuthService(auth Auth, keys *jwt.KeySet, ttl, refreshTTL time.Duration) *AuthService {
+	a := &AuthService{
+		auth:       auth,
+		keys:       keys,
+		tokenTTL:   ttl,
+		refreshTTL: refreshTTL,
+		VerifyTTL:  48 * time.Hour,
+		ResetTTL:   time.Hour,
+		Lockout:    LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: time.Hour},
+	}
+	if tx, ok := auth.(Transactor); ok {
+		a.Tx = tx
+	}
+	return a
 }
 
-func NewAuthService(auth Auth, ttl time.Duration) *AuthService {
-	return &AuthService{
-		auth:     auth,
-		tokenTTL: ttl,
+// withinTx выполняет fn в транзакции репозитория, если она доступна
+func (a *AuthService) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if a.Tx == nil {
+		return fn(ctx)
 	}
+	return a.Tx.WithinTx(ctx, fn)
 }
 
 func (a *AuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
@@ -40,31 +87,46 @@ func (a *AuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 	//
 	//log.Info("attempting to login user")
 
+	now := time.Now()
 	user, err := a.auth.GetUser(ctx, req.Login)
 	if err != nil {
-		if errors.Is(err, postgres.ErrUserNotFound) {
+		if errors.Is(err, storage.ErrUserNotFound) {
 			//a.log.Warn("user not found")
-
-			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+			return nil, fmt.Errorf("%s: %w", op, a.unknownLogin(ctx, req, now))
 		}
 		//a.log.Error("failed to get user", err)
 
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	// Во время блокировки пароль не проверяется, иначе подбор продолжался бы
+	if now.Before(user.LockedUntil) {
+		a.auditLogin(ctx, req, uuid.FromStringOrNil(user.ID), entity.LoginLocked, now)
+
+		return nil, fmt.Errorf("%s: %w", op, &LockedError{Until: user.LockedUntil})
+	}
 	if err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
 		//a.log.Info("invalid credentials")
+		a.passwordFailed(ctx, req, user, now)
 
 		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
+	if user.FailedLogins > 0 || !user.LockedUntil.IsZero() {
+		if err := a.auth.ResetFailedLogins(ctx, uuid.FromStringOrNil(user.ID)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	// Проверяется после пароля, чтобы по ответу нельзя было узнать, кто зарегистрирован
+	if a.RequireVerifiedEmail && !user.EmailVerified {
+		return nil, fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
 
 	//log.Info("user logged in successfully")
-	fmt.Println(a.tokenTTL)
-	token, err := jwt.NewToken(user, a.secret, a.tokenTTL)
+	tokens, err := a.startSession(ctx, user, req.UserAgent, req.IP)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &dto.LoginResponse{Token: token}, nil
+	return tokens, nil
 }
 
 func (a *AuthService) RegisterNewUser(ctx context.Context, req dto.RegisterRequest) (string, error) {
@@ -82,9 +144,17 @@ func (a *AuthService) RegisterNewUser(ctx context.Context, req dto.RegisterReque
 		return "", err
 	}
 
-	id, err := a.auth.CreateUser(ctx, req.Login, passHash)
+	// Пользователь без ролей не смог бы работать с тренировками, поэтому создаётся вместе с ними
+	var id string
+	err = a.withinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if id, err = a.auth.CreateUser(ctx, req.Login, passHash); err != nil {
+			return err
+		}
+		return a.auth.SetUserRoles(ctx, uuid.FromStringOrNil(id), a.defaultRoles(req.Login))
+	})
 	if err != nil {
-		if errors.Is(err, postgres.ErrUserExists) {
+		if errors.Is(err, storage.ErrUserExists) {
 			log.Warn("user already exists")
 
 			return "", fmt.Errorf("%s: %w", op, ErrUserExists)
@@ -97,25 +167,33 @@ func (a *AuthService) RegisterNewUser(ctx context.Context, req dto.RegisterReque
 
 	log.Info("user created:", id)
 
+	// Пользователь уже создан, поэтому ошибка отправки не отменяет регистрацию:
+	// письмо можно запросить повторно через /auth/verify/resend
+	user := &entity.User{ID: id, Email: req.Login}
+	if err := a.sendUserToken(ctx, user, entity.TokenVerifyEmail); err != nil {
+		log.Error("failed to send verification email:", err.Error())
+	}
+
 	return id, nil
 
 }
 
-func (a *AuthService) IsAdmin(ctx context.Context, userID int64) (bool, error) {
+// IsAdmin сообщает, есть ли у пользователя роль admin
+func (a *AuthService) IsAdmin(ctx context.Context, userID string) (bool, error) {
 	const op = "Auth.IsAdmin"
 
-	//log := a.log.With(
-	//	slog.String("op", op),
-	//	slog.String("user_id", fmt.Sprint(userID)))
-	//
-	//log.Info("checking if user is admin")
-
-	isAdmin, err := a.auth.IsAdmin(ctx, userID)
+	uid, err := uuid.FromString(userID)
 	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, ErrInvalidAppID)
+		return false, fmt.Errorf("%s: %w", op, ErrInvalidUserID)
 	}
 
-	log.Info("checking if user is admin")
+	roles, err := a.auth.GetUserRoles(ctx, uid)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
 
-	return isAdmin, nil
+	return slices.Contains(roles, entity.RoleAdmin), nil
 }
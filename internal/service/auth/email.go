@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"workout/internal/adapters/mailer"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid or expired token")
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrInvalidPassword  = errors.New("invalid password")
+)
+
+// sendUserToken создаёт одноразовый токен и отправляет пользователю письмо со ссылкой.
+// В базе хранится только хэш токена, сам токен есть лишь в письме
+func (a *AuthService) sendUserToken(ctx context.Context, user *entity.User, purpose entity.TokenPurpose) error {
+	if a.Mailer == nil {
+		return nil
+	}
+	userID, err := uuid.FromString(user.ID)
+	if err != nil {
+		return err
+	}
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	ttl, link, msg := a.VerifyTTL, a.VerifyURL, verifyEmailMessage
+	if purpose == entity.TokenResetPassword {
+		ttl, link, msg = a.ResetTTL, a.ResetURL, resetPasswordMessage
+	}
+
+	now := time.Now()
+	err = a.auth.CreateUserToken(ctx, &entity.UserToken{
+		Hash:      hash,
+		UserID:    userID,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	link, err = withToken(link, token)
+	if err != nil {
+		return err
+	}
+	return a.Mailer.Send(ctx, msg(user.Email, link, ttl))
+}
+
+// VerifyEmail подтверждает почту по токену из письма
+func (a *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	const op = "Auth.VerifyEmail"
+
+	if token == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+
+	now := time.Now()
+	err := a.withinTx(ctx, func(ctx context.Context) error {
+		userID, err := a.auth.UseUserToken(ctx, hashToken(token), entity.TokenVerifyEmail, now)
+		if err != nil {
+			return err
+		}
+		return a.auth.SetEmailVerified(ctx, userID, now)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) || errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResendVerification отправляет новое письмо подтверждения. Для неизвестного
+// или уже подтверждённого адреса ничего не делает и не сообщает об этом,
+// чтобы по ответу нельзя было узнать, кто зарегистрирован
+func (a *AuthService) ResendVerification(ctx context.Context, email string) error {
+	const op = "Auth.ResendVerification"
+
+	user, err := a.auth.GetUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := a.sendUserToken(ctx, user, entity.TokenVerifyEmail); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ForgotPassword отправляет ссылку сброса пароля. Как и ResendVerification,
+// для неизвестного адреса молча завершается успешно
+func (a *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	const op = "Auth.ForgotPassword"
+
+	user, err := a.auth.GetUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := a.sendUserToken(ctx, user, entity.TokenResetPassword); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ResetPassword задаёт новый пароль по токену из письма. Ссылка пришла на почту,
+// поэтому почта заодно считается подтверждённой, а блокировка входа снимается. Все сессии
+// и персональные токены пользователя отзываются: если пароль сбрасывают из-за взлома,
+// злоумышленник теряет доступ
+func (a *AuthService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	const op = "Auth.ResetPassword"
+
+	if req.Token == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if req.Password == "" {
+		return fmt.Errorf("%s: %w", op, ErrInvalidPassword)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	err = a.withinTx(ctx, func(ctx context.Context) error {
+		userID, err := a.auth.UseUserToken(ctx, hashToken(req.Token), entity.TokenResetPassword, now)
+		if err != nil {
+			return err
+		}
+		if err := a.auth.UpdatePassword(ctx, userID, passHash); err != nil {
+			return err
+		}
+		if err := a.auth.SetEmailVerified(ctx, userID, now); err != nil {
+			return err
+		}
+		if err := a.auth.ResetFailedLogins(ctx, userID); err != nil {
+			return err
+		}
+		if err := a.auth.RevokeUserSessions(ctx, userID, now); err != nil {
+			return err
+		}
+		return a.auth.RevokeUserAccessTokens(ctx, userID, now)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) || errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// withToken добавляет токен в параметр token адреса из конфигурации
+func withToken(link, token string) (string, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("некорректный адрес ссылки %q: %w", link, err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func verifyEmailMessage(to, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Подтверждение адреса почты",
+		Body: fmt.Sprintf("Здравствуйте!\n\n"+
+			"Чтобы подтвердить адрес почты в AthleticHub, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.\n",
+			link, formatTTL(ttl)),
+	}
+}
+
+func resetPasswordMessage(to, link string, ttl time.Duration) mailer.Message {
+	return mailer.Message{
+		To:      to,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте!\n\n"+
+			"Для вашей учётной записи AthleticHub запрошен сброс пароля. Задать новый пароль можно по ссылке:\n%s\n\n"+
+			"Ссылка действует %s и подходит только для одного сброса. "+
+			"После смены пароля все устройства потребуют войти заново.\n"+
+			"Если вы не запрашивали сброс, проигнорируйте это письмо: пароль останется прежним.\n",
+			link, formatTTL(ttl)),
+	}
+}
+
+// formatTTL - срок действия ссылки для текста письма
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d ч", ttl/time.Hour)
+	}
+	return fmt.Sprintf("%d мин", ttl/time.Minute)
+}
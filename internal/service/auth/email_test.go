@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+	"workout/internal/adapters/mailer"
+	"workout/internal/adapters/memory"
+	"workout/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMailer запоминает отправленные письма
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (f *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// lastToken достаёт токен из ссылки последнего письма
+func (f *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+
+	require.NotEmpty(t, f.sent)
+	link := regexp.MustCompile(`https?://\S+`).FindString(f.sent[len(f.sent)-1].Body)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func newMailService(t *testing.T) (*AuthService, *fakeMailer) {
+	t.Helper()
+
+	mail := &fakeMailer{}
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	svc.Mailer = mail
+	svc.VerifyURL = "http://localhost/auth/verify"
+	svc.ResetURL = "http://localhost/reset-password?lang=ru"
+	svc.RequireVerifiedEmail = true
+	return svc, mail
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	svc, mail := newMailService(t)
+	login := dto.LoginRequest{Login: "runner@example.com", Password: "secret"}
+
+	_, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: login.Login, Password: login.Password})
+	require.NoError(t, err)
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, "runner@example.com", mail.sent[0].To)
+
+	_, err = svc.Login(ctx, login)
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+
+	// Подходит ссылка из любого письма, после подтверждения остальные гаснут
+	first := mail.lastToken(t)
+	require.NoError(t, svc.ResendVerification(ctx, login.Login))
+	require.Len(t, mail.sent, 2)
+
+	token := mail.lastToken(t)
+	require.NoError(t, svc.VerifyEmail(ctx, token))
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, token), ErrInvalidToken)
+	assert.ErrorIs(t, svc.VerifyEmail(ctx, first), ErrInvalidToken)
+
+	_, err = svc.Login(ctx, login)
+	require.NoError(t, err)
+
+	// Подтверждённым и неизвестным адресам письмо не уходит, но ответ тот же
+	require.NoError(t, svc.ResendVerification(ctx, login.Login))
+	require.NoError(t, svc.ResendVerification(ctx, "nobody@example.com"))
+	assert.Len(t, mail.sent, 2)
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	svc, mail := newMailService(t)
+	svc.RequireVerifiedEmail = false
+
+	_, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "old"})
+	require.NoError(t, err)
+	session, err := svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "old"})
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ForgotPassword(ctx, "nobody@example.com"))
+	require.Len(t, mail.sent, 1)
+
+	require.NoError(t, svc.ForgotPassword(ctx, "runner@example.com"))
+	require.Len(t, mail.sent, 2)
+	token := mail.lastToken(t)
+	assert.Contains(t, mail.sent[1].Body, "lang=ru")
+
+	assert.ErrorIs(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: "unknown", Password: "new"}), ErrInvalidToken)
+	// Токен подтверждения почты не подходит для сброса
+	assert.ErrorIs(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: tokenOf(t, mail, 0), Password: "new"}), ErrInvalidToken)
+
+	require.NoError(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "new"}))
+	assert.ErrorIs(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "other"}), ErrInvalidToken)
+
+	// Сессии, открытые со старым паролем, завершены
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: session.RefreshToken})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, err = svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "old"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "new"})
+	require.NoError(t, err)
+
+	user, err := svc.auth.GetUser(ctx, "runner@example.com")
+	require.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+}
+
+// tokenOf достаёт токен из i-го письма
+func tokenOf(t *testing.T, f *fakeMailer, i int) string {
+	t.Helper()
+
+	return (&fakeMailer{sent: f.sent[:i+1]}).lastToken(t)
+}
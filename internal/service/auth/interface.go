@@ -2,11 +2,63 @@ package auth
 
 import (
 	"context"
+	"time"
+	"workout/internal/adapters/mailer"
 	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
 )
 
 type Auth interface {
 	CreateUser(ctx context.Context, email string, passHash []byte) (string, error)
 	GetUser(ctx context.Context, email string) (*entity.User, error)
-	IsAdmin(ctx context.Context, userID int64) (bool, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*entity.User, error)
+	ListUsers(ctx context.Context) ([]entity.User, error)
+	SetEmailVerified(ctx context.Context, userID uuid.UUID, now time.Time) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passHash []byte) error
+
+	// Защита от подбора пароля: счётчик неудач подряд, блокировка и журнал попыток
+	AddFailedLogin(ctx context.Context, userID uuid.UUID) (int, error)
+	LockUser(ctx context.Context, userID uuid.UUID, until time.Time) error
+	ResetFailedLogins(ctx context.Context, userID uuid.UUID) error
+	AddLoginAttempt(ctx context.Context, attempt *entity.LoginAttempt) error
+	ListLoginAttempts(ctx context.Context, userID uuid.UUID, limit int) ([]entity.LoginAttempt, error)
+	CountLoginAttempts(ctx context.Context, email string, reason entity.LoginFailure) (int, time.Time, error)
+
+	// Персональные токены доступа для скриптов и устройств синхронизации
+	CreateAccessToken(ctx context.Context, token *entity.AccessToken, tokenHash string) error
+	GetAccessToken(ctx context.Context, tokenHash string) (*entity.AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID uuid.UUID) ([]entity.AccessToken, error)
+	RevokeAccessToken(ctx context.Context, userID, tokenID uuid.UUID, now time.Time) error
+	RevokeUserAccessTokens(ctx context.Context, userID uuid.UUID, now time.Time) error
+	TouchAccessToken(ctx context.Context, tokenID uuid.UUID, now time.Time) error
+
+	// Одноразовые токены из писем: подтверждение почты и сброс пароля
+	CreateUserToken(ctx context.Context, token *entity.UserToken) error
+	UseUserToken(ctx context.Context, tokenHash string, purpose entity.TokenPurpose, now time.Time) (uuid.UUID, error)
+
+	// Роли пользователя; права ролей задаются в entity
+	GetUserRoles(ctx context.Context, userID uuid.UUID) ([]entity.Role, error)
+	SetUserRoles(ctx context.Context, userID uuid.UUID, roles []entity.Role) error
+
+	// Сессии устройств и их refresh-токены
+	CreateSession(ctx context.Context, session *entity.Session, tokenHash string) error
+	GetSession(ctx context.Context, sessionID uuid.UUID) (*entity.Session, error)
+	ListSessions(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID, now time.Time) error
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID, now time.Time) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	UseRefreshToken(ctx context.Context, tokenHash string, now time.Time) error
+	AddRefreshToken(ctx context.Context, sessionID uuid.UUID, tokenHash string, now, expiresAt time.Time) error
+}
+
+// Transactor выполняет несколько вызовов репозитория атомарно.
+// Репозиторий узнаёт о транзакции из контекста, который получает fn
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Mailer отправляет пользователю письма со ссылками подтверждения почты и сброса пароля
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
 }
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/labstack/gommon/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginAttemptsLimit - сколько последних неудачных попыток входа показывать администратору
+const loginAttemptsLimit = 100
+
+var ErrAccountLocked = errors.New("account temporarily locked")
+
+// LockedError - вход заблокирован до Until после серии неудачных попыток
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrAccountLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// LockoutPolicy - прогрессивная блокировка входа. После Threshold неудачных попыток подряд
+// вход блокируется на Duration, каждая следующая неудача удваивает срок, но не больше MaxDuration.
+// Threshold == 0 отключает блокировку
+type LockoutPolicy struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// lockFor возвращает срок блокировки после failures неудач подряд; 0 - не блокировать
+func (p LockoutPolicy) lockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+	d := p.Duration
+	for i := p.Threshold; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if p.MaxDuration > 0 {
+		d = min(d, p.MaxDuration)
+	}
+	return d
+}
+
+// passwordFailed учитывает неверный пароль: увеличивает счётчик неудач, при необходимости
+// блокирует вход и пишет попытку в журнал. Ошибки только логируются: пользователь
+// в любом случае получает ErrInvalidCredentials
+func (a *AuthService) passwordFailed(ctx context.Context, req dto.LoginRequest, user *entity.User, now time.Time) {
+	userID := uuid.FromStringOrNil(user.ID)
+	err := a.withinTx(ctx, func(ctx context.Context) error {
+		failures, err := a.auth.AddFailedLogin(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if d := a.Lockout.lockFor(failures); d > 0 {
+			log.Warnf("user %s locked for %s after %d failed logins", user.ID, d, failures)
+			if err := a.auth.LockUser(ctx, userID, now.Add(d)); err != nil {
+				return err
+			}
+		}
+		return a.auth.AddLoginAttempt(ctx, loginAttempt(req, userID, entity.LoginInvalidPassword, now))
+	})
+	if err != nil {
+		log.Error("failed to record failed login:", err.Error())
+	}
+}
+
+// dummyHash - хэш для проверки пароля незарегистрированного адреса: без него
+// ответ для несуществующей учётной записи приходил бы заметно быстрее
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// unknownLogin обрабатывает вход с незарегистрированным адресом так же, как неверный пароль:
+// пароль проверяется с той же задержкой, а после Threshold попыток подряд адрес блокируется
+// на тот же срок. Иначе по ответу можно было бы узнать, какие адреса зарегистрированы.
+// Неудачи считаются по журналу попыток
+func (a *AuthService) unknownLogin(ctx context.Context, req dto.LoginRequest, now time.Time) error {
+	failures, last, err := a.auth.CountLoginAttempts(ctx, req.Login, entity.LoginUnknownUser)
+	if err != nil {
+		log.Error("failed to count login attempts:", err.Error())
+	}
+	if until := last.Add(a.Lockout.lockFor(failures)); failures > 0 && now.Before(until) {
+		a.auditLogin(ctx, req, uuid.Nil, entity.LoginLocked, now)
+		return &LockedError{Until: until}
+	}
+
+	_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(req.Password))
+	a.auditLogin(ctx, req, uuid.Nil, entity.LoginUnknownUser, now)
+	return ErrInvalidCredentials
+}
+
+// auditLogin пишет в журнал неудачную попытку, которая не меняет счётчик неудач
+func (a *AuthService) auditLogin(ctx context.Context, req dto.LoginRequest, userID uuid.UUID, reason entity.LoginFailure, now time.Time) {
+	if err := a.auth.AddLoginAttempt(ctx, loginAttempt(req, userID, reason, now)); err != nil {
+		log.Error("failed to record login attempt:", err.Error())
+	}
+}
+
+func loginAttempt(req dto.LoginRequest, userID uuid.UUID, reason entity.LoginFailure, now time.Time) *entity.LoginAttempt {
+	return &entity.LoginAttempt{
+		Email:     req.Login,
+		UserID:    userID,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+}
+
+// LoginAttempts возвращает последние неудачные попытки входа пользователя, новые первыми
+func (a *AuthService) LoginAttempts(ctx context.Context, userID string) ([]*dto.LoginAttemptDTO, error) {
+	const op = "Auth.LoginAttempts"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidUserID)
+	}
+
+	attempts, err := a.auth.ListLoginAttempts(ctx, uid, loginAttemptsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]*dto.LoginAttemptDTO, 0, len(attempts))
+	for _, at := range attempts {
+		result = append(result, &dto.LoginAttemptDTO{
+			Email:     at.Email,
+			IP:        at.IP,
+			UserAgent: at.UserAgent,
+			Reason:    string(at.Reason),
+			CreatedAt: at.CreatedAt,
+		})
+	}
+	return result, nil
+}
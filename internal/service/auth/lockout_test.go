@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutPolicy(t *testing.T) {
+	p := LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: 5 * time.Minute}
+
+	for failures, want := range map[int]time.Duration{
+		1: 0,
+		2: 0,
+		3: time.Minute,
+		4: 2 * time.Minute,
+		5: 4 * time.Minute,
+		6: 5 * time.Minute,
+		9: 5 * time.Minute,
+	} {
+		assert.Equal(t, want, p.lockFor(failures), failures)
+	}
+	assert.Zero(t, LockoutPolicy{}.lockFor(100))
+}
+
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	svc, mail := newMailService(t)
+	svc.RequireVerifiedEmail = false
+	svc.Lockout = LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+
+	id, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+	good := dto.LoginRequest{Login: "runner@example.com", Password: "secret", IP: "10.0.0.1"}
+	bad := dto.LoginRequest{Login: "runner@example.com", Password: "guess", IP: "10.0.0.2", UserAgent: "curl"}
+
+	// Удачный вход обнуляет счётчик неудач подряд
+	for range 2 {
+		_, err = svc.Login(ctx, bad)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	_, err = svc.Login(ctx, good)
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err = svc.Login(ctx, bad)
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// Во время блокировки не подходит и верный пароль
+	_, err = svc.Login(ctx, good)
+	require.ErrorIs(t, err, ErrAccountLocked)
+	var locked *LockedError
+	require.ErrorAs(t, err, &locked)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), locked.Until, 5*time.Second)
+
+	attempts, err := svc.LoginAttempts(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, attempts, 6)
+	assert.Equal(t, "locked", attempts[0].Reason)
+	assert.Equal(t, "10.0.0.1", attempts[0].IP)
+	assert.Equal(t, "invalid_password", attempts[1].Reason)
+	assert.Equal(t, "curl", attempts[1].UserAgent)
+
+	// Сброс пароля по почте снимает блокировку
+	require.NoError(t, svc.ForgotPassword(ctx, "runner@example.com"))
+	require.NoError(t, svc.ResetPassword(ctx, dto.ResetPasswordRequest{Token: mail.lastToken(t), Password: "new-secret"}))
+	_, err = svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "new-secret"})
+	require.NoError(t, err)
+
+	_, err = svc.LoginAttempts(ctx, "not-a-uuid")
+	assert.ErrorIs(t, err, ErrInvalidUserID)
+}
+
+func TestLoginLockout_UnknownUser(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newMailService(t)
+	svc.RequireVerifiedEmail = false
+	svc.Lockout = LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+
+	_, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	// Существующая и несуществующая учётные записи отвечают одинаково, в том числе блокировкой
+	for _, login := range []string{"runner@example.com", "ghost@example.com"} {
+		req := dto.LoginRequest{Login: login, Password: "guess"}
+		for range 3 {
+			_, err = svc.Login(ctx, req)
+			assert.ErrorIs(t, err, ErrInvalidCredentials, login)
+		}
+
+		_, err = svc.Login(ctx, req)
+		var locked *LockedError
+		require.ErrorAs(t, err, &locked, login)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), locked.Until, 5*time.Second, login)
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+var (
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrCannotDemoteSelf = errors.New("cannot remove own admin role")
+)
+
+// defaultRoles - роли нового пользователя
+func (a *AuthService) defaultRoles(email string) []entity.Role {
+	roles := []entity.Role{entity.RoleAthlete}
+	if slices.ContainsFunc(a.AdminEmails, func(admin string) bool { return strings.EqualFold(admin, email) }) {
+		roles = append(roles, entity.RoleAdmin)
+	}
+	return roles
+}
+
+// Users возвращает всех пользователей с их ролями
+func (a *AuthService) Users(ctx context.Context) ([]*dto.UserDTO, error) {
+	const op = "Auth.Users"
+
+	users, err := a.auth.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]*dto.UserDTO, 0, len(users))
+	for _, u := range users {
+		result = append(result, userToDTO(u.ID, u.Email, u.Name, u.Roles))
+	}
+	return result, nil
+}
+
+// SetRoles заменяет роли пользователя userID. actorID - администратор, выполняющий изменение:
+// снять роль admin с самого себя нельзя, чтобы не остаться без администратора.
+// Новые роли попадают в токены при следующем обновлении через /auth/refresh
+func (a *AuthService) SetRoles(ctx context.Context, actorID, userID string, req dto.UserRolesRequest) (*dto.UserDTO, error) {
+	const op = "Auth.SetRoles"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidUserID)
+	}
+
+	if len(req.Roles) == 0 {
+		return nil, fmt.Errorf("%s: %w: at least one role is required", op, ErrInvalidRole)
+	}
+	roles := make([]entity.Role, 0, len(req.Roles))
+	for _, name := range req.Roles {
+		r := entity.Role(name)
+		if !r.Valid() {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidRole, name)
+		}
+		if !slices.Contains(roles, r) {
+			roles = append(roles, r)
+		}
+	}
+	if userID == actorID && !slices.Contains(roles, entity.RoleAdmin) {
+		return nil, fmt.Errorf("%s: %w", op, ErrCannotDemoteSelf)
+	}
+
+	if err := a.auth.SetUserRoles(ctx, uid, roles); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.auth.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if roles, err = a.auth.GetUserRoles(ctx, uid); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return userToDTO(user.ID, user.Email, user.Name, roles), nil
+}
+
+func userToDTO(id, email, name string, roles []entity.Role) *dto.UserDTO {
+	u := &dto.UserDTO{ID: id, Email: email, Name: name, Roles: make([]string, 0, len(roles))}
+	for _, r := range roles {
+		u.Roles = append(u.Roles, string(r))
+	}
+	return u
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/memory"
+	"workout/internal/dto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRoles читает роли из access-токена без проверки подписи
+func tokenRoles(t *testing.T, token string) []any {
+	t.Helper()
+
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	require.NoError(t, err)
+	roles, _ := claims["roles"].([]any)
+	return roles
+}
+
+func TestRegisterAssignsRoles(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	svc.AdminEmails = []string{"Owner@example.com"}
+
+	runner, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+	owner, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "owner@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	isAdmin, err := svc.IsAdmin(ctx, runner)
+	require.NoError(t, err)
+	assert.False(t, isAdmin)
+	isAdmin, err = svc.IsAdmin(ctx, owner)
+	require.NoError(t, err)
+	assert.True(t, isAdmin)
+
+	tokens, err := svc.Login(ctx, dto.LoginRequest{Login: "owner@example.com", Password: "secret"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []any{"admin", "athlete"}, tokenRoles(t, tokens.Token))
+}
+
+func TestSetRoles(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	svc.AdminEmails = []string{"owner@example.com"}
+
+	owner, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "owner@example.com", Password: "secret"})
+	require.NoError(t, err)
+	coach, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "coach@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	login, err := svc.Login(ctx, dto.LoginRequest{Login: "coach@example.com", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"athlete"}, tokenRoles(t, login.Token))
+
+	user, err := svc.SetRoles(ctx, owner, coach, dto.UserRolesRequest{Roles: []string{"coach", "athlete", "coach"}})
+	require.NoError(t, err)
+	assert.Equal(t, "coach@example.com", user.Email)
+	assert.Equal(t, []string{"athlete", "coach"}, user.Roles)
+
+	// Новые роли попадают в токен при обновлении
+	refreshed, err := svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []any{"athlete", "coach"}, tokenRoles(t, refreshed.Token))
+
+	_, err = svc.SetRoles(ctx, owner, coach, dto.UserRolesRequest{Roles: []string{"superuser"}})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = svc.SetRoles(ctx, owner, coach, dto.UserRolesRequest{})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = svc.SetRoles(ctx, owner, owner, dto.UserRolesRequest{Roles: []string{"athlete"}})
+	assert.ErrorIs(t, err, ErrCannotDemoteSelf)
+	_, err = svc.SetRoles(ctx, owner, "00000000-0000-0000-0000-000000000001", dto.UserRolesRequest{Roles: []string{"athlete"}})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	users, err := svc.Users(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "coach@example.com", users[0].Email)
+	assert.Equal(t, []string{"admin", "athlete"}, users[1].Roles)
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"workout/internal/adapters/storage"
+	"workout/internal/dto"
+	"workout/internal/entity"
+	"workout/internal/lib/jwt"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/labstack/gommon/log"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidSessionID    = errors.New("invalid session id")
+)
+
+// startSession открывает сессию устройства и выдаёт первую пару токенов
+func (a *AuthService) startSession(ctx context.Context, user *entity.User, userAgent, ip string) (*dto.LoginResponse, error) {
+	userID, err := uuid.FromString(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &entity.Session{
+		ID:         sessionID,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(a.refreshTTL),
+	}
+	if err := a.auth.CreateSession(ctx, session, hash); err != nil {
+		return nil, err
+	}
+	if user.Roles, err = a.auth.GetUserRoles(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return a.issueTokens(user, sessionID, refresh)
+}
+
+// Refresh меняет refresh-токен на новую пару токенов. Каждый refresh-токен одноразовый:
+// повторное предъявление уже обменянного токена значит, что его перехватили,
+// поэтому сессия отзывается целиком вместе со всеми выданными ей токенами
+func (a *AuthService) Refresh(ctx context.Context, req dto.RefreshRequest) (*dto.LoginResponse, error) {
+	const op = "Auth.Refresh"
+
+	if req.RefreshToken == "" {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+
+	var (
+		user    *entity.User
+		session *entity.Session
+		refresh string
+	)
+	now := time.Now()
+	hash := hashToken(req.RefreshToken)
+
+	err := a.withinTx(ctx, func(ctx context.Context) error {
+		token, err := a.auth.GetRefreshToken(ctx, hash)
+		if err != nil {
+			if errors.Is(err, storage.ErrSessionNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		if session, err = a.auth.GetSession(ctx, token.SessionID); err != nil {
+			return err
+		}
+		if !token.UsedAt.IsZero() {
+			return ErrRefreshTokenReused
+		}
+		if !session.Active(now) {
+			return ErrInvalidRefreshToken
+		}
+
+		// Параллельный запрос мог обменять тот же токен между чтением и этой записью
+		if err := a.auth.UseRefreshToken(ctx, hash, now); err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenUsed) {
+				return ErrRefreshTokenReused
+			}
+			return err
+		}
+
+		var newHash string
+		if refresh, newHash, err = newRefreshToken(); err != nil {
+			return err
+		}
+		if err := a.auth.AddRefreshToken(ctx, session.ID, newHash, now, now.Add(a.refreshTTL)); err != nil {
+			return err
+		}
+
+		// Роли читаются заново: изменения администратора вступают в силу при обновлении токенов
+		if user, err = a.auth.GetUserByID(ctx, session.UserID); err != nil {
+			return err
+		}
+		user.Roles, err = a.auth.GetUserRoles(ctx, session.UserID)
+		return err
+	})
+	if err != nil {
+		// Отзыв - вне транзакции: она откатывается вместе с ошибкой
+		if errors.Is(err, ErrRefreshTokenReused) {
+			log.Warn("refresh token reuse detected, revoking session ", session.ID)
+			if err := a.auth.RevokeSession(ctx, session.UserID, session.ID, now); err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			return nil, fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tokens, err := a.issueTokens(user, session.ID, refresh)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return tokens, nil
+}
+
+// Logout завершает сессию, которой выдан access-токен: её refresh-токены больше не принимаются
+func (a *AuthService) Logout(ctx context.Context, userID, sessionID string) error {
+	const op = "Auth.Logout"
+
+	if err := a.RevokeSession(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// Sessions возвращает активные сессии пользователя; currentID - сессия текущего запроса
+func (a *AuthService) Sessions(ctx context.Context, userID, currentID string) ([]*dto.SessionDTO, error) {
+	const op = "Auth.Sessions"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessions, err := a.auth.ListSessions(ctx, uid, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]*dto.SessionDTO, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, &dto.SessionDTO{
+			ID:         s.ID.String(),
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID.String() == currentID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession завершает сессию пользователя, например на потерянном устройстве
+func (a *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	const op = "Auth.RevokeSession"
+
+	uid, err := uuid.FromString(userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	sid, err := uuid.FromString(sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, ErrInvalidSessionID)
+	}
+
+	if err := a.auth.RevokeSession(ctx, uid, sid, time.Now()); err != nil {
+		if errors.Is(err, storage.ErrSessionNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrSessionNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// issueTokens выпускает access-токен сессии и возвращает его вместе с refresh-токеном
+func (a *AuthService) issueTokens(user *entity.User, sessionID uuid.UUID, refresh string) (*dto.LoginResponse, error) {
+	token, err := jwt.NewToken(user, sessionID.String(), a.keys, a.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(a.tokenTTL / time.Second),
+	}, nil
+}
+
+// newRefreshToken генерирует случайный refresh-токен и его хэш для хранения
+func newRefreshToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken - sha256 токена. У токена 256 бит случайности, поэтому медленный хэш не нужен
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// JWKS возвращает открытые ключи проверки access-токенов
+func (a *AuthService) JWKS() jwt.JWKS {
+	return a.keys.JWKS()
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+	"workout/internal/adapters/memory"
+	"workout/internal/dto"
+	"workout/internal/lib/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKeys(t *testing.T) *jwt.KeySet {
+	t.Helper()
+
+	keys, err := jwt.NewEphemeralKeys()
+	require.NoError(t, err)
+	return keys
+}
+
+func newTestService(t *testing.T) (*AuthService, *dto.LoginResponse) {
+	t.Helper()
+	ctx := context.Background()
+
+	svc := NewAuthService(memory.NewMemoryAdapter(), testKeys(t), time.Minute, time.Hour)
+	_, err := svc.RegisterNewUser(ctx, dto.RegisterRequest{Login: "runner@example.com", Password: "secret"})
+	require.NoError(t, err)
+
+	tokens, err := svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "secret", UserAgent: "iPhone"})
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.RefreshToken)
+	return svc, tokens
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	svc, login := newTestService(t)
+
+	first, err := svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, first.RefreshToken)
+	assert.NotEmpty(t, first.Token)
+	assert.Equal(t, int64(60), first.ExpiresIn)
+
+	second, err := svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: first.RefreshToken})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: "unknown"})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	svc, login := newTestService(t)
+
+	// Клиент получил новую пару, а перехваченный старый токен предъявлен повторно
+	fresh, err := svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// Вся сессия отозвана: новый токен того же семейства тоже не принимается
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: fresh.RefreshToken})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestLogoutAndSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, phone := newTestService(t)
+
+	laptop, err := svc.Login(ctx, dto.LoginRequest{Login: "runner@example.com", Password: "secret", UserAgent: "Firefox"})
+	require.NoError(t, err)
+
+	user, err := svc.auth.GetUser(ctx, "runner@example.com")
+	require.NoError(t, err)
+	token, err := svc.auth.GetRefreshToken(ctx, hashToken(laptop.RefreshToken))
+	require.NoError(t, err)
+	laptopID := token.SessionID.String()
+
+	sessions, err := svc.Sessions(ctx, user.ID, laptopID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, s := range sessions {
+		assert.Equal(t, s.ID == laptopID, s.Current)
+	}
+
+	require.NoError(t, svc.Logout(ctx, user.ID, laptopID))
+	assert.ErrorIs(t, svc.Logout(ctx, user.ID, laptopID), ErrSessionNotFound)
+	assert.ErrorIs(t, svc.RevokeSession(ctx, user.ID, "not-a-uuid"), ErrInvalidSessionID)
+
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: laptop.RefreshToken})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = svc.Refresh(ctx, dto.RefreshRequest{RefreshToken: phone.RefreshToken})
+	assert.NoError(t, err)
+
+	sessions, err = svc.Sessions(ctx, user.ID, laptopID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "iPhone", sessions[0].UserAgent)
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"github.com/muktihari/fit/kit/semicircles"
 	"github.com/muktihari/fit/profile/typedef"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -93,3 +95,30 @@ func GetSportName(sport typedef.Sport) string {
 		return fmt.Sprintf("Неизвестный спорт (%d)", sport)
 	}
 }
+
+// SemicirclesToDegrees переводит координату FIT (semicircles) в градусы
+func SemicirclesToDegrees(v int32) float64 {
+	return semicircles.ToDegrees(v)
+}
+
+// DegreesToSemicircles переводит координату в градусах в semicircles FIT
+func DegreesToSemicircles(v float64) int32 {
+	return semicircles.ToSemicircles(v)
+}
+
+// AltitudeToMeters переводит высоту FIT (м * 5 + 500) в метры
+func AltitudeToMeters(v uint16) float64 {
+	return float64(v)/5.0 - 500.0
+}
+
+// MetersToAltitude переводит высоту в метрах в формат FIT (м * 5 + 500)
+func MetersToAltitude(m float64) uint16 {
+	v := math.Round((m + 500.0) * 5.0)
+	if v <= 0 {
+		return 0
+	}
+	if v >= math.MaxUint16 {
+		return math.MaxUint16 - 1
+	}
+	return uint16(v)
+}